@@ -68,6 +68,11 @@ type BaseConfig struct {
 	EnableOneShotSenderReportSync bool                                `yaml:"enable_one_shot_sender_report_sync"` // temporary rollout flag enabling one-shot sender report correction for room composite / track requests that previously used audio PTS adjustment disabling
 	EnableSyncEngine              bool                                `yaml:"enable_sync_engine"`                 // use Chrome-inspired sync engine for improved cross-participant alignment and A/V sync
 	AudioTempoController          AudioTempoController                `yaml:"audio_tempo_controller"`             // audio tempo controller
+	FragmentedMP4                 FragmentedMP4Config                 `yaml:"fragmented_mp4"`                     // write mp4 files as fragments by default, so that they remain playable if the handler is killed
+	ImageOutput                   ImageOutputConfig                   `yaml:"image_output"`                       // image encoder settings
+	PreviewClip                   PreviewClipConfig                   `yaml:"preview_clip"`                       // animated preview defaults, for file outputs requesting one
+	WebsocketReconnect            WebsocketReconnectConfig            `yaml:"websocket_reconnect"`                // reconnect websocket outputs when the connection drops
+	SRTListener                   SRTListenerConfig                   `yaml:"srt_listener"`                       // ports srt outputs can listen on for receivers to connect
 	TestOverrides                 TestOverrides                       `yaml:"test_overrides"`                     // set of config overrides for testing purposes
 }
 
@@ -102,6 +107,52 @@ type AudioTempoController struct {
 	AdjustmentRate float64 `yaml:"adjustment_rate"` // rate at which to adjust the tempo to compensate for PTS drift
 }
 
+type FragmentedMP4Config struct {
+	Enabled          bool          `yaml:"enabled"`
+	FragmentDuration time.Duration `yaml:"fragment_duration"` // defaults to 2s
+	FastStart        bool          `yaml:"fast_start"`        // remux to a regular mp4 with the moov atom first before upload
+}
+
+type ImageOutputConfig struct {
+	Quality          int               `yaml:"quality"`           // jpeg and webp quality, 1-100. 100 produces lossless webp images
+	CompressionLevel int               `yaml:"compression_level"` // png compression level, 1-9. Defaults to 6
+	SpriteColumns    int               `yaml:"sprite_columns"`    // sprite sheet columns, defaults to 5
+	SpriteRows       int               `yaml:"sprite_rows"`       // sprite sheet rows, defaults to 5
+	SceneChange      SceneChangeConfig `yaml:"scene_change"`      // default for the scene_change image option, ignored by sprite sheets
+	Dedupe           ImageDedupeConfig `yaml:"dedupe"`            // default for the dedupe image options, jpeg and png images only
+	EmbedMetadata    bool              `yaml:"embed_metadata"`    // write capture time and source as EXIF and XMP by default, jpeg and png images only
+}
+
+type ImageDedupeConfig struct {
+	Enabled   bool `yaml:"enabled"`   // skip uploading images which match the previous upload
+	Threshold int  `yaml:"threshold"` // maximum number of differing perceptual hash bits, 0-64. 0 requires identical hashes
+}
+
+type SceneChangeConfig struct {
+	Threshold   float64       `yaml:"threshold"`    // mean luma difference from the last captured image, 0-1. 0 disables scene change capture by default
+	MinInterval time.Duration `yaml:"min_interval"` // minimum time between images, defaults to 1s
+	MaxInterval time.Duration `yaml:"max_interval"` // if set, an image is captured at least this often even without scene changes
+}
+
+type PreviewClipConfig struct {
+	Format    string        `yaml:"format"`     // webp, gif or mp4 (silent). Defaults to webp
+	Duration  time.Duration `yaml:"duration"`   // preview length, defaults to 3s
+	Width     int32         `yaml:"width"`      // defaults to 320, the height follows the aspect ratio
+	FrameRate int32         `yaml:"frame_rate"` // defaults to 10
+}
+
+type WebsocketReconnectConfig struct {
+	MaxAttempts  int           `yaml:"max_attempts"` // attempts before the output fails, defaults to 5. -1 disables reconnection
+	MaxBackoff   time.Duration `yaml:"max_backoff"`  // the delay between attempts doubles from 500ms up to this, defaults to 8s
+	BufferWindow time.Duration `yaml:"-"`            // set per output with the buffer_window url parameter
+}
+
+type SRTListenerConfig struct {
+	Address string `yaml:"address"`  // address receivers connect to, reported in stream info. Defaults to the node's ip
+	PortMin int    `yaml:"port_min"` // listeners can use any free port unless a range is configured
+	PortMax int    `yaml:"port_max"`
+}
+
 func (c *BaseConfig) InitLogger(serviceName string, values ...interface{}) error {
 	_, exists := os.LookupEnv("GST_DEBUG")
 
@@ -16,7 +16,9 @@ package config
 
 import (
 	"os"
+	"path"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -95,6 +97,578 @@ func TestSegmentNaming(t *testing.T) {
 	}
 }
 
+func TestSegmentContainer(t *testing.T) {
+	p := &PipelineConfig{Info: &livekit.EgressInfo{EgressId: "egress_ID"}}
+	seg := &livekit.SegmentedFileOutput{
+		FilenamePrefix: "filename",
+		PlaylistName:   "playlist.m3u8?container=fmp4",
+	}
+	o, err := p.getSegmentConfig(seg, seg)
+	require.NoError(t, err)
+	require.Equal(t, types.OutputTypeFMP4, o.SegmentContainer)
+	require.Equal(t, "filename", o.SegmentPrefix)
+	require.Equal(t, "playlist.m3u8", o.PlaylistFilename)
+	require.Equal(t, "filename_init.mp4", o.InitSegmentFilename())
+	require.Equal(t, "playlist.mpd", o.MPDFilename())
+	require.Equal(t, "", o.LiveMPDFilename())
+
+	seg.PlaylistName = "playlist"
+	o, err = p.getSegmentConfig(seg, seg)
+	require.NoError(t, err)
+	require.Equal(t, types.OutputTypeTS, o.SegmentContainer)
+	require.Equal(t, "", o.InitSegmentFilename())
+	require.Equal(t, "", o.MPDFilename())
+
+	// the prefix extension does not select the container
+	seg.FilenamePrefix = "filename.m4s"
+	o, err = p.getSegmentConfig(seg, seg)
+	require.NoError(t, err)
+	require.Equal(t, types.OutputTypeTS, o.SegmentContainer)
+
+	// names containing a '?' without options are used as they are
+	seg.FilenamePrefix = "filename?"
+	seg.PlaylistName = "what?.m3u8"
+	o, err = p.getSegmentConfig(seg, seg)
+	require.NoError(t, err)
+	require.Equal(t, types.OutputTypeTS, o.SegmentContainer)
+	require.Equal(t, "filename?", o.SegmentPrefix)
+	require.Equal(t, "what?.m3u8", o.PlaylistFilename)
+
+	seg.PlaylistName = "playlist?format=fmp4"
+	o, err = p.getSegmentConfig(seg, seg)
+	require.NoError(t, err)
+	require.Equal(t, "playlist?format=fmp4.m3u8", o.PlaylistFilename)
+
+	// invalid, repeated and conflicting options are rejected
+	for _, names := range [][2]string{
+		{"filename", "playlist?container=mkv"},
+		{"filename", "playlist?container=fmp4&container=ts"},
+		{"filename?container=ts", "playlist?container=fmp4"},
+	} {
+		seg.FilenamePrefix = names[0]
+		seg.PlaylistName = names[1]
+		_, err = p.getSegmentConfig(seg, seg)
+		require.Error(t, err)
+	}
+
+	// opus is only supported in fMP4 segments
+	for _, container := range []types.OutputType{types.OutputTypeTS, types.OutputTypeFMP4} {
+		p = &PipelineConfig{
+			Outputs: map[types.EgressType][]OutputConfig{
+				types.EgressTypeSegments: {
+					&SegmentConfig{outputConfig: outputConfig{OutputType: types.OutputTypeHLS}, SegmentContainer: container},
+				},
+			},
+		}
+		p.AudioEnabled = true
+		p.AudioOutCodec = types.MimeTypeOpus
+		p.Info = &livekit.EgressInfo{}
+
+		err = p.validateAndUpdateOutputParams()
+		if container == types.OutputTypeFMP4 {
+			require.NoError(t, err)
+		} else {
+			require.Error(t, err)
+		}
+	}
+}
+
+func TestSegmentLowLatencyHLS(t *testing.T) {
+	p := &PipelineConfig{Info: &livekit.EgressInfo{EgressId: "egress_ID"}}
+
+	seg := &livekit.SegmentedFileOutput{
+		PlaylistName:     "playlist?container=fmp4",
+		LivePlaylistName: "live",
+	}
+	o, err := p.getSegmentConfig(seg, seg)
+	require.NoError(t, err)
+	require.False(t, o.LowLatency())
+
+	seg.PlaylistName = "playlist?container=fmp4&part_duration=500ms"
+	o, err = p.getSegmentConfig(seg, seg)
+	require.NoError(t, err)
+	require.True(t, o.LowLatency())
+	require.Equal(t, 500*time.Millisecond, o.PartDuration)
+	require.Equal(t, 1500*time.Millisecond, o.PartHoldBack)
+
+	seg.PlaylistName = "playlist?container=fmp4&part_duration=1s&part_hold_back=2.5s"
+	o, err = p.getSegmentConfig(seg, seg)
+	require.NoError(t, err)
+	require.Equal(t, 2500*time.Millisecond, o.PartHoldBack)
+
+	for _, playlistName := range []string{
+		// parts are fragments of fMP4 segments
+		"playlist?part_duration=1s",
+		"playlist?container=fmp4&part_duration=1",
+		"playlist?container=fmp4&part_duration=3s",
+		"playlist?container=fmp4&part_duration=1s&part_hold_back=1s",
+		"playlist?container=fmp4&part_hold_back=3s",
+	} {
+		seg.PlaylistName = playlistName
+		_, err = p.getSegmentConfig(seg, seg)
+		require.Error(t, err, playlistName)
+	}
+
+	// parts are only published in live playlists
+	seg.PlaylistName = "playlist?container=fmp4&part_duration=1s"
+	seg.LivePlaylistName = ""
+	_, err = p.getSegmentConfig(seg, seg)
+	require.Error(t, err)
+}
+
+func TestSegmentRenditions(t *testing.T) {
+	p := &PipelineConfig{Info: &livekit.EgressInfo{EgressId: "egress_ID"}}
+	p.VideoDecoding = true
+	p.Width = 1920
+	p.Height = 1080
+	p.VideoBitrate = 4500
+
+	seg := &livekit.SegmentedFileOutput{
+		FilenamePrefix:   "prefix",
+		PlaylistName:     "playlist.m3u8?renditions=1920x1080@4500,1280x720@2500,640x360",
+		LivePlaylistName: "live.m3u8",
+	}
+	require.Equal(t, 3, RenditionCount(seg))
+	o, err := p.getSegmentConfig(seg, seg)
+	require.NoError(t, err)
+
+	// a rendition the size of the output is the main variant
+	renditions, err := p.getRenditionConfigs(o)
+	require.NoError(t, err)
+	require.Len(t, renditions, 2)
+	require.Len(t, o.Variants, 2)
+	require.Equal(t, "playlist_master.m3u8", o.MasterPlaylistFilename())
+	require.Equal(t, "live_master.m3u8", o.LiveMasterPlaylistFilename())
+
+	r := renditions[0].(*SegmentConfig)
+	require.Equal(t, "720p", r.RenditionName())
+	require.Equal(t, "playlist_720p.m3u8", r.PlaylistFilename)
+	require.Equal(t, "live_720p.m3u8", r.LivePlaylistFilename)
+	require.Equal(t, "prefix_720p", r.SegmentPrefix)
+	require.Equal(t, int32(2500), r.Rendition.VideoBitrate)
+	require.Equal(t, "", r.MasterPlaylistFilename())
+	require.True(t, r.DisableManifest)
+
+	// the default bitrate is scaled by pixel count, with a floor
+	require.Equal(t, int32(500), renditions[1].(*SegmentConfig).Rendition.VideoBitrate)
+	seg.PlaylistName = "playlist.m3u8?renditions=320x180"
+	o, err = p.getSegmentConfig(seg, seg)
+	require.NoError(t, err)
+	renditions, err = p.getRenditionConfigs(o)
+	require.NoError(t, err)
+	require.Equal(t, int32(300), renditions[0].(*SegmentConfig).Rendition.VideoBitrate)
+
+	// renditions larger than the output are rejected
+	for _, playlistName := range []string{
+		"playlist.m3u8?renditions=2560x1440",
+		"playlist.m3u8?renditions=1080x1920",
+	} {
+		seg.PlaylistName = playlistName
+		o, err = p.getSegmentConfig(seg, seg)
+		require.NoError(t, err)
+		_, err = p.getRenditionConfigs(o)
+		require.Error(t, err, playlistName)
+	}
+
+	// passthrough outputs cannot be scaled
+	p.VideoDecoding = false
+	o, err = p.getSegmentConfig(seg, seg)
+	require.NoError(t, err)
+	renditions, err = p.getRenditionConfigs(o)
+	require.NoError(t, err)
+	require.Empty(t, renditions)
+	require.Equal(t, "", o.MasterPlaylistFilename())
+
+	for _, playlistName := range []string{
+		"playlist.m3u8?renditions=720p",
+		"playlist.m3u8?renditions=1280x720@",
+		"playlist.m3u8?renditions=1280x0",
+		"playlist.m3u8?renditions=1280x720,960x720",
+	} {
+		seg.PlaylistName = playlistName
+		_, err = p.getSegmentConfig(seg, seg)
+		require.Error(t, err, playlistName)
+		require.Equal(t, 0, RenditionCount(seg))
+	}
+}
+
+func TestFragmentedMP4(t *testing.T) {
+	p := &PipelineConfig{
+		Info:   &livekit.EgressInfo{RoomName: "test-room"},
+		TmpDir: t.TempDir(),
+	}
+
+	file := &livekit.EncodedFileOutput{
+		FileType: livekit.EncodedFileType_MP4,
+		Filepath: "recording.mp4",
+	}
+	o, err := p.getEncodedFileConfig(file)
+	require.NoError(t, err)
+	require.False(t, o.IsFragmented())
+
+	p.FragmentedMP4.Enabled = true
+	p.FragmentedMP4.FastStart = true
+	o, err = p.getEncodedFileConfig(file)
+	require.NoError(t, err)
+	require.True(t, o.IsFragmented())
+	require.True(t, o.FastStart)
+	require.Equal(t, 2*time.Second, o.FragmentDuration)
+	require.Equal(t, o.LocalFilepath+".mrf", o.MoovRecoveryFilepath())
+
+	// file options override the service config
+	file.Filepath = "recording.mp4?fragmented=0"
+	o, err = p.getEncodedFileConfig(file)
+	require.NoError(t, err)
+	require.False(t, o.IsFragmented())
+	require.Equal(t, "recording.mp4", o.StorageFilepath)
+
+	p.FragmentedMP4 = FragmentedMP4Config{}
+	file.Filepath = "recording.mp4?fragmented=1&faststart=1"
+	o, err = p.getEncodedFileConfig(file)
+	require.NoError(t, err)
+	require.True(t, o.IsFragmented())
+	require.True(t, o.FastStart)
+
+	file.Filepath = "recording.mp4?fragmented"
+	o, err = p.getEncodedFileConfig(file)
+	require.NoError(t, err)
+	require.True(t, o.IsFragmented())
+	require.False(t, o.FastStart)
+
+	for _, filepath := range []string{
+		"recording.mp4?faststart=1",
+		"recording.mp4?fragmented=maybe",
+	} {
+		file.Filepath = filepath
+		_, err = p.getEncodedFileConfig(file)
+		require.Error(t, err, filepath)
+	}
+
+	// only mp4 files are fragmented
+	file.FileType = livekit.EncodedFileType_OGG
+	file.Filepath = "recording.ogg?fragmented=1"
+	o, err = p.getEncodedFileConfig(file)
+	require.NoError(t, err)
+	require.False(t, o.IsFragmented())
+}
+
+func TestImageSceneChange(t *testing.T) {
+	p := &PipelineConfig{Info: &livekit.EgressInfo{EgressId: "egress_ID"}, TmpDir: t.TempDir()}
+
+	o, err := p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: "slides/room"}, &livekit.ImageOutput{})
+	require.NoError(t, err)
+	require.False(t, o.IsSceneChange())
+
+	o, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: "slides/room?scene_change"}, &livekit.ImageOutput{})
+	require.NoError(t, err)
+	require.Equal(t, 0.1, o.SceneChangeThreshold)
+
+	for _, prefix := range []string{
+		"slides/room?scene_change=high",
+		"slides/room?scene_change=1.5",
+	} {
+		_, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: prefix}, &livekit.ImageOutput{})
+		require.Error(t, err, prefix)
+	}
+
+	p.ImageOutput.SceneChange.Threshold = 0.05
+	o, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: "slides/room", CaptureInterval: 30}, &livekit.ImageOutput{})
+	require.NoError(t, err)
+	require.True(t, o.IsSceneChange())
+	require.Equal(t, time.Second, o.MinCaptureInterval)
+	require.Zero(t, o.MaxCaptureInterval)
+
+	p.ImageOutput.SceneChange.MaxInterval = 30 * time.Second
+	o, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: "slides/room"}, &livekit.ImageOutput{})
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Second, o.MaxCaptureInterval)
+
+	// sprite sheets are captured at a fixed interval
+	o, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: "slides/room?sprite"}, &livekit.ImageOutput{})
+	require.NoError(t, err)
+	require.False(t, o.IsSceneChange())
+	_, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: "slides/room?sprite&scene_change"}, &livekit.ImageOutput{})
+	require.Error(t, err)
+
+	// the threshold can be set per request, 0 disables scene change capture
+	o, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: "slides/room?scene_change=0.2"}, &livekit.ImageOutput{})
+	require.NoError(t, err)
+	require.Equal(t, 0.2, o.SceneChangeThreshold)
+	o, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: "slides/room?sprite&scene_change=0"}, &livekit.ImageOutput{})
+	require.NoError(t, err)
+	require.False(t, o.IsSceneChange())
+	require.True(t, o.IsSpriteSheet())
+
+	p.ImageOutput.SceneChange.MinInterval = time.Minute
+	_, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: "slides/room"}, &livekit.ImageOutput{})
+	require.Error(t, err)
+
+	p.ImageOutput.SceneChange.Threshold = 2
+	_, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: "slides/room"}, &livekit.ImageOutput{})
+	require.Error(t, err)
+}
+
+func TestImageDedupe(t *testing.T) {
+	p := &PipelineConfig{Info: &livekit.EgressInfo{EgressId: "egress_ID"}, TmpDir: t.TempDir()}
+	p.ImageOutput.Dedupe.Enabled = true
+	p.ImageOutput.Dedupe.Threshold = 4
+
+	o, err := p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: "thumbs/room"}, &livekit.ImageOutput{})
+	require.NoError(t, err)
+	require.True(t, o.Dedupe)
+	require.Equal(t, 4, o.DedupeThreshold)
+
+	// the service default does not apply to webp images and sprite sheets, which cannot request it
+	for _, prefix := range []string{"thumbs/room.webp", "thumbs/room?sprite"} {
+		o, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: prefix}, &livekit.ImageOutput{})
+		require.NoError(t, err)
+		require.False(t, o.Dedupe)
+	}
+	for _, prefix := range []string{"thumbs/room.webp?dedupe", "thumbs/room?sprite&dedupe=1", "thumbs/room?sprite&dedupe_threshold=2"} {
+		_, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: prefix}, &livekit.ImageOutput{})
+		require.ErrorContains(t, err, "not yet supported", prefix)
+	}
+
+	// dedupe can be set per request
+	o, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: "thumbs/room?dedupe=0"}, &livekit.ImageOutput{})
+	require.NoError(t, err)
+	require.False(t, o.Dedupe)
+
+	p.ImageOutput.Dedupe.Enabled = false
+	o, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: "thumbs/room?dedupe&dedupe_threshold=10"}, &livekit.ImageOutput{})
+	require.NoError(t, err)
+	require.True(t, o.Dedupe)
+	require.Equal(t, 10, o.DedupeThreshold)
+
+	for _, prefix := range []string{"thumbs/room?dedupe_threshold=10", "thumbs/room?dedupe&dedupe_threshold=65"} {
+		_, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: prefix}, &livekit.ImageOutput{})
+		require.Error(t, err, prefix)
+	}
+
+	p.ImageOutput.Dedupe.Threshold = 65
+	_, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: "thumbs/room"}, &livekit.ImageOutput{})
+	require.Error(t, err)
+}
+
+func TestImageEmbedMetadata(t *testing.T) {
+	p := &PipelineConfig{Info: &livekit.EgressInfo{EgressId: "egress_ID"}, TmpDir: t.TempDir()}
+	p.ImageOutput.EmbedMetadata = true
+
+	o, err := p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: "thumbs/room"}, &livekit.ImageOutput{})
+	require.NoError(t, err)
+	require.True(t, o.EmbedMetadata)
+
+	o, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: "thumbs/room.png"}, &livekit.ImageOutput{})
+	require.NoError(t, err)
+	require.True(t, o.EmbedMetadata)
+
+	// webp images are uploaded as is, and sprite sheets cannot request metadata
+	for _, prefix := range []string{"thumbs/room.webp", "thumbs/room?sprite"} {
+		o, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: prefix}, &livekit.ImageOutput{})
+		require.NoError(t, err)
+		require.False(t, o.EmbedMetadata)
+	}
+	for _, prefix := range []string{"thumbs/room.webp?metadata", "thumbs/room?sprite&metadata=1"} {
+		_, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: prefix}, &livekit.ImageOutput{})
+		require.ErrorContains(t, err, "not yet supported", prefix)
+	}
+
+	// metadata can be set per output
+	o, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: "thumbs/room?metadata=false"}, &livekit.ImageOutput{})
+	require.NoError(t, err)
+	require.False(t, o.EmbedMetadata)
+
+	p.ImageOutput.EmbedMetadata = false
+	o, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: "thumbs/room.png?metadata"}, &livekit.ImageOutput{})
+	require.NoError(t, err)
+	require.True(t, o.EmbedMetadata)
+	o, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: "thumbs/room.webp?metadata=0"}, &livekit.ImageOutput{})
+	require.NoError(t, err)
+	require.False(t, o.EmbedMetadata)
+}
+
+func TestPreviewClip(t *testing.T) {
+	newPipelineConfig := func(filepath string) (*PipelineConfig, error) {
+		p := &PipelineConfig{
+			Info:   &livekit.EgressInfo{RoomName: "test-room"},
+			TmpDir: t.TempDir(),
+		}
+		p.VideoEnabled = true
+		p.VideoDecoding = true
+		p.Width = 1280
+		p.Height = 720
+
+		filepath, options, err := getFileOptions(filepath)
+		if err != nil {
+			return nil, err
+		}
+		preview, err := options.previewRequest(p)
+		if err != nil {
+			return nil, err
+		}
+		p.Outputs = map[types.EgressType][]OutputConfig{
+			types.EgressTypeFile: {
+				&FileConfig{outputConfig: outputConfig{OutputType: types.OutputTypeMP4}, StorageFilepath: filepath, preview: preview},
+			},
+		}
+		return p, p.updatePreviewOutput()
+	}
+
+	// not requested
+	p, err := newPipelineConfig("recordings/room.mp4")
+	require.NoError(t, err)
+	require.Nil(t, p.GetPreviewConfig())
+	require.False(t, PreviewRequested("recordings/room.mp4"))
+
+	p, err = newPipelineConfig("recordings/room.mp4?preview")
+	require.NoError(t, err)
+	o := p.GetPreviewConfig()
+	require.NotNil(t, o)
+	require.Equal(t, types.OutputTypeWebP, o.OutputType)
+	require.Equal(t, "recordings/room_preview.webp", o.StorageFilepath)
+	require.Equal(t, path.Join(p.TmpDir, "room_preview.webp"), o.LocalFilepath)
+	require.Equal(t, int32(320), o.Width)
+	require.Equal(t, int32(180), o.Height)
+	require.Equal(t, 30, o.FrameCount())
+	require.True(t, o.Sampled)
+	require.True(t, PreviewRequested("recordings/room.mp4?preview"))
+
+	p, err = newPipelineConfig("recordings/room.mp4?preview=gif&preview_duration=5s&preview_mode=start")
+	require.NoError(t, err)
+	o = p.GetPreviewConfig()
+	require.Equal(t, "recordings/room_preview.gif", o.StorageFilepath)
+	require.Equal(t, 50, o.FrameCount())
+	require.False(t, o.Sampled)
+
+	for _, filepath := range []string{
+		"recordings/room.mp4?preview=avi",
+		"recordings/room.mp4?preview&preview_duration=0s",
+		"recordings/room.mp4?preview&preview_mode=random",
+		"recordings/room.mp4?preview_duration=5s",
+	} {
+		_, err = newPipelineConfig(filepath)
+		require.Error(t, err, filepath)
+	}
+
+	// a '?' which is not followed by known options is part of the filepath
+	p, err = newPipelineConfig("recordings/room.mp4?thumbnail")
+	require.NoError(t, err)
+	require.Nil(t, p.GetPreviewConfig())
+	require.False(t, PreviewRequested("recordings/room.mp4?thumbnail"))
+}
+
+func TestValidateAndUpdateOutputParamsLosslessAudio(t *testing.T) {
+	for _, test := range []struct {
+		filepath           string
+		expectedOutputType types.OutputType
+		expectedAudioCodec types.MimeType
+	}{
+		{filepath: "recording.wav", expectedOutputType: types.OutputTypeWAV, expectedAudioCodec: types.MimeTypeRawAudio},
+		{filepath: "recording.flac", expectedOutputType: types.OutputTypeFLAC, expectedAudioCodec: types.MimeTypeFLAC},
+		{filepath: "recording", expectedOutputType: types.OutputTypeOGG, expectedAudioCodec: types.MimeTypeOpus},
+	} {
+		p := &PipelineConfig{
+			Outputs: map[types.EgressType][]OutputConfig{
+				types.EgressTypeFile: {
+					&FileConfig{
+						outputConfig:    outputConfig{OutputType: types.OutputTypeUnknownFile},
+						FileInfo:        &livekit.FileInfo{},
+						StorageFilepath: test.filepath,
+					},
+				},
+			},
+		}
+
+		p.AudioEnabled = true
+		p.Info = &livekit.EgressInfo{}
+
+		require.NoError(t, p.validateAndUpdateOutputParams())
+		require.Equal(t, test.expectedOutputType, p.GetFileConfig().OutputType, test.filepath)
+		require.Equal(t, test.expectedAudioCodec, p.AudioOutCodec, test.filepath)
+	}
+}
+
+func TestImageFormat(t *testing.T) {
+	p := &PipelineConfig{Info: &livekit.EgressInfo{EgressId: "egress_ID"}, TmpDir: t.TempDir()}
+
+	for _, test := range []struct {
+		prefix             string
+		codec              livekit.ImageCodec
+		expectedPrefix     string
+		expectedOutputType types.OutputType
+		expectedCodec      types.MimeType
+	}{
+		{prefix: "thumbs/room", expectedPrefix: "room", expectedOutputType: types.OutputTypeJPEG, expectedCodec: types.MimeTypeJPEG},
+		{prefix: "thumbs/room.png", expectedPrefix: "room", expectedOutputType: types.OutputTypePNG, expectedCodec: types.MimeTypePNG},
+		{prefix: "thumbs/room.webp", expectedPrefix: "room", expectedOutputType: types.OutputTypeWebP, expectedCodec: types.MimeTypeWebP},
+		{prefix: "thumbs/room.webp", codec: livekit.ImageCodec_IC_JPEG, expectedPrefix: "room.webp", expectedOutputType: types.OutputTypeJPEG, expectedCodec: types.MimeTypeJPEG},
+	} {
+		o, err := p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: test.prefix, ImageCodec: test.codec}, &livekit.ImageOutput{})
+		require.NoError(t, err)
+		require.Equal(t, test.expectedPrefix, o.ImagePrefix, test.prefix)
+		require.Equal(t, test.expectedOutputType, o.OutputType, test.prefix)
+		require.Equal(t, test.expectedCodec, o.ImageOutCodec, test.prefix)
+		require.Equal(t, types.FileExtensionForOutputType[test.expectedOutputType], o.ImageExtension, test.prefix)
+	}
+
+	p.ImageOutput.Quality = 101
+	_, err := p.getImageConfig(&livekit.ImageOutput{}, &livekit.ImageOutput{})
+	require.Error(t, err)
+}
+
+func TestImageSpriteSheet(t *testing.T) {
+	p := &PipelineConfig{Info: &livekit.EgressInfo{EgressId: "egress_ID"}, TmpDir: t.TempDir()}
+	p.Width = 1920
+	p.Height = 1080
+
+	o, err := p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: "thumbs/room?sprite"}, &livekit.ImageOutput{})
+	require.NoError(t, err)
+	require.True(t, o.IsSpriteSheet())
+	require.Equal(t, "room", o.ImagePrefix)
+	require.Equal(t, types.OutputTypeJPEG, o.OutputType)
+	require.Equal(t, 5, o.SpriteColumns)
+	require.Equal(t, 5, o.SpriteRows)
+	require.Equal(t, int32(160), o.Width)
+	require.Equal(t, int32(90), o.Height)
+
+	require.Equal(t, types.OutputTypePNG, o.FrameOutputType())
+
+	p.ImageOutput.SpriteColumns = 10
+	o, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: "thumbs/room.png?sprite", Width: 320, Height: 180}, &livekit.ImageOutput{})
+	require.NoError(t, err)
+	require.Equal(t, "room", o.ImagePrefix)
+	require.Equal(t, types.OutputTypePNG, o.OutputType)
+	require.Equal(t, 10, o.SpriteColumns)
+	require.Equal(t, 5, o.SpriteRows)
+	require.Equal(t, int32(320), o.Width)
+
+	// the grid can be set per request
+	o, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: "thumbs/room?sprite=4x3"}, &livekit.ImageOutput{})
+	require.NoError(t, err)
+	require.Equal(t, 4, o.SpriteColumns)
+	require.Equal(t, 3, o.SpriteRows)
+
+	// a .vtt prefix is a regular image prefix
+	o, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: "thumbs/room.vtt"}, &livekit.ImageOutput{})
+	require.NoError(t, err)
+	require.False(t, o.IsSpriteSheet())
+	require.Equal(t, types.OutputTypeJPEG, o.FrameOutputType())
+
+	for _, prefix := range []string{
+		"thumbs/room.webp?sprite",
+		"thumbs/room?sprite=4",
+		"thumbs/room?sprite=0x3",
+	} {
+		_, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: prefix}, &livekit.ImageOutput{})
+		require.Error(t, err, prefix)
+	}
+
+	// unknown options are part of the prefix
+	o, err = p.getImageConfig(&livekit.ImageOutput{FilenamePrefix: "thumbs/room?grid=4x3"}, &livekit.ImageOutput{})
+	require.NoError(t, err)
+	require.False(t, o.IsSpriteSheet())
+}
+
 func TestValidateAndUpdateOutputParamsRejectsHLSMP3(t *testing.T) {
 	p := &PipelineConfig{
 		Outputs: map[types.EgressType][]OutputConfig{
@@ -133,3 +707,178 @@ func TestValidateAndUpdateOutputParamsRejectsVideoFileMP3(t *testing.T) {
 	require.Error(t, err)
 	require.ErrorContains(t, err, "format audio/mpeg incompatible with codec video/h264")
 }
+
+func TestValidateAndUpdateOutputParamsFileExtension(t *testing.T) {
+	for _, test := range []struct {
+		filepath           string
+		audioEnabled       bool
+		videoOutCodec      types.MimeType
+		expectedOutputType types.OutputType
+		expectedVideoCodec types.MimeType
+	}{
+		{
+			// without a requested codec, webm and ivf files are still recorded as h264 mp4
+			filepath: "recording.webm", audioEnabled: true,
+			expectedOutputType: types.OutputTypeMP4, expectedVideoCodec: types.MimeTypeH264,
+		},
+		{
+			filepath:           "recording.ivf",
+			expectedOutputType: types.OutputTypeMP4, expectedVideoCodec: types.MimeTypeH264,
+		},
+		{
+			filepath: "recording.webm", audioEnabled: true, videoOutCodec: types.MimeTypeVP9,
+			expectedOutputType: types.OutputTypeWebM, expectedVideoCodec: types.MimeTypeVP9,
+		},
+		{
+			filepath: "recording.ivf", videoOutCodec: types.MimeTypeVP9,
+			expectedOutputType: types.OutputTypeIVF, expectedVideoCodec: types.MimeTypeVP9,
+		},
+		{
+			filepath: "recording.mp4", audioEnabled: true, videoOutCodec: types.MimeTypeVP9,
+			expectedOutputType: types.OutputTypeMP4, expectedVideoCodec: types.MimeTypeVP9,
+		},
+		{
+			filepath: "recording.mp4", audioEnabled: true, videoOutCodec: types.MimeTypeAV1,
+			expectedOutputType: types.OutputTypeMP4, expectedVideoCodec: types.MimeTypeAV1,
+		},
+		{
+			filepath: "recording.mp4", audioEnabled: true, videoOutCodec: types.MimeTypeH265,
+			expectedOutputType: types.OutputTypeMP4, expectedVideoCodec: types.MimeTypeH265,
+		},
+		{
+			filepath: "recording.webm", audioEnabled: true, videoOutCodec: types.MimeTypeAV1,
+			expectedOutputType: types.OutputTypeWebM, expectedVideoCodec: types.MimeTypeAV1,
+		},
+		{
+			filepath: "recording.mkv", audioEnabled: true,
+			expectedOutputType: types.OutputTypeMKV, expectedVideoCodec: types.MimeTypeH264,
+		},
+		{
+			filepath: "recording.mkv", audioEnabled: true, videoOutCodec: types.MimeTypeVP9,
+			expectedOutputType: types.OutputTypeMKV, expectedVideoCodec: types.MimeTypeVP9,
+		},
+		{
+			filepath: "recording", audioEnabled: true,
+			expectedOutputType: types.OutputTypeMP4, expectedVideoCodec: types.MimeTypeH264,
+		},
+	} {
+		p := &PipelineConfig{
+			Outputs: map[types.EgressType][]OutputConfig{
+				types.EgressTypeFile: {
+					&FileConfig{
+						outputConfig:    outputConfig{OutputType: types.OutputTypeUnknownFile},
+						FileInfo:        &livekit.FileInfo{},
+						StorageFilepath: test.filepath,
+					},
+				},
+			},
+		}
+
+		p.AudioEnabled = test.audioEnabled
+		p.VideoEnabled = true
+		p.VideoOutCodec = test.videoOutCodec
+		p.Info = &livekit.EgressInfo{}
+
+		require.NoError(t, p.validateAndUpdateOutputParams())
+		require.Equal(t, test.expectedOutputType, p.GetFileConfig().OutputType, test.filepath)
+		require.Equal(t, test.expectedVideoCodec, p.VideoOutCodec, test.filepath)
+	}
+}
+
+func TestWHIPStreamConfig(t *testing.T) {
+	p := &PipelineConfig{}
+	p.VideoProfile = types.ProfileMain
+
+	o, err := p.getStreamConfig(types.OutputTypeWHIP, []string{"whip://localhost:8080/whip/room"})
+	require.NoError(t, err)
+	require.Equal(t, types.OutputTypeWHIP, o.OutputType)
+	require.Equal(t, types.MimeTypeOpus, p.AudioOutCodec)
+	require.Equal(t, types.MimeTypeH264, p.VideoOutCodec)
+	require.Equal(t, types.ProfileBaseline, p.VideoProfile)
+
+	p = &PipelineConfig{}
+	p.VideoOutCodec = types.MimeTypeVP8
+	_, err = p.getStreamConfig(types.OutputTypeWHIP, []string{"whip://localhost:8080/whip/room"})
+	require.NoError(t, err)
+	require.Equal(t, types.MimeTypeVP8, p.VideoOutCodec)
+
+	_, err = p.getStreamConfig(types.OutputTypeWHIP, []string{
+		"whip://localhost:8080/whip/room",
+		"whip://localhost:8080/whip/other",
+	})
+	require.Error(t, err)
+}
+
+func TestHLSPushStreamConfig(t *testing.T) {
+	p := &PipelineConfig{TmpDir: t.TempDir()}
+	p.VideoOutCodec = types.MimeTypeVP8
+
+	o, err := p.getStreamConfig(types.OutputTypeHLS, []string{"hls+https://token@origin.example.com/live/stream.m3u8"})
+	require.NoError(t, err)
+	require.Equal(t, types.MimeTypeAAC, p.AudioOutCodec)
+	require.Equal(t, types.MimeTypeH264, p.VideoOutCodec)
+
+	require.NotNil(t, o.Segments)
+	require.NotNil(t, o.Segments.Push)
+	require.Equal(t, types.OutputTypeHLS, o.Segments.OutputType)
+	require.Equal(t, types.OutputTypeTS, o.Segments.SegmentContainer)
+	require.Equal(t, "stream.m3u8", o.Segments.PlaylistFilename)
+	require.Equal(t, "stream", o.Segments.SegmentPrefix)
+	require.Equal(t, path.Join(p.TmpDir, hlsPushDir), o.Segments.LocalDir)
+	require.True(t, o.Segments.DisableManifest)
+	require.DirExists(t, o.Segments.LocalDir)
+
+	// segments are cut on keyframes
+	p.Outputs = map[types.EgressType][]OutputConfig{types.EgressTypeHLSPush: {o}}
+	p.KeyFrameInterval = 2
+	p.updateHLSPushSegmentDuration()
+	require.Equal(t, 2, o.Segments.SegmentDuration)
+	p.KeyFrameInterval = 0.5
+	p.updateHLSPushSegmentDuration()
+	require.Equal(t, 1, o.Segments.SegmentDuration)
+
+	_, err = p.getStreamConfig(types.OutputTypeHLS, []string{
+		"hls+https://origin.example.com/live/stream.m3u8",
+		"hls+https://origin.example.com/live/other.m3u8",
+	})
+	require.Error(t, err)
+}
+
+func TestWebsocketStreamConfig(t *testing.T) {
+	for rawUrl, codec := range map[string]types.MimeType{
+		"wss://agent.example.com/audio":            types.MimeTypeRawAudio,
+		"wss://agent.example.com/audio?codec=opus": types.MimeTypeOpus,
+		"wss://agent.example.com/audio?codec=pcmu": types.MimeTypePCMU,
+		"wss://agent.example.com/audio?codec=pcma": types.MimeTypePCMA,
+	} {
+		p := &PipelineConfig{}
+		p.AudioOutCodec = types.MimeTypeAAC
+		_, err := p.getStreamConfig(types.OutputTypeRaw, []string{rawUrl})
+		require.NoError(t, err)
+		require.Equal(t, codec, p.AudioOutCodec)
+		require.True(t, types.CodecCompatibility[types.OutputTypeRaw][codec])
+	}
+
+	p := &PipelineConfig{}
+	_, err := p.getStreamConfig(types.OutputTypeRaw, []string{
+		"wss://agent.example.com/audio",
+		"wss://agent.example.com/other",
+	})
+	require.Error(t, err)
+
+	// reconnection defaults
+	p = &PipelineConfig{}
+	o, err := p.getStreamConfig(types.OutputTypeRaw, []string{"wss://agent.example.com/audio"})
+	require.NoError(t, err)
+	require.Equal(t, defaultWebsocketReconnectAttempts, o.Reconnect.MaxAttempts)
+	require.Equal(t, defaultWebsocketReconnectBackoff, o.Reconnect.MaxBackoff)
+	require.Zero(t, o.Reconnect.BufferWindow)
+
+	// the buffer window is set per output
+	p = &PipelineConfig{}
+	p.WebsocketReconnect = WebsocketReconnectConfig{MaxAttempts: -1}
+	o, err = p.getStreamConfig(types.OutputTypeRaw, []string{"wss://agent.example.com/audio?buffer_window=5s"})
+	require.NoError(t, err)
+	require.Equal(t, -1, o.Reconnect.MaxAttempts)
+	require.Equal(t, 5*time.Second, o.Reconnect.BufferWindow)
+}
@@ -105,6 +105,19 @@ func (p *PipelineConfig) applyAdvanced(advanced *livekit.EncodingOptions) error
 	case livekit.VideoCodec_H264_HIGH:
 		p.VideoOutCodec = types.MimeTypeH264
 		p.VideoProfile = types.ProfileHigh
+
+	case livekit.VideoCodec_H265_MAIN:
+		p.VideoOutCodec = types.MimeTypeH265
+		p.VideoProfile = types.ProfileMain
+
+	case livekit.VideoCodec_VP8:
+		p.VideoOutCodec = types.MimeTypeVP8
+
+	case livekit.VideoCodec_VP9:
+		p.VideoOutCodec = types.MimeTypeVP9
+
+	case livekit.VideoCodec_AV1:
+		p.VideoOutCodec = types.MimeTypeAV1
 	}
 
 	if advanced.Width > 0 {
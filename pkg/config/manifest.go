@@ -59,9 +59,11 @@ type Segment struct {
 }
 
 type Image struct {
-	Filename  string    `json:"filename,omitempty"`
-	Timestamp time.Time `json:"timestamp,omitempty"`
-	Location  string    `json:"location,omitempty"`
+	Filename    string    `json:"filename,omitempty"`
+	Timestamp   time.Time `json:"timestamp,omitempty"`
+	Location    string    `json:"location,omitempty"`
+	SceneScore  float64   `json:"scene_score,omitempty"`  // set if the image was captured because the scene changed
+	DuplicateOf string    `json:"duplicate_of,omitempty"` // set if the image was not uploaded because it matched this earlier image
 }
 
 func (p *PipelineConfig) initManifest() {
@@ -144,6 +146,29 @@ func (m *Manifest) AddImage(filename string, ts time.Time, location string) {
 	m.mu.Unlock()
 }
 
+// AddSceneChangeImage adds an image captured because the scene changed, with its difference from the previous image
+func (m *Manifest) AddSceneChangeImage(filename string, ts time.Time, location string, score float64) {
+	m.mu.Lock()
+	m.Images = append(m.Images, &Image{
+		Filename:   filename,
+		Timestamp:  ts,
+		Location:   location,
+		SceneScore: score,
+	})
+	m.mu.Unlock()
+}
+
+// AddDuplicateImage adds an image which was not uploaded because it matched an earlier image
+func (m *Manifest) AddDuplicateImage(filename string, ts time.Time, duplicateOf string) {
+	m.mu.Lock()
+	m.Images = append(m.Images, &Image{
+		Filename:    filename,
+		Timestamp:   ts,
+		DuplicateOf: duplicateOf,
+	})
+	m.mu.Unlock()
+}
+
 func (m *Manifest) Close(endedAt int64) ([]byte, error) {
 	m.EndedAt = endedAt
 
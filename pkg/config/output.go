@@ -88,6 +88,7 @@ func (p *PipelineConfig) updateEncodedOutputs(req egress.EncodedOutput) error {
 	}
 	if stream != nil {
 		var outputType types.OutputType
+		egressType := types.EgressTypeStream
 		switch stream.Protocol {
 		case livekit.StreamProtocol_DEFAULT_PROTOCOL:
 			if len(stream.Urls) == 0 {
@@ -104,6 +105,12 @@ func (p *PipelineConfig) updateEncodedOutputs(req egress.EncodedOutput) error {
 			if !ok {
 				return errors.ErrInvalidUrl(stream.Urls[0], "invalid protocol")
 			}
+			switch outputType {
+			case types.OutputTypeWHIP:
+				egressType = types.EgressTypeWHIP
+			case types.OutputTypeHLS:
+				egressType = types.EgressTypeHLSPush
+			}
 
 		case livekit.StreamProtocol_RTMP:
 			outputType = types.OutputTypeRTMP
@@ -117,8 +124,12 @@ func (p *PipelineConfig) updateEncodedOutputs(req egress.EncodedOutput) error {
 			return err
 		}
 
-		p.Outputs[types.EgressTypeStream] = []OutputConfig{conf}
+		p.Outputs[egressType] = []OutputConfig{conf}
 		p.OutputCount.Add(int32(len(stream.Urls)))
+		if egressType == types.EgressTypeHLSPush {
+			// the last segment and the final playlist are pushed after eos
+			p.FinalizationRequired = true
+		}
 		if p.VideoEnabled {
 			p.VideoEncoding = true
 		}
@@ -158,7 +169,12 @@ func (p *PipelineConfig) updateEncodedOutputs(req egress.EncodedOutput) error {
 			return err
 		}
 
-		p.Outputs[types.EgressTypeSegments] = []OutputConfig{conf}
+		renditions, err := p.getRenditionConfigs(conf)
+		if err != nil {
+			return err
+		}
+
+		p.Outputs[types.EgressTypeSegments] = append([]OutputConfig{conf}, renditions...)
 		p.OutputCount.Inc()
 		p.FinalizationRequired = true
 		if p.VideoEnabled {
@@ -174,14 +190,17 @@ func (p *PipelineConfig) updateEncodedOutputs(req egress.EncodedOutput) error {
 	if segmentConf := p.Outputs[types.EgressTypeSegments]; segmentConf != nil {
 		if stream != nil && p.KeyFrameInterval > 0 {
 			// segment duration must match keyframe interval - use the lower of the two
-			conf := segmentConf[0].(*SegmentConfig)
-			conf.SegmentDuration = min(int(p.KeyFrameInterval), conf.SegmentDuration)
+			for _, c := range segmentConf {
+				conf := c.(*SegmentConfig)
+				conf.SegmentDuration = min(int(p.KeyFrameInterval), conf.SegmentDuration)
+			}
 		}
 		p.KeyFrameInterval = 0
-	} else if p.KeyFrameInterval == 0 && p.Outputs[types.EgressTypeStream] != nil {
+	} else if p.KeyFrameInterval == 0 && p.hasStreamOutput() {
 		// default 4s for streams
 		p.KeyFrameInterval = StreamKeyframeInterval
 	}
+	p.updateHLSPushSegmentDuration()
 
 	// image output
 	if len(images) > 0 {
@@ -247,7 +266,11 @@ func (p *PipelineConfig) updateOutputs(req egress.EgressRequest) error {
 			}
 			hasFile = true
 
-			conf, err := p.getFileConfig(fileTypeToOutputType(o.File.FileType), o.File.GetFilepath(), o.File.GetDisableManifest(), storage)
+			filepath, options, err := getFileOptions(o.File.GetFilepath())
+			if err != nil {
+				return err
+			}
+			conf, err := p.getFileConfig(fileTypeToOutputType(o.File.FileType), filepath, o.File.GetDisableManifest(), storage, options)
 			if err != nil {
 				return err
 			}
@@ -286,9 +309,14 @@ func (p *PipelineConfig) updateOutputs(req egress.EgressRequest) error {
 				if !ok {
 					return errors.ErrInvalidUrl(stream.Urls[0], "invalid protocol")
 				}
-				if parsed.Scheme == "ws" || parsed.Scheme == "wss" {
+				switch {
+				case parsed.Scheme == "ws" || parsed.Scheme == "wss":
 					egressType = types.EgressTypeWebsocket
-				} else {
+				case outputType == types.OutputTypeWHIP:
+					egressType = types.EgressTypeWHIP
+				case outputType == types.OutputTypeHLS:
+					egressType = types.EgressTypeHLSPush
+				default:
 					egressType = types.EgressTypeStream
 				}
 
@@ -318,6 +346,10 @@ func (p *PipelineConfig) updateOutputs(req egress.EgressRequest) error {
 
 			p.Outputs[egressType] = []OutputConfig{conf}
 			p.OutputCount.Add(int32(len(stream.Urls)))
+			if egressType == types.EgressTypeHLSPush {
+				// the last segment and the final playlist are pushed after eos
+				p.FinalizationRequired = true
+			}
 			if p.VideoEnabled && !p.Passthrough {
 				p.VideoEncoding = true
 			}
@@ -341,7 +373,12 @@ func (p *PipelineConfig) updateOutputs(req egress.EgressRequest) error {
 				return err
 			}
 
-			p.Outputs[types.EgressTypeSegments] = []OutputConfig{conf}
+			renditions, err := p.getRenditionConfigs(conf)
+			if err != nil {
+				return err
+			}
+
+			p.Outputs[types.EgressTypeSegments] = append([]OutputConfig{conf}, renditions...)
 			p.OutputCount.Inc()
 			p.FinalizationRequired = true
 			if p.VideoEnabled && !p.Passthrough {
@@ -399,13 +436,16 @@ func (p *PipelineConfig) updateOutputs(req egress.EgressRequest) error {
 	// keyframe interval handling
 	if segmentConf := p.Outputs[types.EgressTypeSegments]; segmentConf != nil {
 		if hasStream && p.KeyFrameInterval > 0 {
-			conf := segmentConf[0].(*SegmentConfig)
-			conf.SegmentDuration = min(int(p.KeyFrameInterval), conf.SegmentDuration)
+			for _, c := range segmentConf {
+				conf := c.(*SegmentConfig)
+				conf.SegmentDuration = min(int(p.KeyFrameInterval), conf.SegmentDuration)
+			}
 		}
 		p.KeyFrameInterval = 0
-	} else if p.KeyFrameInterval == 0 && p.Outputs[types.EgressTypeStream] != nil {
+	} else if p.KeyFrameInterval == 0 && p.hasStreamOutput() {
 		p.KeyFrameInterval = StreamKeyframeInterval
 	}
+	p.updateHLSPushSegmentDuration()
 
 	return nil
 }
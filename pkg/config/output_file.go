@@ -33,10 +33,25 @@ type FileConfig struct {
 	LocalFilepath   string
 	StorageFilepath string
 
+	// fragmented mp4 options, only used for mp4 outputs
+	FragmentDuration time.Duration
+	FastStart        bool
+
 	DisableManifest bool
 	StorageConfig   *StorageConfig
+
+	preview *previewRequest
 }
 
+const defaultFragmentDuration = 2 * time.Second
+
+const (
+	// writes an mp4 file as fragments, e.g. "recordings/room.mp4?fragmented=1". Defaults to fragmented_mp4.enabled
+	fileOptionFragmented = "fragmented"
+	// remuxes a fragmented mp4 file with the moov atom first before upload. Defaults to fragmented_mp4.fast_start
+	fileOptionFastStart = "faststart"
+)
+
 func (p *PipelineConfig) GetFileConfig() *FileConfig {
 	o, ok := p.Outputs[types.EgressTypeFile]
 	if !ok || len(o) == 0 {
@@ -45,12 +60,26 @@ func (p *PipelineConfig) GetFileConfig() *FileConfig {
 	return o[0].(*FileConfig)
 }
 
+// IsFragmented returns true if the file is written as a fragmented mp4
+func (o *FileConfig) IsFragmented() bool {
+	return o.OutputType == types.OutputTypeMP4 && o.FragmentDuration > 0
+}
+
+// MoovRecoveryFilepath returns the path of the file mp4mux uses to recover the moov atom of an unfinished file
+func (o *FileConfig) MoovRecoveryFilepath() string {
+	return o.LocalFilepath + ".mrf"
+}
+
 func (p *PipelineConfig) getEncodedFileConfig(file *livekit.EncodedFileOutput) (*FileConfig, error) {
-	return p.getFileConfig(fileTypeToOutputType(file.FileType), file.GetFilepath(), file.GetDisableManifest(), file)
+	filepath, options, err := getFileOptions(file.GetFilepath())
+	if err != nil {
+		return nil, err
+	}
+	return p.getFileConfig(fileTypeToOutputType(file.FileType), filepath, file.GetDisableManifest(), file, options)
 }
 
 func (p *PipelineConfig) getDirectFileConfig(file *livekit.DirectFileOutput) (*FileConfig, error) {
-	return p.getFileConfig(types.OutputTypeUnknownFile, file.GetFilepath(), file.GetDisableManifest(), file)
+	return p.getFileConfig(types.OutputTypeUnknownFile, file.GetFilepath(), file.GetDisableManifest(), file, nil)
 }
 
 func fileTypeToOutputType(ft livekit.EncodedFileType) types.OutputType {
@@ -66,11 +95,21 @@ func fileTypeToOutputType(ft livekit.EncodedFileType) types.OutputType {
 	}
 }
 
-func (p *PipelineConfig) getFileConfig(outputType types.OutputType, filepath string, disableManifest bool, upload egress.UploadRequest) (*FileConfig, error) {
+func (p *PipelineConfig) getFileConfig(
+	outputType types.OutputType,
+	filepath string,
+	disableManifest bool,
+	upload egress.UploadRequest,
+	options outputOptions,
+) (*FileConfig, error) {
 	sc, err := p.getStorageConfig(upload)
 	if err != nil {
 		return nil, err
 	}
+	preview, err := options.previewRequest(p)
+	if err != nil {
+		return nil, err
+	}
 
 	filepath = clean(filepath)
 
@@ -86,6 +125,10 @@ func (p *PipelineConfig) getFileConfig(outputType types.OutputType, filepath str
 		StorageFilepath: filepath,
 		DisableManifest: disableManifest,
 		StorageConfig:   sc,
+		preview:         preview,
+	}
+	if err = conf.updateFragmentedMP4(p, options); err != nil {
+		return nil, err
 	}
 
 	// filename
@@ -102,6 +145,32 @@ func (p *PipelineConfig) getFileConfig(outputType types.OutputType, filepath str
 	return conf, nil
 }
 
+// updateFragmentedMP4 applies the fragmented mp4 file options, using the service config as defaults
+func (o *FileConfig) updateFragmentedMP4(p *PipelineConfig, options outputOptions) error {
+	enabled, err := options.boolean(fileOptionFragmented, p.FragmentedMP4.Enabled)
+	if err != nil {
+		return err
+	}
+	fastStart, err := options.boolean(fileOptionFastStart, p.FragmentedMP4.FastStart)
+	if err != nil {
+		return err
+	}
+
+	if !enabled {
+		if _, ok := options[fileOptionFastStart]; ok && fastStart {
+			return errors.ErrInvalidInput(fmt.Sprintf("%s requires %s", fileOptionFastStart, fileOptionFragmented))
+		}
+		return nil
+	}
+
+	o.FragmentDuration = p.FragmentedMP4.FragmentDuration
+	if o.FragmentDuration <= 0 {
+		o.FragmentDuration = defaultFragmentDuration
+	}
+	o.FastStart = fastStart
+	return nil
+}
+
 func (p *PipelineConfig) getFilenameInfo() (string, map[string]string) {
 	now := time.Now()
 	utc := fmt.Sprintf("%s%03d", now.Format("20060102150405"), now.UnixMilli()%1000)
@@ -124,6 +193,34 @@ func (p *PipelineConfig) getFilenameInfo() (string, map[string]string) {
 	return "web", replacements
 }
 
+// preferredOutputTypes moves the output type named by the filepath extension, if any, to the front of the list.
+// Unless a codec was requested, the extension is only used if its default codecs are compatible, so requests
+// naming a .webm or .ivf file keep the output type they had before vp9 was added.
+func (p *PipelineConfig) preferredOutputTypes(
+	o *FileConfig,
+	outputTypes []types.OutputType,
+	compatibleAudioCodecs, compatibleVideoCodecs map[types.MimeType]bool,
+) []types.OutputType {
+	ext := types.FileExtension(path.Ext(o.StorageFilepath))
+	for i, ot := range outputTypes {
+		if types.FileExtensionForOutputType[ot] != ext {
+			continue
+		}
+		if p.AudioEnabled && p.AudioOutCodec == "" && !compatibleAudioCodecs[types.DefaultAudioCodecs[ot]] {
+			return outputTypes
+		}
+		if p.VideoEnabled && p.VideoOutCodec == "" && !compatibleVideoCodecs[types.DefaultVideoCodecs[ot]] {
+			return outputTypes
+		}
+
+		preferred := make([]types.OutputType, 0, len(outputTypes))
+		preferred = append(preferred, ot)
+		preferred = append(preferred, outputTypes[:i]...)
+		return append(preferred, outputTypes[i+1:]...)
+	}
+	return outputTypes
+}
+
 func (o *FileConfig) updateFilepath(p *PipelineConfig, identifier string, replacements map[string]string) error {
 	o.StorageFilepath = stringReplace(o.StorageFilepath, replacements)
 
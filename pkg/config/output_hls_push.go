@@ -0,0 +1,212 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/egress/pkg/types"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils"
+)
+
+const (
+	hlsPushDir             = "hls_push"
+	hlsPushSegmentDuration = 4
+
+	// query parameters used by egress, which are not sent to the origin
+	hlsPushMethodParam = "method"
+	hlsPushHeaderParam = "header" // header={name}:{value}, added to every request. Can be repeated
+	// query parameter naming the uploaded file, for origins such as youtube which do not use the path
+	hlsPushFileParam = "file"
+)
+
+// HLSPushConfig is the origin of an hls push stream, e.g. hls+https://{token}@origin.example.com/live/stream.m3u8.
+// Files are uploaded next to the playlist, or as the file query parameter if the url has one
+// (hls+https://a.upload.youtube.com/http_upload_hls?cid={stream_key}&copy=0&file=stream.m3u8).
+// Credentials are sent as basic auth, or as a bearer token when there is no password.
+// Other request headers can be set with header query parameters (?header=X-Api-Key:{key}).
+type HLSPushConfig struct {
+	Playlist string
+	Method   string
+	Header   http.Header
+
+	base      *url.URL
+	fileParam bool
+}
+
+func (p *PipelineConfig) GetHLSPushConfig() *StreamConfig {
+	o, ok := p.Outputs[types.EgressTypeHLSPush]
+	if !ok || len(o) == 0 {
+		return nil
+	}
+	return o[0].(*StreamConfig)
+}
+
+// ParseHLSPushUrl validates an hls push url, and returns its origin
+func ParseHLSPushUrl(rawUrl string) (*HLSPushConfig, error) {
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	base := *parsedUrl
+	switch parsedUrl.Scheme {
+	case "hls+http":
+		base.Scheme = "http"
+	case "hls+https":
+		base.Scheme = "https"
+	default:
+		return nil, errors.New("invalid scheme")
+	}
+	if parsedUrl.Host == "" {
+		return nil, errors.New("hls push urls must be of format hls+http(s)://({credentials}@){host}(:{port})/{path}/{playlist}.m3u8")
+	}
+	base.User = nil
+
+	conf := &HLSPushConfig{
+		Method: http.MethodPut,
+		Header: make(http.Header),
+		base:   &base,
+	}
+
+	query := parsedUrl.Query()
+	if method := query.Get(hlsPushMethodParam); method != "" {
+		conf.Method = strings.ToUpper(method)
+		if conf.Method != http.MethodPut && conf.Method != http.MethodPost {
+			return nil, errors.New("hls push method must be put or post")
+		}
+	}
+	for _, header := range query[hlsPushHeaderParam] {
+		name, value, ok := strings.Cut(header, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, errors.New("hls push headers must be of format header={name}:{value}")
+		}
+		conf.Header.Add(name, strings.TrimSpace(value))
+	}
+	if query.Has(hlsPushMethodParam) || query.Has(hlsPushHeaderParam) {
+		query.Del(hlsPushMethodParam)
+		query.Del(hlsPushHeaderParam)
+		base.RawQuery = query.Encode()
+	}
+
+	if query.Has(hlsPushFileParam) {
+		conf.fileParam = true
+		conf.Playlist = query.Get(hlsPushFileParam)
+	} else {
+		conf.Playlist = path.Base(parsedUrl.Path)
+		base.Path = path.Dir(parsedUrl.Path)
+	}
+	if path.Ext(conf.Playlist) != types.FileExtensionM3U8 || strings.Contains(conf.Playlist, "/") {
+		return nil, errors.New("hls push urls must end with a .m3u8 playlist")
+	}
+
+	if parsedUrl.User != nil {
+		if password, ok := parsedUrl.User.Password(); ok {
+			credentials := parsedUrl.User.Username() + ":" + password
+			conf.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)))
+		} else if token := parsedUrl.User.Username(); token != "" {
+			conf.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	return conf, nil
+}
+
+// FileUrl returns the url a segment or playlist is uploaded to
+func (c *HLSPushConfig) FileUrl(filename string) string {
+	u := *c.base
+	if c.fileParam {
+		query := u.Query()
+		query.Set(hlsPushFileParam, filename)
+		u.RawQuery = query.Encode()
+	} else {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + filename
+	}
+	return u.String()
+}
+
+// redactHLSPushUrl redacts credentials, header values, and the values of query parameters which often hold
+// stream keys, e.g. hls+https://a.upload.youtube.com/http_upload_hls?cid={str...key}&file=stream.m3u8
+func redactHLSPushUrl(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	if u.RawQuery != "" {
+		params := strings.Split(u.RawQuery, "&")
+		for i, param := range params {
+			k, v, ok := strings.Cut(param, "=")
+			if !ok || k == hlsPushFileParam || k == hlsPushMethodParam {
+				continue
+			}
+			if k == hlsPushHeaderParam {
+				if header, err := url.QueryUnescape(v); err == nil {
+					if name, value, ok := strings.Cut(header, ":"); ok {
+						params[i] = k + "=" + name + ":" + utils.RedactIdentifier(value)
+						continue
+					}
+				}
+			}
+			params[i] = k + "=" + utils.RedactIdentifier(v)
+		}
+		redacted.RawQuery = strings.Join(params, "&")
+	}
+
+	var userinfo string
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			userinfo = fmt.Sprintf("%s:%s@", u.User.Username(), utils.RedactIdentifier(password))
+		} else if username := u.User.Username(); username != "" {
+			userinfo = utils.RedactIdentifier(username) + "@"
+		}
+	}
+	return strings.Replace(redacted.String(), "://", "://"+userinfo, 1)
+}
+
+// getHLSPushSegmentConfig segments the stream locally, the same way as segment outputs
+func (p *PipelineConfig) getHLSPushSegmentConfig(push *HLSPushConfig) (*SegmentConfig, error) {
+	localDir := path.Join(p.TmpDir, hlsPushDir)
+	if err := os.MkdirAll(localDir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &SegmentConfig{
+		outputConfig:     outputConfig{OutputType: types.OutputTypeHLS},
+		SegmentsInfo:     &livekit.SegmentsInfo{},
+		LocalDir:         localDir,
+		PlaylistFilename: push.Playlist,
+		SegmentPrefix:    strings.TrimSuffix(push.Playlist, types.FileExtensionM3U8),
+		SegmentDuration:  hlsPushSegmentDuration,
+		SegmentContainer: types.OutputTypeTS,
+		DisableManifest:  true,
+		Push:             push,
+	}, nil
+}
+
+// updateHLSPushSegmentDuration keeps pushed segments aligned with the keyframe interval of other streams
+func (p *PipelineConfig) updateHLSPushSegmentDuration() {
+	if o := p.GetHLSPushConfig(); o != nil && p.KeyFrameInterval > 0 {
+		// segments are cut on keyframes, so they cannot be shorter than the interval
+		o.Segments.SegmentDuration = min(int(math.Ceil(p.KeyFrameInterval)), o.Segments.SegmentDuration)
+	}
+}
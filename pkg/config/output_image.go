@@ -18,6 +18,8 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/livekit/egress/pkg/errors"
@@ -46,6 +48,53 @@ type ImageConfig struct {
 	Width           int32
 	Height          int32
 	ImageOutCodec   types.MimeType
+
+	Quality          int // jpeg and webp quality, 0 uses the encoder default
+	CompressionLevel int // png compression level, 0 uses the encoder default
+
+	// frames are tiled into sprite sheets with a WebVTT thumbnail track if set
+	SpriteColumns int
+	SpriteRows    int
+
+	// images are captured when the scene changes if set, no more often than the min interval
+	// and, if a max interval is set, at least that often
+	SceneChangeThreshold float64
+	MinCaptureInterval   time.Duration
+	MaxCaptureInterval   time.Duration
+
+	// images matching the previous upload are skipped if set
+	Dedupe          bool
+	DedupeThreshold int
+
+	// capture time and source are embedded as EXIF and XMP if set
+	EmbedMetadata bool
+}
+
+const (
+	defaultSpriteGridSize  = 5
+	defaultSpriteTileWidth = 160
+
+	defaultSceneChangeThreshold = 0.1
+	defaultMinCaptureInterval   = time.Second
+)
+
+// IsSpriteSheet returns true if frames are tiled into sprite sheets
+func (o *ImageConfig) IsSpriteSheet() bool {
+	return o.SpriteColumns > 0 && o.SpriteRows > 0
+}
+
+// FrameOutputType returns the type of the images written by the pipeline. Sprite sheet tiles are written
+// as lossless png, and only compressed once with their sheet.
+func (o *ImageConfig) FrameOutputType() types.OutputType {
+	if o.IsSpriteSheet() {
+		return types.OutputTypePNG
+	}
+	return o.OutputType
+}
+
+// IsSceneChange returns true if images are only captured when the scene changes
+func (o *ImageConfig) IsSceneChange() bool {
+	return o.SceneChangeThreshold > 0
 }
 
 func (p *PipelineConfig) GetImageConfigs() []*ImageConfig {
@@ -78,7 +127,42 @@ func (p *PipelineConfig) getImageConfig(images *livekit.ImageOutput, upload egre
 		return nil, err
 	}
 
-	filenamePrefix := clean(images.FilenamePrefix)
+	filenamePrefix, options, err := splitOutputOptions("filename_prefix", images.FilenamePrefix, imageOptions...)
+	if err != nil {
+		return nil, err
+	}
+	filenamePrefix = clean(filenamePrefix)
+
+	columns, rows, spriteSheet, err := options.spriteGrid()
+	if err != nil {
+		return nil, err
+	}
+
+	if images.ImageCodec == livekit.ImageCodec_IC_DEFAULT {
+		// png and webp can be selected with the prefix extension, e.g. thumbnails/room.webp
+		if codec, ot, ok := getPrefixMimeTypes(filenamePrefix); ok {
+			outCodec, outputType = codec, ot
+			filenamePrefix = filenamePrefix[:len(filenamePrefix)-len(path.Ext(filenamePrefix))]
+		}
+	}
+
+	if spriteSheet && outputType == types.OutputTypeWebP {
+		return nil, errors.ErrNotSupported("webp sprite sheets")
+	}
+
+	if q := p.ImageOutput.Quality; q < 0 || q > 100 {
+		return nil, errors.ErrInvalidInput("image_output.quality")
+	}
+	if c := p.ImageOutput.CompressionLevel; c < 0 || c > 9 {
+		return nil, errors.ErrInvalidInput("image_output.compression_level")
+	}
+	if t := p.ImageOutput.SceneChange.Threshold; t < 0 || t > 1 {
+		return nil, errors.ErrInvalidInput("image_output.scene_change.threshold")
+	}
+	if t := p.ImageOutput.Dedupe.Threshold; t < 0 || t > 64 {
+		return nil, errors.ErrInvalidInput("image_output.dedupe.threshold")
+	}
+
 	conf := &ImageConfig{
 		outputConfig: outputConfig{
 			OutputType: outputType,
@@ -96,6 +180,9 @@ func (p *PipelineConfig) getImageConfig(images *livekit.ImageOutput, upload egre
 		Width:           images.Width,
 		Height:          images.Height,
 		ImageOutCodec:   outCodec,
+
+		Quality:          p.ImageOutput.Quality,
+		CompressionLevel: p.ImageOutput.CompressionLevel,
 	}
 
 	if conf.CaptureInterval == 0 {
@@ -103,6 +190,62 @@ func (p *PipelineConfig) getImageConfig(images *livekit.ImageOutput, upload egre
 		conf.CaptureInterval = 10
 	}
 
+	if err = conf.updateDedupe(p, options, spriteSheet); err != nil {
+		return nil, err
+	}
+
+	if err = conf.updateEmbedMetadata(p, options, spriteSheet); err != nil {
+		return nil, err
+	}
+
+	// sprite sheet thumbnail tracks require a fixed interval
+	sceneChange, requested, err := options.sceneChangeThreshold(p.ImageOutput.SceneChange.Threshold)
+	if err != nil {
+		return nil, err
+	}
+	if sceneChange > 0 && spriteSheet {
+		if requested {
+			return nil, errors.ErrInvalidInput(fmt.Sprintf("%s with %s", imageOptionSceneChange, imageOptionSprite))
+		}
+		sceneChange = 0
+	}
+	if sceneChange > 0 {
+		conf.SceneChangeThreshold = sceneChange
+		conf.MinCaptureInterval = p.ImageOutput.SceneChange.MinInterval
+		if conf.MinCaptureInterval <= 0 {
+			conf.MinCaptureInterval = defaultMinCaptureInterval
+		}
+		conf.MaxCaptureInterval = p.ImageOutput.SceneChange.MaxInterval
+		if conf.MaxCaptureInterval > 0 && conf.MaxCaptureInterval < conf.MinCaptureInterval {
+			return nil, errors.ErrInvalidInput("image_output.scene_change.max_interval")
+		}
+	}
+
+	if spriteSheet {
+		conf.SpriteColumns, conf.SpriteRows = columns, rows
+		if conf.SpriteColumns == 0 {
+			conf.SpriteColumns = p.ImageOutput.SpriteColumns
+		}
+		if conf.SpriteColumns <= 0 {
+			conf.SpriteColumns = defaultSpriteGridSize
+		}
+		if conf.SpriteRows == 0 {
+			conf.SpriteRows = p.ImageOutput.SpriteRows
+		}
+		if conf.SpriteRows <= 0 {
+			conf.SpriteRows = defaultSpriteGridSize
+		}
+
+		// sprites are small thumbnails unless dimensions were requested
+		if conf.Width == 0 && conf.Height == 0 {
+			conf.Width = defaultSpriteTileWidth
+			conf.Height = defaultSpriteTileWidth * 9 / 16
+			if p.Width > 0 && p.Height > 0 {
+				conf.Height = (defaultSpriteTileWidth * p.Height / p.Width) &^ 1
+			}
+		}
+	}
+
 	// Set default dimensions for RoomComposite and Web. For all SDKs input, default will be
 	// set from the track dimensions
 	setDims := func(request egress.EgressRequest) {
@@ -167,6 +310,144 @@ func (o *ImageConfig) updatePrefix(p *PipelineConfig) error {
 	return os.MkdirAll(o.LocalDir, 0755)
 }
 
+const (
+	// tiles frames into sprite sheets with a WebVTT thumbnail track. The grid can be set as {columns}x{rows},
+	// e.g. "thumbnails/room?sprite=10x10", and defaults to the image_output config.
+	imageOptionSprite = "sprite"
+	// captures images when the scene changes, with an optional threshold from 0 to 1, e.g. "slides/room?scene_change=0.05".
+	// Defaults to the image_output config, and 0 disables it. Not supported with sprite sheets.
+	imageOptionSceneChange = "scene_change"
+	// skips images matching the previous upload, e.g. "thumbs/room?dedupe=1". Defaults to image_output.dedupe.enabled.
+	// Not supported with webp images or sprite sheets.
+	imageOptionDedupe = "dedupe"
+	// maximum number of differing perceptual hash bits, 0-64. Defaults to image_output.dedupe.threshold
+	imageOptionDedupeThreshold = "dedupe_threshold"
+	// embeds the capture time and source as EXIF and XMP, e.g. "thumbs/room?metadata=1". Defaults to
+	// image_output.embed_metadata. Not supported with webp images or sprite sheets.
+	imageOptionMetadata = "metadata"
+)
+
+var imageOptions = []string{
+	imageOptionSprite,
+	imageOptionSceneChange,
+	imageOptionDedupe,
+	imageOptionDedupeThreshold,
+	imageOptionMetadata,
+}
+
+func (o outputOptions) spriteGrid() (int, int, bool, error) {
+	v, ok := o[imageOptionSprite]
+	if !ok {
+		return 0, 0, false, nil
+	}
+	if v[0] == "" {
+		return 0, 0, true, nil
+	}
+
+	columns, rows, ok := strings.Cut(v[0], "x")
+	c, err := strconv.Atoi(columns)
+	if err != nil || !ok || c <= 0 {
+		return 0, 0, false, errors.ErrInvalidInput("sprite")
+	}
+	r, err := strconv.Atoi(rows)
+	if err != nil || r <= 0 {
+		return 0, 0, false, errors.ErrInvalidInput("sprite")
+	}
+	return c, r, true, nil
+}
+
+// updateDedupe applies the dedupe options, using the image_output config as defaults. Webp images cannot be
+// decoded for hashing, and sprite sheets keep every frame.
+func (o *ImageConfig) updateDedupe(p *PipelineConfig, options outputOptions, spriteSheet bool) error {
+	dedupe, err := options.boolean(imageOptionDedupe, p.ImageOutput.Dedupe.Enabled)
+	if err != nil {
+		return err
+	}
+	threshold := p.ImageOutput.Dedupe.Threshold
+	if v := options.get(imageOptionDedupeThreshold); v != "" {
+		if threshold, err = strconv.Atoi(v); err != nil || threshold < 0 || threshold > 64 {
+			return errors.ErrInvalidInput(imageOptionDedupeThreshold)
+		}
+	}
+	if !dedupe {
+		if _, ok := options[imageOptionDedupeThreshold]; ok {
+			return errors.ErrInvalidInput(fmt.Sprintf("%s requires %s", imageOptionDedupeThreshold, imageOptionDedupe))
+		}
+		return nil
+	}
+
+	if o.OutputType == types.OutputTypeWebP || spriteSheet {
+		_, requested := options[imageOptionDedupe]
+		_, thresholdRequested := options[imageOptionDedupeThreshold]
+		if !requested && !thresholdRequested {
+			// the service default only applies to jpeg and png images
+			return nil
+		}
+		if spriteSheet {
+			return errors.ErrNotSupported("dedupe with sprite sheets")
+		}
+		return errors.ErrNotSupported("dedupe with webp images")
+	}
+
+	o.Dedupe = true
+	o.DedupeThreshold = threshold
+	return nil
+}
+
+// updateEmbedMetadata applies the metadata option, using the image_output config as default. Webp images are
+// uploaded as encoded, and sprite sheets have a capture time per tile.
+func (o *ImageConfig) updateEmbedMetadata(p *PipelineConfig, options outputOptions, spriteSheet bool) error {
+	embed, err := options.boolean(imageOptionMetadata, p.ImageOutput.EmbedMetadata)
+	if err != nil || !embed {
+		return err
+	}
+
+	if o.OutputType == types.OutputTypeWebP || spriteSheet {
+		if _, ok := options[imageOptionMetadata]; !ok {
+			// the service default only applies to jpeg and png images
+			return nil
+		}
+		if spriteSheet {
+			return errors.ErrNotSupported("metadata with sprite sheets")
+		}
+		return errors.ErrNotSupported("metadata with webp images")
+	}
+
+	o.EmbedMetadata = true
+	return nil
+}
+
+// sceneChangeThreshold returns the scene change threshold, and whether scene change capture was requested
+func (o outputOptions) sceneChangeThreshold(defaultThreshold float64) (float64, bool, error) {
+	v, ok := o[imageOptionSceneChange]
+	if !ok {
+		return defaultThreshold, false, nil
+	}
+	if v[0] == "" {
+		if defaultThreshold > 0 {
+			return defaultThreshold, true, nil
+		}
+		return defaultSceneChangeThreshold, true, nil
+	}
+
+	t, err := strconv.ParseFloat(v[0], 64)
+	if err != nil || t < 0 || t > 1 {
+		return 0, false, errors.ErrInvalidInput(imageOptionSceneChange)
+	}
+	return t, true, nil
+}
+
+func getPrefixMimeTypes(prefix string) (types.MimeType, types.OutputType, bool) {
+	switch types.FileExtension(path.Ext(prefix)) {
+	case types.FileExtensionPNG:
+		return types.MimeTypePNG, types.OutputTypePNG, true
+	case types.FileExtensionWebP:
+		return types.MimeTypeWebP, types.OutputTypeWebP, true
+	default:
+		return "", "", false
+	}
+}
+
 func getMimeTypes(imageCodec livekit.ImageCodec) (types.MimeType, types.OutputType, error) {
 	switch imageCodec {
 	case livekit.ImageCodec_IC_DEFAULT, livekit.ImageCodec_IC_JPEG:
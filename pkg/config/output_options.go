@@ -0,0 +1,102 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/livekit/egress/pkg/errors"
+)
+
+// Storage outputs accept options which are not part of the request as a query string on their
+// filename, e.g. "playlist.m3u8?container=fmp4". Filenames containing a '?' which is not followed
+// by known options are used as they are. Repeated options are rejected.
+type outputOptions url.Values
+
+// splitOutputOptions removes the query string from an output filename, and parses its options
+func splitOutputOptions(field, filename string, allowed ...string) (string, outputOptions, error) {
+	idx := strings.LastIndexByte(filename, '?')
+	if idx < 0 {
+		return filename, outputOptions{}, nil
+	}
+
+	values, err := url.ParseQuery(filename[idx+1:])
+	if err != nil || len(values) == 0 {
+		return filename, outputOptions{}, nil
+	}
+	for key := range values {
+		if !slices.Contains(allowed, key) {
+			// not an option string, but part of the filename
+			return filename, outputOptions{}, nil
+		}
+	}
+	for key, v := range values {
+		if len(v) != 1 {
+			return "", nil, errors.ErrInvalidInput(fmt.Sprintf("%s option %s", field, key))
+		}
+	}
+
+	return filename[:idx], outputOptions(values), nil
+}
+
+// merge adds options set on another field, which cannot set the same option
+func (o outputOptions) merge(field string, other outputOptions) error {
+	for key, v := range other {
+		if _, ok := o[key]; ok {
+			return errors.ErrInvalidInput(fmt.Sprintf("%s option %s", field, key))
+		}
+		o[key] = v
+	}
+	return nil
+}
+
+func (o outputOptions) get(key string) string {
+	return url.Values(o).Get(key)
+}
+
+// boolean parses a boolean option, e.g. "1" or "false". Options without a value are true, missing options
+// are defaultValue.
+func (o outputOptions) boolean(key string, defaultValue bool) (bool, error) {
+	if _, ok := o[key]; !ok {
+		return defaultValue, nil
+	}
+	v := o.get(key)
+	if v == "" {
+		return true, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, errors.ErrInvalidInput(key)
+	}
+	return b, nil
+}
+
+// duration parses a Go duration option, e.g. "500ms". Missing options are 0.
+func (o outputOptions) duration(key string) (time.Duration, error) {
+	v := o.get(key)
+	if v == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, errors.ErrInvalidInput(key)
+	}
+	return d, nil
+}
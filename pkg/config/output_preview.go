@@ -0,0 +1,207 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/egress/pkg/types"
+)
+
+// PreviewConfig is a short animated preview of a file output, e.g. for hover previews in a recordings gallery
+type PreviewConfig struct {
+	outputConfig
+
+	LocalFilepath   string
+	StorageFilepath string
+
+	Duration  time.Duration
+	Sampled   bool // frames are spread across the recording, otherwise the preview is the start of the recording
+	Width     int32
+	Height    int32
+	FrameRate int32
+
+	StorageConfig *StorageConfig
+}
+
+// previewRequest holds the preview options of a file output
+type previewRequest struct {
+	outputType types.OutputType
+	duration   time.Duration
+	sampled    bool
+}
+
+const (
+	defaultPreviewDuration  = 3 * time.Second
+	defaultPreviewWidth     = 320
+	defaultPreviewFrameRate = 10
+
+	previewFilenameSuffix = "_preview"
+)
+
+const (
+	// adds an animated preview of the recording, as "webp", "gif" or "mp4" (silent),
+	// e.g. "recordings/room.mp4?preview=gif". Defaults to the preview_clip format.
+	fileOptionPreview = "preview"
+	// preview length, e.g. "5s"
+	fileOptionPreviewDuration = "preview_duration"
+	// "sampled" (default) spreads the preview frames across the recording, "start" uses its first seconds
+	fileOptionPreviewMode = "preview_mode"
+
+	previewModeSampled = "sampled"
+	previewModeStart   = "start"
+)
+
+var fileOptions = []string{
+	fileOptionFragmented,
+	fileOptionFastStart,
+	fileOptionPreview,
+	fileOptionPreviewDuration,
+	fileOptionPreviewMode,
+}
+
+func (p *PipelineConfig) GetPreviewConfig() *PreviewConfig {
+	o, ok := p.Outputs[types.EgressTypePreview]
+	if !ok || len(o) == 0 {
+		return nil
+	}
+	return o[0].(*PreviewConfig)
+}
+
+// FrameCount returns the number of frames in the preview
+func (o *PreviewConfig) FrameCount() int {
+	return int(o.Duration * time.Duration(o.FrameRate) / time.Second)
+}
+
+// getFileOptions removes the options from a file output's filepath
+func getFileOptions(filepath string) (string, outputOptions, error) {
+	return splitOutputOptions("filepath", filepath, fileOptions...)
+}
+
+// PreviewRequested returns true if a file output requests a preview, which needs its own encoder
+func PreviewRequested(filepath string) bool {
+	_, options, err := getFileOptions(filepath)
+	if err != nil {
+		return false
+	}
+	_, ok := options[fileOptionPreview]
+	return ok
+}
+
+func (o outputOptions) previewRequest(p *PipelineConfig) (*previewRequest, error) {
+	if _, ok := o[fileOptionPreview]; !ok {
+		for _, option := range []string{fileOptionPreviewDuration, fileOptionPreviewMode} {
+			if _, ok = o[option]; ok {
+				return nil, errors.ErrInvalidInput(fmt.Sprintf("%s requires %s", option, fileOptionPreview))
+			}
+		}
+		return nil, nil
+	}
+
+	format := o.get(fileOptionPreview)
+	if format == "" {
+		format = p.PreviewClip.Format
+	}
+	req := &previewRequest{}
+	switch strings.ToLower(format) {
+	case "", "webp":
+		req.outputType = types.OutputTypeWebP
+	case "gif":
+		req.outputType = types.OutputTypeGIF
+	case "mp4":
+		req.outputType = types.OutputTypeMP4
+	default:
+		return nil, errors.ErrInvalidInput(fileOptionPreview)
+	}
+
+	duration, err := o.duration(fileOptionPreviewDuration)
+	if err != nil {
+		return nil, err
+	}
+	req.duration = duration
+
+	switch o.get(fileOptionPreviewMode) {
+	case "", previewModeSampled:
+		req.sampled = true
+	case previewModeStart:
+	default:
+		return nil, errors.ErrInvalidInput(fileOptionPreviewMode)
+	}
+
+	return req, nil
+}
+
+// updatePreviewOutput adds a preview output for the file output, if requested
+func (p *PipelineConfig) updatePreviewOutput() error {
+	file := p.GetFileConfig()
+	if file == nil || file.preview == nil {
+		return nil
+	}
+	if !p.VideoEnabled || !p.VideoDecoding {
+		return errors.ErrNotSupported("preview without decoded video")
+	}
+
+	conf := &PreviewConfig{
+		outputConfig:  outputConfig{OutputType: file.preview.outputType},
+		Duration:      file.preview.duration,
+		Sampled:       file.preview.sampled,
+		Width:         p.PreviewClip.Width,
+		FrameRate:     p.PreviewClip.FrameRate,
+		StorageConfig: file.StorageConfig,
+	}
+	if conf.Duration <= 0 {
+		conf.Duration = p.PreviewClip.Duration
+	}
+	if conf.Duration <= 0 {
+		conf.Duration = defaultPreviewDuration
+	}
+	if conf.Width <= 0 {
+		conf.Width = defaultPreviewWidth
+	}
+	if conf.FrameRate <= 0 {
+		conf.FrameRate = defaultPreviewFrameRate
+	}
+	if conf.FrameCount() == 0 {
+		return errors.ErrInvalidInput(fileOptionPreviewDuration)
+	}
+
+	// encoders require even dimensions
+	conf.Width &^= 1
+	conf.Height = (conf.Width * 9 / 16) &^ 1
+	if p.Width > 0 && p.Height > 0 {
+		conf.Height = (conf.Width * p.Height / p.Width) &^ 1
+	}
+
+	conf.updateFilepath(p, file)
+	p.Outputs[types.EgressTypePreview] = []OutputConfig{conf}
+	return nil
+}
+
+// updateFilepath names the preview after the file output, e.g. recordings/room.mp4 -> recordings/room_preview.webp
+func (o *PreviewConfig) updateFilepath(p *PipelineConfig, file *FileConfig) {
+	filepath := file.StorageFilepath
+	if ext := path.Ext(filepath); ext != "" {
+		if _, ok := types.FileExtensions[types.FileExtension(ext)]; ok {
+			filepath = filepath[:len(filepath)-len(ext)]
+		}
+	}
+
+	o.StorageFilepath = filepath + previewFilenameSuffix + string(types.FileExtensionForOutputType[o.OutputType])
+	o.LocalFilepath = path.Join(p.TmpDir, path.Base(o.StorageFilepath))
+}
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -38,9 +39,23 @@ type SegmentConfig struct {
 	SegmentPrefix        string
 	SegmentSuffix        livekit.SegmentedFileSuffix
 	SegmentDuration      int
+	SegmentContainer     types.OutputType
+
+	// low latency HLS parts, published in the live playlist
+	PartDuration time.Duration
+	PartHoldBack time.Duration
+
+	// adaptive bitrate renditions are segment outputs with their own encoder and variant playlist,
+	// listed by the main output's master playlist
+	Rendition  *Rendition
+	Variants   []*SegmentConfig
+	renditions []Rendition // requested renditions
 
 	DisableManifest bool
 	StorageConfig   *StorageConfig
+
+	// hls push streams are uploaded to an http origin instead of storage
+	Push *HLSPushConfig
 }
 
 func (p *PipelineConfig) GetSegmentConfig() *SegmentConfig {
@@ -51,6 +66,68 @@ func (p *PipelineConfig) GetSegmentConfig() *SegmentConfig {
 	return o[0].(*SegmentConfig)
 }
 
+// GetOutputType returns the segment container for fMP4 playlists, which supports more codecs than MPEG-TS
+func (o *SegmentConfig) GetOutputType() types.OutputType {
+	if o.SegmentContainer == types.OutputTypeFMP4 {
+		return types.OutputTypeFMP4
+	}
+	return o.OutputType
+}
+
+// InitSegmentFilename returns the name of the fMP4 initialization segment, or an empty string for MPEG-TS
+func (o *SegmentConfig) InitSegmentFilename() string {
+	if o.SegmentContainer != types.OutputTypeFMP4 {
+		return ""
+	}
+	return fmt.Sprintf("%s_init%s", o.SegmentPrefix, types.FileExtensionMP4)
+}
+
+// LowLatency returns true if LL-HLS parts are published
+func (o *SegmentConfig) LowLatency() bool {
+	return o.PartDuration > 0
+}
+
+// RenditionName returns the name of the adaptive bitrate rendition, or an empty string for the main output
+func (o *SegmentConfig) RenditionName() string {
+	if o.Rendition == nil {
+		return ""
+	}
+	return o.Rendition.Name
+}
+
+// MasterPlaylistFilename returns the name of the playlist listing all renditions, if any
+func (o *SegmentConfig) MasterPlaylistFilename() string {
+	if len(o.Variants) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s_master%s", removeKnownExtension(o.PlaylistFilename), types.FileExtensionM3U8)
+}
+
+// LiveMasterPlaylistFilename returns the name of the playlist listing all live rendition playlists, if any
+func (o *SegmentConfig) LiveMasterPlaylistFilename() string {
+	if len(o.Variants) == 0 || o.LivePlaylistFilename == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s_master%s", removeKnownExtension(o.LivePlaylistFilename), types.FileExtensionM3U8)
+}
+
+// MPDFilename returns the name of the DASH manifest written next to the playlist. DASH manifests are only
+// generated for fMP4 segments, and not for adaptive bitrate renditions.
+func (o *SegmentConfig) MPDFilename() string {
+	if o.SegmentContainer != types.OutputTypeFMP4 || o.Rendition != nil {
+		return ""
+	}
+	return removeKnownExtension(o.PlaylistFilename) + string(types.FileExtensionMPD)
+}
+
+// LiveMPDFilename returns the name of the sliding window DASH manifest, if a live playlist was requested
+func (o *SegmentConfig) LiveMPDFilename() string {
+	if o.MPDFilename() == "" || o.LivePlaylistFilename == "" {
+		return ""
+	}
+	return removeKnownExtension(o.LivePlaylistFilename) + string(types.FileExtensionMPD)
+}
+
 // segments should always be added last, so we can check keyframe interval from file/stream
 func (p *PipelineConfig) getSegmentConfig(segments *livekit.SegmentedFileOutput, upload egress.UploadRequest) (*SegmentConfig, error) {
 	sc, err := p.getStorageConfig(upload)
@@ -58,10 +135,23 @@ func (p *PipelineConfig) getSegmentConfig(segments *livekit.SegmentedFileOutput,
 		return nil, err
 	}
 
-	prefix := clean(segments.FilenamePrefix)
-	playlist := clean(segments.PlaylistName)
+	prefix, playlist, options, err := getSegmentOptions(segments)
+	if err != nil {
+		return nil, err
+	}
+	prefix = clean(prefix)
+	playlist = clean(playlist)
+
+	container, err := options.segmentContainer()
+	if err != nil {
+		return nil, err
+	}
+	renditions, err := options.renditions()
+	if err != nil {
+		return nil, err
+	}
 
-	// On retry, segment filenames are "{prefix}_{index}.ts" so prefix must contain {retry}
+	// On retry, segment filenames are "{prefix}_{index}.ts" (or .m4s) so prefix must contain {retry}
 	// to avoid overwriting. When prefix is empty it derives from playlist name, so playlist
 	// must contain {retry}. When both are empty, names are auto-generated with retry count.
 	if p.Info.RetryCount > 0 {
@@ -80,7 +170,9 @@ func (p *PipelineConfig) getSegmentConfig(segments *livekit.SegmentedFileOutput,
 		PlaylistFilename:     playlist,
 		LivePlaylistFilename: clean(segments.LivePlaylistName),
 		SegmentDuration:      int(segments.SegmentDuration),
+		SegmentContainer:     container,
 		DisableManifest:      segments.DisableManifest,
+		renditions:           renditions,
 		StorageConfig:        sc,
 	}
 
@@ -88,6 +180,10 @@ func (p *PipelineConfig) getSegmentConfig(segments *livekit.SegmentedFileOutput,
 		conf.SegmentDuration = 4
 	}
 
+	if err = conf.updateLowLatency(options); err != nil {
+		return nil, err
+	}
+
 	switch segments.Protocol {
 	case livekit.SegmentedFileProtocol_DEFAULT_SEGMENTED_FILE_PROTOCOL,
 		livekit.SegmentedFileProtocol_HLS_PROTOCOL:
@@ -102,6 +198,229 @@ func (p *PipelineConfig) getSegmentConfig(segments *livekit.SegmentedFileOutput,
 	return conf, nil
 }
 
+const (
+	// selects the segment container, "ts" (default) or "fmp4" (CMAF)
+	segmentOptionContainer = "container"
+	// enables low latency HLS with parts of this duration, e.g. "500ms". Playlists are uploaded to storage,
+	// so blocking playlist reloads are not supported and players poll the live playlist.
+	segmentOptionPartDuration = "part_duration"
+	// how far behind the live edge players should stay, defaults to 3 parts
+	segmentOptionPartHoldBack = "part_hold_back"
+	// adaptive bitrate renditions, as {width}x{height} with an optional @{kbps} bitrate, e.g. "1280x720@2500,640x360"
+	segmentOptionRenditions = "renditions"
+
+	// lowest default rendition bitrate, in kbps
+	minRenditionBitrate = 300
+)
+
+var segmentOptions = []string{
+	segmentOptionContainer,
+	segmentOptionPartDuration,
+	segmentOptionPartHoldBack,
+	segmentOptionRenditions,
+}
+
+// Rendition is an additional encoding of a segment output, scaled from the decoded video
+type Rendition struct {
+	Name         string // used in variant playlist and segment names, e.g. 720p
+	Width        int32
+	Height       int32
+	VideoBitrate int32 // kbps, defaults to the output bitrate scaled by pixel count
+}
+
+// getSegmentOptions removes the options from the filename prefix and playlist name
+func getSegmentOptions(segments *livekit.SegmentedFileOutput) (string, string, outputOptions, error) {
+	prefix, options, err := splitOutputOptions("filename_prefix", segments.FilenamePrefix, segmentOptions...)
+	if err != nil {
+		return "", "", nil, err
+	}
+	playlist, playlistOptions, err := splitOutputOptions("playlist_name", segments.PlaylistName, segmentOptions...)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if err = options.merge("playlist_name", playlistOptions); err != nil {
+		return "", "", nil, err
+	}
+	return prefix, playlist, options, nil
+}
+
+// RenditionCount returns the number of adaptive bitrate renditions requested by a segment output,
+// each of which needs its own encoder
+func RenditionCount(segments *livekit.SegmentedFileOutput) int {
+	_, _, options, err := getSegmentOptions(segments)
+	if err != nil {
+		return 0
+	}
+	renditions, err := options.renditions()
+	if err != nil {
+		return 0
+	}
+	return len(renditions)
+}
+
+func (o outputOptions) renditions() ([]Rendition, error) {
+	v := o.get(segmentOptionRenditions)
+	if v == "" {
+		return nil, nil
+	}
+
+	var renditions []Rendition
+	for _, r := range strings.Split(v, ",") {
+		rendition, err := parseRendition(r)
+		if err != nil {
+			return nil, err
+		}
+		for _, existing := range renditions {
+			if existing.Name == rendition.Name {
+				return nil, errors.ErrInvalidInput(fmt.Sprintf("rendition %s", r))
+			}
+		}
+		renditions = append(renditions, rendition)
+	}
+	return renditions, nil
+}
+
+// parseRendition parses {width}x{height}[@{kbps}]. Renditions are named after their height.
+func parseRendition(r string) (Rendition, error) {
+	size, bitrate, hasBitrate := strings.Cut(r, "@")
+	width, height, ok := strings.Cut(size, "x")
+	if !ok {
+		return Rendition{}, errors.ErrInvalidInput(fmt.Sprintf("rendition %s", r))
+	}
+
+	values := []string{width, height}
+	if hasBitrate {
+		values = append(values, bitrate)
+	}
+	parsed := make([]int32, 3)
+	for i, value := range values {
+		n, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || n <= 0 {
+			return Rendition{}, errors.ErrInvalidInput(fmt.Sprintf("rendition %s", r))
+		}
+		parsed[i] = int32(n)
+	}
+
+	return Rendition{
+		Name:         fmt.Sprintf("%dp", parsed[1]),
+		Width:        parsed[0],
+		Height:       parsed[1],
+		VideoBitrate: parsed[2],
+	}, nil
+}
+
+func (o outputOptions) segmentContainer() (types.OutputType, error) {
+	switch o.get(segmentOptionContainer) {
+	case "", "ts":
+		return types.OutputTypeTS, nil
+	case "fmp4", "cmaf":
+		return types.OutputTypeFMP4, nil
+	default:
+		return "", errors.ErrInvalidInput("segment container")
+	}
+}
+
+// updateLowLatency enables LL-HLS when a part duration is requested. Parts are fragments of fMP4 segments,
+// and are only published in the live playlist.
+func (o *SegmentConfig) updateLowLatency(options outputOptions) error {
+	partDuration, err := options.duration(segmentOptionPartDuration)
+	if err != nil {
+		return err
+	}
+	partHoldBack, err := options.duration(segmentOptionPartHoldBack)
+	if err != nil {
+		return err
+	}
+
+	if partDuration == 0 {
+		if partHoldBack != 0 {
+			return errors.ErrInvalidInput("part_hold_back requires part_duration")
+		}
+		return nil
+	}
+	if o.LivePlaylistFilename == "" {
+		return errors.ErrInvalidInput("part_duration requires live_playlist_name")
+	}
+	if o.SegmentContainer != types.OutputTypeFMP4 {
+		return errors.ErrInvalidInput("part_duration requires container=fmp4")
+	}
+	if partDuration*2 > time.Duration(o.SegmentDuration)*time.Second {
+		return errors.ErrInvalidInput("part_duration must be at most half of segment_duration")
+	}
+
+	// players must stay at least two parts behind the live edge
+	if partHoldBack == 0 {
+		partHoldBack = partDuration * 3
+	} else if partHoldBack < partDuration*2 {
+		return errors.ErrInvalidInput("part_hold_back must be at least twice part_duration")
+	}
+
+	o.PartDuration = partDuration
+	o.PartHoldBack = partHoldBack
+	return nil
+}
+
+// getRenditionConfigs creates a segment output for each requested rendition smaller than the output.
+// A rendition the size of the output is served by the main variant.
+func (p *PipelineConfig) getRenditionConfigs(conf *SegmentConfig) ([]OutputConfig, error) {
+	// renditions are scaled from the decoded video
+	if !p.VideoDecoding {
+		return nil, nil
+	}
+
+	var renditions []OutputConfig
+	for i := range conf.renditions {
+		r := &conf.renditions[i]
+		if r.Width > p.Width || r.Height > p.Height {
+			return nil, errors.ErrInvalidInput(fmt.Sprintf("rendition %dx%d is larger than the output", r.Width, r.Height))
+		}
+		if r.Width == p.Width && r.Height == p.Height {
+			continue
+		}
+		if r.VideoBitrate == 0 {
+			r.VideoBitrate = p.renditionBitrate(r)
+		}
+
+		rc := &SegmentConfig{
+			outputConfig:     conf.outputConfig,
+			SegmentsInfo:     &livekit.SegmentsInfo{},
+			LocalDir:         conf.LocalDir,
+			StorageDir:       conf.StorageDir,
+			PlaylistFilename: fmt.Sprintf("%s_%s%s", removeKnownExtension(conf.PlaylistFilename), r.Name, types.FileExtensionM3U8),
+			SegmentPrefix:    fmt.Sprintf("%s_%s", conf.SegmentPrefix, r.Name),
+			SegmentSuffix:    conf.SegmentSuffix,
+			SegmentDuration:  conf.SegmentDuration,
+			SegmentContainer: conf.SegmentContainer,
+			PartDuration:     conf.PartDuration,
+			PartHoldBack:     conf.PartHoldBack,
+			Rendition:        r,
+			DisableManifest:  true,
+			StorageConfig:    conf.StorageConfig,
+		}
+		if conf.LivePlaylistFilename != "" {
+			rc.LivePlaylistFilename = fmt.Sprintf("%s_%s%s", removeKnownExtension(conf.LivePlaylistFilename), r.Name, types.FileExtensionM3U8)
+		}
+		rc.SegmentsInfo.PlaylistName = path.Join(rc.StorageDir, rc.PlaylistFilename)
+		if rc.LivePlaylistFilename != "" {
+			rc.SegmentsInfo.LivePlaylistName = path.Join(rc.StorageDir, rc.LivePlaylistFilename)
+		}
+
+		conf.Variants = append(conf.Variants, rc)
+		renditions = append(renditions, rc)
+	}
+
+	return renditions, nil
+}
+
+// renditionBitrate scales the output bitrate by the pixel count of a rendition, in kbps
+func (p *PipelineConfig) renditionBitrate(r *Rendition) int32 {
+	bitrate := int32(int64(p.VideoBitrate) * int64(r.Width) * int64(r.Height) / (int64(p.Width) * int64(p.Height)))
+	if bitrate < minRenditionBitrate {
+		bitrate = min(minRenditionBitrate, p.VideoBitrate)
+	}
+	return bitrate
+}
+
 func removeKnownExtension(filename string) string {
 	if extIdx := strings.LastIndex(filename, "."); extIdx > -1 {
 		existingExt := types.FileExtension(filename[extIdx:])
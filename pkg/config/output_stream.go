@@ -19,17 +19,30 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/livekit/egress/pkg/errors"
 	"github.com/livekit/egress/pkg/types"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
 )
 
+const (
+	defaultWebsocketReconnectAttempts = 5
+	defaultWebsocketReconnectBackoff  = 8 * time.Second
+)
+
 type StreamConfig struct {
 	outputConfig
 
 	// url -> Stream
 	Streams sync.Map
 
+	// hls push streams are segmented locally before being uploaded
+	Segments *SegmentConfig
+
+	// websocket streams reconnect when the connection drops
+	Reconnect WebsocketReconnectConfig
+
+	srtListener    SRTListenerConfig
 	twitchTemplate string
 }
 
@@ -59,9 +72,40 @@ func (p *PipelineConfig) GetWebsocketConfig() *StreamConfig {
 	return o[0].(*StreamConfig)
 }
 
+func (p *PipelineConfig) GetWHIPConfig() *StreamConfig {
+	o, ok := p.Outputs[types.EgressTypeWHIP]
+	if !ok || len(o) == 0 {
+		return nil
+	}
+	return o[0].(*StreamConfig)
+}
+
+// hasStreamOutput returns true for outputs which are encoded with a fixed keyframe interval by default
+func (p *PipelineConfig) hasStreamOutput() bool {
+	return p.Outputs[types.EgressTypeStream] != nil ||
+		p.Outputs[types.EgressTypeWHIP] != nil ||
+		p.Outputs[types.EgressTypeHLSPush] != nil
+}
+
 func (p *PipelineConfig) getStreamConfig(outputType types.OutputType, urls []string) (*StreamConfig, error) {
 	conf := &StreamConfig{
 		outputConfig: outputConfig{OutputType: outputType},
+		srtListener:  p.SRTListener,
+	}
+
+	switch outputType {
+	case types.OutputTypeWHIP:
+		if len(urls) != 1 {
+			return nil, errors.ErrInvalidInput("whip url count")
+		}
+	case types.OutputTypeHLS:
+		if len(urls) != 1 {
+			return nil, errors.ErrInvalidInput("hls push url count")
+		}
+	case types.OutputTypeRaw:
+		if len(urls) != 1 {
+			return nil, errors.ErrInvalidInput("websocket url count")
+		}
 	}
 
 	for _, rawUrl := range urls {
@@ -72,12 +116,45 @@ func (p *PipelineConfig) getStreamConfig(outputType types.OutputType, urls []str
 	}
 
 	switch outputType {
-	case types.OutputTypeRTMP, types.OutputTypeSRT:
+	case types.OutputTypeRTMP, types.OutputTypeSRT, types.OutputTypeRTSP:
 		p.AudioOutCodec = types.MimeTypeAAC
 		p.VideoOutCodec = types.MimeTypeH264
 
+	case types.OutputTypeHLS:
+		p.AudioOutCodec = types.MimeTypeAAC
+		p.VideoOutCodec = types.MimeTypeH264
+
+		push, err := ParseHLSPushUrl(urls[0])
+		if err != nil {
+			return nil, err
+		}
+		if conf.Segments, err = p.getHLSPushSegmentConfig(push); err != nil {
+			return nil, err
+		}
+
+	case types.OutputTypeWHIP:
+		p.AudioOutCodec = types.MimeTypeOpus
+		if p.VideoOutCodec != types.MimeTypeVP8 {
+			// constrained baseline is the h264 profile every webrtc endpoint can decode
+			p.VideoOutCodec = types.MimeTypeH264
+			p.VideoProfile = types.ProfileBaseline
+		}
+
 	case types.OutputTypeRaw:
-		p.AudioOutCodec = types.MimeTypeRawAudio
+		opts, err := ParseWebsocketUrl(urls[0])
+		if err != nil {
+			return nil, err
+		}
+		p.AudioOutCodec = opts.Codec
+
+		conf.Reconnect = p.WebsocketReconnect
+		if conf.Reconnect.MaxAttempts == 0 {
+			conf.Reconnect.MaxAttempts = defaultWebsocketReconnectAttempts
+		}
+		if conf.Reconnect.MaxBackoff <= 0 {
+			conf.Reconnect.MaxBackoff = defaultWebsocketReconnectBackoff
+		}
+		conf.Reconnect.BufferWindow = opts.BufferWindow
 	}
 
 	return conf, nil
@@ -121,6 +121,7 @@ type TrackSource struct {
 	MimeType           types.MimeType
 	PayloadType        webrtc.PayloadType
 	ClockRate          uint32
+	Muted              bool // mute state when the track was subscribed
 	TempoController    *tempo.Controller
 	OnKeyframeRequired func()
 }
@@ -548,6 +549,9 @@ func (p *PipelineConfig) Update(request *rpc.StartEgressRequest) error {
 			return err
 		}
 	}
+	if err := p.updatePreviewOutput(); err != nil {
+		return err
+	}
 
 	p.initManifest()
 	return nil
@@ -874,25 +878,21 @@ func (p *PipelineConfig) updateOutputType(compatibleAudioCodecs map[types.MimeTy
 		return nil
 	}
 
+	var ot types.OutputType
 	if !p.VideoEnabled {
-		ot := types.GetOutputTypeCompatibleWithCodecs(types.AudioOnlyFileOutputTypes, compatibleAudioCodecs, nil)
-		if ot == types.OutputTypeUnknownFile {
-			return errors.ErrNoCompatibleFileOutputType
-		}
-		o.OutputType = ot
+		outputTypes := p.preferredOutputTypes(o, types.AudioOnlyFileOutputTypes, compatibleAudioCodecs, nil)
+		ot = types.GetOutputTypeCompatibleWithCodecs(outputTypes, compatibleAudioCodecs, nil)
 	} else if !p.AudioEnabled {
-		ot := types.GetOutputTypeCompatibleWithCodecs(types.VideoOnlyFileOutputTypes, nil, compatibleVideoCodecs)
-		if ot == types.OutputTypeUnknownFile {
-			return errors.ErrNoCompatibleFileOutputType
-		}
-		o.OutputType = ot
+		outputTypes := p.preferredOutputTypes(o, types.VideoOnlyFileOutputTypes, nil, compatibleVideoCodecs)
+		ot = types.GetOutputTypeCompatibleWithCodecs(outputTypes, nil, compatibleVideoCodecs)
 	} else {
-		ot := types.GetOutputTypeCompatibleWithCodecs(types.AudioVideoFileOutputTypes, compatibleAudioCodecs, compatibleVideoCodecs)
-		if ot == types.OutputTypeUnknownFile {
-			return errors.ErrNoCompatibleFileOutputType
-		}
-		o.OutputType = ot
+		outputTypes := p.preferredOutputTypes(o, types.AudioVideoFileOutputTypes, compatibleAudioCodecs, compatibleVideoCodecs)
+		ot = types.GetOutputTypeCompatibleWithCodecs(outputTypes, compatibleAudioCodecs, compatibleVideoCodecs)
 	}
+	if ot == types.OutputTypeUnknownFile {
+		return errors.ErrNoCompatibleFileOutputType
+	}
+	o.OutputType = ot
 
 	identifier, replacements := p.getFilenameInfo()
 	err := o.updateFilepath(p, identifier, replacements)
@@ -915,17 +915,24 @@ func (p *PipelineConfig) UpdateInfoFromSDK(identifier string, replacements map[s
 		}
 		switch egressType {
 		case types.EgressTypeFile:
-			err = c[0].(*FileConfig).updateFilepath(p, identifier, replacements)
+			o := c[0].(*FileConfig)
+			err = o.updateFilepath(p, identifier, replacements)
+			if preview := p.GetPreviewConfig(); preview != nil {
+				preview.updateFilepath(p, o)
+			}
 
 		case types.EgressTypeSegments:
-			o := c[0].(*SegmentConfig)
-			o.LocalDir = stringReplace(o.LocalDir, replacements)
-			o.StorageDir = stringReplace(o.StorageDir, replacements)
-			o.PlaylistFilename = stringReplace(o.PlaylistFilename, replacements)
-			o.LivePlaylistFilename = stringReplace(o.LivePlaylistFilename, replacements)
-			o.SegmentPrefix = stringReplace(o.SegmentPrefix, replacements)
-			o.SegmentsInfo.PlaylistName = stringReplace(o.SegmentsInfo.PlaylistName, replacements)
-			o.SegmentsInfo.LivePlaylistName = stringReplace(o.SegmentsInfo.LivePlaylistName, replacements)
+			// includes adaptive bitrate renditions
+			for _, cs := range c {
+				o := cs.(*SegmentConfig)
+				o.LocalDir = stringReplace(o.LocalDir, replacements)
+				o.StorageDir = stringReplace(o.StorageDir, replacements)
+				o.PlaylistFilename = stringReplace(o.PlaylistFilename, replacements)
+				o.LivePlaylistFilename = stringReplace(o.LivePlaylistFilename, replacements)
+				o.SegmentPrefix = stringReplace(o.SegmentPrefix, replacements)
+				o.SegmentsInfo.PlaylistName = stringReplace(o.SegmentsInfo.PlaylistName, replacements)
+				o.SegmentsInfo.LivePlaylistName = stringReplace(o.SegmentsInfo.LivePlaylistName, replacements)
+			}
 
 		case types.EgressTypeImages:
 			for _, ci := range c {
@@ -958,7 +965,7 @@ func (p *PipelineConfig) UpdateInfoFromSDK(identifier string, replacements map[s
 func (p *PipelineConfig) GetEncodedOutputs() []OutputConfig {
 	ret := make([]OutputConfig, 0)
 
-	for _, k := range []types.EgressType{types.EgressTypeFile, types.EgressTypeSegments, types.EgressTypeStream, types.EgressTypeWebsocket} {
+	for _, k := range []types.EgressType{types.EgressTypeFile, types.EgressTypeSegments, types.EgressTypeStream, types.EgressTypeWebsocket, types.EgressTypeWHIP, types.EgressTypeHLSPush} {
 		ret = append(ret, p.Outputs[k]...)
 	}
 
@@ -16,6 +16,7 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"time"
 
@@ -36,6 +37,8 @@ const (
 	participantCpuCost        = 2
 	trackCompositeCpuCost     = 1
 	trackCpuCost              = 0.5
+	renditionCpuCost          = 1
+	previewCpuCost            = 0.5
 	maxCpuUtilization         = 0.8
 	maxUploadQueue            = 60
 
@@ -98,7 +101,9 @@ type CPUCostConfig struct {
 	ParticipantCpuCost              float64 `yaml:"participant_cpu_cost"`
 	TrackCompositeCpuCost           float64 `yaml:"track_composite_cpu_cost"`
 	TrackCpuCost                    float64 `yaml:"track_cpu_cost"`
-	MaxPulseClients                 int     `yaml:"max_pulse_clients"` // pulse client limit for launching chrome
+	RenditionCpuCost                float64 `yaml:"rendition_cpu_cost"` // added for each adaptive bitrate rendition
+	PreviewCpuCost                  float64 `yaml:"preview_cpu_cost"`   // added for each file output preview
+	MaxPulseClients                 int     `yaml:"max_pulse_clients"`  // pulse client limit for launching chrome
 
 	// Memory source configuration (cgroup-aware memory accounting)
 	MemorySource       MemorySource `yaml:"memory_source"`         // memory measurement source: proc_rss, cgroup
@@ -190,6 +195,12 @@ func (c *ServiceConfig) InitDefaults() {
 	if c.TrackCpuCost <= 0 {
 		c.TrackCpuCost = trackCpuCost
 	}
+	if c.RenditionCpuCost <= 0 {
+		c.RenditionCpuCost = renditionCpuCost
+	}
+	if c.PreviewCpuCost <= 0 {
+		c.PreviewCpuCost = previewCpuCost
+	}
 	if c.MaxPulseClients == 0 {
 		c.MaxPulseClients = defaultMaxPulseClients
 	}
@@ -217,6 +228,17 @@ func (c *ServiceConfig) InitDefaults() {
 		c.MaxUploadQueue = maxUploadQueue
 	}
 
+	if c.SRTListener.PortMin > 0 || c.SRTListener.PortMax > 0 {
+		if c.SRTListener.PortMin <= 0 || c.SRTListener.PortMax < c.SRTListener.PortMin || c.SRTListener.PortMax > 65535 {
+			logger.Warnw("invalid srt_listener port range, allowing any port", nil,
+				"portMin", c.SRTListener.PortMin, "portMax", c.SRTListener.PortMax)
+			c.SRTListener.PortMin, c.SRTListener.PortMax = 0, 0
+		}
+	}
+	if c.SRTListener.Address == "" {
+		c.SRTListener.Address = localIPv4()
+	}
+
 	applyLatencyDefaults(&c.Latency)
 
 	if c.AudioTempoController.Enabled {
@@ -226,6 +248,23 @@ func (c *ServiceConfig) InitDefaults() {
 	}
 }
 
+// localIPv4 returns the first non-loopback ipv4 address of the node
+func localIPv4() string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		logger.Warnw("failed to get interface addresses", err)
+		return ""
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() {
+			if ip := ipNet.IP.To4(); ip != nil {
+				return ip.String()
+			}
+		}
+	}
+	return ""
+}
+
 func applyLatencyDefaults(latency *LatencyConfig) {
 	if latency.JitterBufferLatency == 0 {
 		latency.JitterBufferLatency = defaultJitterBufferLatency
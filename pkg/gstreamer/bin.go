@@ -177,6 +177,24 @@ func (b *Bin) AddElements(elements ...*gst.Element) error {
 	return nil
 }
 
+// RemoveElement - removes an element which was added to a running bin. The element must already be unlinked
+func (b *Bin) RemoveElement(e *gst.Element) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, element := range b.elements {
+		if element.GetName() == e.GetName() {
+			b.elements = append(b.elements[:i], b.elements[i+1:]...)
+			break
+		}
+	}
+	if err := b.bin.Remove(e); err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+
+	return nil
+}
+
 func (b *Bin) RemoveSourceBin(name string) error {
 	logger.Debugw(fmt.Sprintf("removing src %s from %s", name, b.bin.GetName()))
 	return b.removeBin(name, gst.PadDirectionSource)
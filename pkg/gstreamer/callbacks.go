@@ -19,6 +19,7 @@ import (
 	"github.com/linkdata/deadlock"
 	"github.com/livekit/egress/pkg/config"
 	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/egress/pkg/types"
 )
 
 type Callbacks struct {
@@ -30,6 +31,10 @@ type Callbacks struct {
 	onError           func(error)
 	onStop            []func() error
 	onDebugDotRequest func(string)
+	onEOSRequested    func(string)
+	onStreamFailed    func(types.EgressType, *config.Stream, error)
+	onStreamFinished  func(types.EgressType, *config.Stream)
+	onStreamRetry     func(*config.Stream)
 
 	// source callbacks
 	onTrackAdded     []func(*config.TrackSource)
@@ -57,6 +62,76 @@ func (c *Callbacks) OnError(err error) {
 	}
 }
 
+func (c *Callbacks) SetOnEOSRequested(f func(string)) {
+	c.mu.Lock()
+	c.onEOSRequested = f
+	c.mu.Unlock()
+}
+
+// OnEOSRequested stops the egress, for sinks which are told by their receiver that the stream is over
+func (c *Callbacks) OnEOSRequested(reason string) {
+	c.mu.RLock()
+	onEOSRequested := c.onEOSRequested
+	c.mu.RUnlock()
+
+	if onEOSRequested != nil {
+		onEOSRequested(reason)
+	}
+}
+
+func (c *Callbacks) SetOnStreamFailed(f func(types.EgressType, *config.Stream, error)) {
+	c.mu.Lock()
+	c.onStreamFailed = f
+	c.mu.Unlock()
+}
+
+// OnStreamFailed removes a stream which cannot continue, for sinks which manage their own connection.
+// The sink must have stopped sending to it.
+func (c *Callbacks) OnStreamFailed(egressType types.EgressType, stream *config.Stream, err error) {
+	c.mu.RLock()
+	onStreamFailed := c.onStreamFailed
+	c.mu.RUnlock()
+
+	if onStreamFailed != nil {
+		onStreamFailed(egressType, stream, err)
+	}
+}
+
+func (c *Callbacks) SetOnStreamFinished(f func(types.EgressType, *config.Stream)) {
+	c.mu.Lock()
+	c.onStreamFinished = f
+	c.mu.Unlock()
+}
+
+// OnStreamFinished removes a stream which was ended by its receiver, for sinks which manage their own connection.
+// The sink must have stopped sending to it.
+func (c *Callbacks) OnStreamFinished(egressType types.EgressType, stream *config.Stream) {
+	c.mu.RLock()
+	onStreamFinished := c.onStreamFinished
+	c.mu.RUnlock()
+
+	if onStreamFinished != nil {
+		onStreamFinished(egressType, stream)
+	}
+}
+
+func (c *Callbacks) SetOnStreamRetry(f func(*config.Stream)) {
+	c.mu.Lock()
+	c.onStreamRetry = f
+	c.mu.Unlock()
+}
+
+// OnStreamRetry records a reconnection attempt, for sinks which manage their own connection
+func (c *Callbacks) OnStreamRetry(stream *config.Stream) {
+	c.mu.RLock()
+	onStreamRetry := c.onStreamRetry
+	c.mu.RUnlock()
+
+	if onStreamRetry != nil {
+		onStreamRetry(stream)
+	}
+}
+
 func (c *Callbacks) SetOnDebugDotRequest(f func(string)) {
 	c.mu.Lock()
 	c.onDebugDotRequest = f
@@ -37,6 +37,9 @@ const (
 
 	audioRateTolerance = 3 * time.Millisecond
 	audioBinName       = "audio"
+
+	g711SampleRate    = 8000
+	g711FrameDuration = "1/50"
 )
 
 type AudioBin struct {
@@ -445,7 +448,40 @@ func (b *AudioBin) addEncoder() error {
 		}
 		return b.bin.AddElement(mp3enc)
 
+	case types.MimeTypeFLAC:
+		flacEnc, err := gst.NewElement("flacenc")
+		if err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		return b.bin.AddElement(flacEnc)
+
+	case types.MimeTypePCMU, types.MimeTypePCMA:
+		// telephony backends expect 20ms frames
+		split, err := gst.NewElement("audiobuffersplit")
+		if err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		split.SetArg("output-buffer-duration", g711FrameDuration)
+
+		encoder := "mulawenc"
+		if b.conf.AudioOutCodec == types.MimeTypePCMA {
+			encoder = "alawenc"
+		}
+		g711Enc, err := gst.NewElement(encoder)
+		if err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		return b.bin.AddElements(split, g711Enc)
+
 	case types.MimeTypeRawAudio:
+		if o := b.conf.GetFileConfig(); o != nil && o.OutputType == types.OutputTypeWAV {
+			// wavenc writes the RIFF header, and updates its sizes on EOS
+			wavEnc, err := gst.NewElement("wavenc")
+			if err != nil {
+				return errors.ErrGstPipelineError(err)
+			}
+			return b.bin.AddElement(wavEnc)
+		}
 		return nil
 
 	default:
@@ -453,6 +489,21 @@ func (b *AudioBin) addEncoder() error {
 	}
 }
 
+// audioSampleRate returns the output sample rate. Opus and raw audio streams are always 48kHz, and G.711 is always 8kHz.
+func audioSampleRate(p *config.PipelineConfig) int32 {
+	switch p.AudioOutCodec {
+	case types.MimeTypeAAC, types.MimeTypeMP3, types.MimeTypeFLAC:
+		return p.AudioFrequency
+	case types.MimeTypePCMU, types.MimeTypePCMA:
+		return g711SampleRate
+	case types.MimeTypeRawAudio:
+		if o := p.GetFileConfig(); o != nil && o.OutputType == types.OutputTypeWAV {
+			return p.AudioFrequency
+		}
+	}
+	return 48000
+}
+
 func addAudioConverter(b *gstreamer.Bin, p *config.PipelineConfig, channel livekit.AudioChannel, isLeaky bool) error {
 	rate, err := gstreamer.BuildAudioRate("audio_rate", audioRateTolerance)
 	if err != nil {
@@ -636,17 +687,8 @@ func (b *AudioBin) addAudioConvertWithPitch(bin *gstreamer.Bin, p *config.Pipeli
 
 // F32 caps used only around `pitch`
 func newAudioFloatCapsFilter(p *config.PipelineConfig, channel livekit.AudioChannel) (*gst.Element, error) {
-	var channelCaps string
-	if channel == livekit.AudioChannel_AUDIO_CHANNEL_BOTH {
-		channelCaps = "channels=2"
-	} else {
-		channelCaps = fmt.Sprintf("channels=1,channel-mask=(bitmask)0x%d", channel)
-	}
-	rate := 48000
-	if p.AudioOutCodec == types.MimeTypeAAC || p.AudioOutCodec == types.MimeTypeMP3 {
-		rate = int(p.AudioFrequency)
-	}
-	caps := gst.NewCapsFromString(fmt.Sprintf("audio/x-raw,format=F32LE,layout=interleaved,rate=%d,%s", rate, channelCaps))
+	channelCaps := audioChannelCaps(p, channel)
+	caps := gst.NewCapsFromString(fmt.Sprintf("audio/x-raw,format=F32LE,layout=interleaved,rate=%d,%s", audioSampleRate(p), channelCaps))
 
 	cf, err := gst.NewElement("capsfilter")
 	if err != nil {
@@ -658,25 +700,28 @@ func newAudioFloatCapsFilter(p *config.PipelineConfig, channel livekit.AudioChan
 	return cf, nil
 }
 
-func newAudioCapsFilter(p *config.PipelineConfig, channel livekit.AudioChannel) (*gst.Element, error) {
-	var channelCaps string
-	if channel == livekit.AudioChannel_AUDIO_CHANNEL_BOTH {
-		channelCaps = "channels=2"
-	} else {
-		channelCaps = fmt.Sprintf("channels=1,channel-mask=(bitmask)0x%d", channel)
+// audioChannelCaps returns the channel caps of a track or mix. G.711 is always mono.
+func audioChannelCaps(p *config.PipelineConfig, channel livekit.AudioChannel) string {
+	switch {
+	case p.AudioOutCodec == types.MimeTypePCMU || p.AudioOutCodec == types.MimeTypePCMA:
+		return "channels=1"
+	case channel == livekit.AudioChannel_AUDIO_CHANNEL_BOTH:
+		return "channels=2"
+	default:
+		return fmt.Sprintf("channels=1,channel-mask=(bitmask)0x%d", channel)
 	}
+}
+
+func newAudioCapsFilter(p *config.PipelineConfig, channel livekit.AudioChannel) (*gst.Element, error) {
+	channelCaps := audioChannelCaps(p, channel)
 
 	var caps *gst.Caps
 	switch p.AudioOutCodec {
-	case types.MimeTypeOpus, types.MimeTypeRawAudio:
-		caps = gst.NewCapsFromString(fmt.Sprintf(
-			"audio/x-raw,format=S16LE,layout=interleaved,rate=48000,%s",
-			channelCaps,
-		))
-	case types.MimeTypeAAC, types.MimeTypeMP3:
+	case types.MimeTypeOpus, types.MimeTypeRawAudio, types.MimeTypeAAC, types.MimeTypeMP3, types.MimeTypeFLAC,
+		types.MimeTypePCMU, types.MimeTypePCMA:
 		caps = gst.NewCapsFromString(fmt.Sprintf(
 			"audio/x-raw,format=S16LE,layout=interleaved,rate=%d,%s",
-			p.AudioFrequency, channelCaps,
+			audioSampleRate(p), channelCaps,
 		))
 	default:
 		return nil, errors.ErrNotSupported(string(p.AudioOutCodec))
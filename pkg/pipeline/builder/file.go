@@ -35,11 +35,20 @@ func BuildFileBin(pipeline *gstreamer.Pipeline, p *config.PipelineConfig) (*gstr
 	case types.OutputTypeIVF:
 		mux, err = newMuxer("avmux_ivf")
 	case types.OutputTypeMP4:
-		mux, err = newMuxer("mp4mux")
+		if o.IsFragmented() {
+			mux, err = newFragmentedMP4Muxer(o.FragmentDuration, o.MoovRecoveryFilepath())
+		} else {
+			mux, err = newMuxer("mp4mux")
+		}
 	case types.OutputTypeWebM:
 		mux, err = newMuxer("webmmux")
+	case types.OutputTypeMKV:
+		// clusters are written as they are completed, so files remain playable if the handler is killed
+		mux, err = newMuxer("matroskamux")
 	case types.OutputTypeMP3:
 		mux, err = newMP3Muxer()
+	case types.OutputTypeWAV, types.OutputTypeFLAC:
+		mux, err = newPassthroughMuxer()
 
 	default:
 		return nil, errors.ErrInvalidInput("output type")
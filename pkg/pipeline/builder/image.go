@@ -25,10 +25,14 @@ import (
 	"github.com/livekit/egress/pkg/errors"
 	"github.com/livekit/egress/pkg/gstreamer"
 	"github.com/livekit/egress/pkg/types"
+	"github.com/livekit/protocol/logger"
 )
 
 const (
 	imageQueueLatency = 200 * time.Millisecond
+
+	// pngenc default
+	defaultPNGCompressionLevel = 6
 )
 
 func BuildImageBin(c *config.ImageConfig, pipeline *gstreamer.Pipeline, p *config.PipelineConfig) (*gstreamer.Bin, error) {
@@ -85,9 +89,14 @@ func BuildImageBin(c *config.ImageConfig, pipeline *gstreamer.Pipeline, p *confi
 		return nil, errors.ErrGstPipelineError(err)
 	}
 
+	framerate := fmt.Sprintf("1/%d", c.CaptureInterval)
+	if c.IsSceneChange() {
+		// frames are analyzed as often as images can be captured
+		framerate = fmt.Sprintf("1000/%d", c.MinCaptureInterval.Milliseconds())
+	}
 	capsString := fmt.Sprintf(
-		"video/x-raw,framerate=1/%d,format=I420,colorimetry=bt709,chroma-site=mpeg2,pixel-aspect-ratio=1/1",
-		c.CaptureInterval)
+		"video/x-raw,framerate=%s,format=I420,colorimetry=bt709,chroma-site=mpeg2,pixel-aspect-ratio=1/1",
+		framerate)
 
 	if c.Width > 0 && c.Height > 0 {
 		capsString = fmt.Sprintf("%s,width=%d,height=%d,", capsString, c.Width, c.Height)
@@ -101,17 +110,12 @@ func BuildImageBin(c *config.ImageConfig, pipeline *gstreamer.Pipeline, p *confi
 		return nil, errors.ErrGstPipelineError(err)
 	}
 
-	switch c.ImageOutCodec {
-	case types.MimeTypeJPEG:
-		enc, err := gst.NewElement("jpegenc")
-		if err != nil {
-			return nil, errors.ErrGstPipelineError(err)
-		}
-		if err := b.AddElements(enc); err != nil {
-			return nil, errors.ErrGstPipelineError(err)
-		}
-	default:
-		return nil, errors.ErrNoCompatibleCodec
+	enc, err := buildImageEncoder(c)
+	if err != nil {
+		return nil, err
+	}
+	if err = b.AddElements(enc...); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
 	}
 
 	sink, err := gst.NewElementWithName("multifilesink", fmt.Sprintf("multifilesink_%s", c.Id))
@@ -124,7 +128,7 @@ func BuildImageBin(c *config.ImageConfig, pipeline *gstreamer.Pipeline, p *confi
 	}
 
 	// File will be renamed if the TS prefix is configured
-	location := fmt.Sprintf("%s_%%05d%s", path.Join(c.LocalDir, c.ImagePrefix), types.FileExtensionForOutputType[c.OutputType])
+	location := fmt.Sprintf("%s_%%05d%s", path.Join(c.LocalDir, c.ImagePrefix), types.FileExtensionForOutputType[c.FrameOutputType()])
 
 	err = sink.SetProperty("location", location)
 	if err != nil {
@@ -134,5 +138,137 @@ func BuildImageBin(c *config.ImageConfig, pipeline *gstreamer.Pipeline, p *confi
 		return nil, errors.ErrGstPipelineError(err)
 	}
 
+	if c.IsSceneChange() {
+		addSceneChangeProbe(c, caps.GetStaticPad("src"), sink)
+	}
+
 	return b, nil
 }
+
+// addSceneChangeProbe drops frames which are too similar to the last captured image
+func addSceneChangeProbe(c *config.ImageConfig, pad *gst.Pad, sink *gst.Element) {
+	detector := &sceneChangeDetector{
+		threshold:   c.SceneChangeThreshold,
+		minInterval: c.MinCaptureInterval,
+		maxInterval: c.MaxCaptureInterval,
+	}
+
+	var width, height int
+	pad.AddProbe(gst.PadProbeTypeBuffer, func(pad *gst.Pad, info *gst.PadProbeInfo) gst.PadProbeReturn {
+		buffer := info.GetBuffer()
+		if buffer == nil {
+			return gst.PadProbeOK
+		}
+		if width == 0 {
+			width, height = getVideoDimensions(pad)
+		}
+
+		pts := buffer.PresentationTimestamp()
+		luma := buffer.Map(gst.MapRead).Bytes()
+		capture, score := detector.shouldCapture(luma, width, height, i420Stride(width), time.Duration(pts))
+		buffer.Unmap()
+		if !capture {
+			return gst.PadProbeDrop
+		}
+
+		if score > 0 {
+			// the multifilesink message for this frame is matched using its timestamp
+			msg := gst.NewElementMessage(sink, gst.MarshalStructure(SceneChange{
+				Timestamp: uint64(pts),
+				Score:     score,
+			}))
+			if ok := sink.PostMessage(msg); !ok {
+				logger.Debugw("failed to post scene change message")
+			}
+		}
+		return gst.PadProbeOK
+	})
+}
+
+func getVideoDimensions(pad *gst.Pad) (int, int) {
+	caps := pad.GetCurrentCaps()
+	if caps == nil {
+		return 0, 0
+	}
+	s := caps.GetStructureAt(0)
+	if s == nil {
+		return 0, 0
+	}
+	width, _ := s.GetValue("width")
+	height, _ := s.GetValue("height")
+	w, _ := width.(int)
+	h, _ := height.(int)
+	return w, h
+}
+
+// i420Stride returns the stride of the luma plane, which is aligned to 4 bytes
+func i420Stride(width int) int {
+	return (width + 3) &^ 3
+}
+
+func buildImageEncoder(c *config.ImageConfig) ([]*gst.Element, error) {
+	if c.IsSpriteSheet() {
+		// sprite sheet tiles are kept lossless and uncompressed, the sheet is only encoded once it is full
+		return buildPNGEncoder(0)
+	}
+
+	switch c.ImageOutCodec {
+	case types.MimeTypeJPEG:
+		enc, err := gst.NewElement("jpegenc")
+		if err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		if c.Quality > 0 {
+			if err = enc.SetProperty("quality", c.Quality); err != nil {
+				return nil, errors.ErrGstPipelineError(err)
+			}
+		}
+		return []*gst.Element{enc}, nil
+
+	case types.MimeTypePNG:
+		compressionLevel := uint(defaultPNGCompressionLevel)
+		if c.CompressionLevel > 0 {
+			compressionLevel = uint(c.CompressionLevel)
+		}
+		return buildPNGEncoder(compressionLevel)
+
+	case types.MimeTypeWebP:
+		enc, err := gst.NewElement("webpenc")
+		if err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		if c.Quality == 100 {
+			if err = enc.SetProperty("lossless", true); err != nil {
+				return nil, errors.ErrGstPipelineError(err)
+			}
+		} else if c.Quality > 0 {
+			if err = enc.SetProperty("quality", float32(c.Quality)); err != nil {
+				return nil, errors.ErrGstPipelineError(err)
+			}
+		}
+		return []*gst.Element{enc}, nil
+
+	default:
+		return nil, errors.ErrNoCompatibleCodec
+	}
+}
+
+func buildPNGEncoder(compressionLevel uint) ([]*gst.Element, error) {
+	// pngenc does not accept yuv input
+	videoConvert, err := gst.NewElement("videoconvert")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	enc, err := gst.NewElement("pngenc")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	// pngenc sends EOS after the first frame by default
+	if err = enc.SetProperty("snapshot", false); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if err = enc.SetProperty("compression-level", compressionLevel); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	return []*gst.Element{videoConvert, enc}, nil
+}
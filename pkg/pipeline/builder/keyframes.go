@@ -0,0 +1,73 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"time"
+
+	"github.com/go-gst/go-gst/gst"
+
+	"github.com/livekit/protocol/logger"
+)
+
+// forceKeyUnitEvent is handled by video encoders, see gst_video_event_new_upstream_force_key_unit
+const forceKeyUnitEvent = "GstForceKeyUnit"
+
+func newForceKeyUnitEvent(runningTime gst.ClockTime) *gst.Event {
+	s := gst.NewStructure(forceKeyUnitEvent)
+	if runningTime != gst.ClockTimeNone {
+		_ = s.SetValue("running-time", uint64(runningTime))
+	}
+	_ = s.SetValue("all-headers", true)
+	return gst.NewCustomEvent(gst.EventTypeCustomUpstream, s)
+}
+
+// RequestKeyFrame asks the upstream encoder for a keyframe as soon as possible, e.g. after a receiver lost packets
+func RequestKeyFrame(sink *gst.Element) {
+	if !sink.GetStaticPad("sink").SendEvent(newForceKeyUnitEvent(gst.ClockTimeNone)) {
+		logger.Debugw("keyframe request not handled", "sink", sink.GetName())
+	}
+}
+
+// addKeyFrameRequestProbe asks the upstream encoder for a keyframe one interval after each keyframe, the same way
+// splitmuxsink does with send-keyframe-requests
+func addKeyFrameRequestProbe(pad *gst.Pad, interval time.Duration) {
+	next := gst.ClockTimeNone
+	pad.AddProbe(gst.PadProbeTypeBuffer, func(pad *gst.Pad, info *gst.PadProbeInfo) gst.PadProbeReturn {
+		buffer := info.GetBuffer()
+		if buffer == nil || buffer.HasFlags(gst.BufferFlagDeltaUnit) {
+			return gst.PadProbeOK
+		}
+		pts := buffer.PresentationTimestamp()
+		if pts == gst.ClockTimeNone {
+			return gst.PadProbeOK
+		}
+
+		runningTime := pts
+		if ev := pad.GetStickyEvent(gst.EventTypeSegment, 0); ev != nil {
+			runningTime = gst.ClockTime(ev.ParseSegment().ToRunningTime(gst.FormatTime, uint64(pts)))
+		}
+		if next != gst.ClockTimeNone && runningTime < next {
+			// an earlier keyframe, the requested one is still pending
+			return gst.PadProbeOK
+		}
+
+		next = runningTime + gst.ClockTime(interval)
+		if !pad.SendEvent(newForceKeyUnitEvent(next)) {
+			logger.Debugw("keyframe request not handled", "runningTime", time.Duration(next))
+		}
+		return gst.PadProbeOK
+	})
+}
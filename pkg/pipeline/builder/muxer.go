@@ -17,6 +17,7 @@ package builder
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/go-gst/go-gst/gst"
 )
@@ -58,6 +59,23 @@ func (m *muxerImpl) GetElement() *gst.Element {
 	return m.Element
 }
 
+// newFragmentedMP4Muxer creates an mp4mux writing a moof/mdat pair every fragmentDuration, so that files
+// remain playable up to the last fragment if the pipeline is interrupted.
+// The moov recovery file can be used to rebuild the header of a file that was never finalized.
+func newFragmentedMP4Muxer(fragmentDuration time.Duration, moovRecoveryFile string) (*muxerImpl, error) {
+	m, err := newMuxer("mp4mux")
+	if err != nil {
+		return nil, err
+	}
+	if err = m.SetProperty("fragment-duration", uint(fragmentDuration.Milliseconds())); err != nil {
+		return nil, err
+	}
+	if err = m.SetProperty("moov-recovery-file", moovRecoveryFile); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // mp3Muxer wraps xingmux as a muxer so audio-only MP3 outputs
 // can reuse the same linking logic as containerised formats.
 type mp3Muxer struct {
@@ -83,3 +101,26 @@ func newMP3Muxer() (*mp3Muxer, error) {
 func (m *mp3Muxer) GetRequestPad(_ string) *gst.Pad {
 	return m.GetStaticPad("sink")
 }
+
+// passthroughMuxer wraps identity as a muxer for formats whose encoder already
+// writes the container, such as wavenc and flacenc.
+type passthroughMuxer struct {
+	muxerImpl
+}
+
+func newPassthroughMuxer() (*passthroughMuxer, error) {
+	identity, err := gst.NewElement("identity")
+	if err != nil {
+		return nil, err
+	}
+	return &passthroughMuxer{
+		muxerImpl: muxerImpl{
+			Element: identity,
+		},
+	}, nil
+}
+
+// GetRequestPad always returns the static sink pad to satisfy the muxer contract.
+func (m *passthroughMuxer) GetRequestPad(_ string) *gst.Pad {
+	return m.GetStaticPad("sink")
+}
@@ -19,7 +19,7 @@ func initGStreamer(t *testing.T) {
 func TestNewMuxer_KnownMuxers(t *testing.T) {
 	initGStreamer(t)
 
-	for _, name := range []string{"oggmux", "avmux_ivf", "mp4mux", "webmmux", "mpegtsmux"} {
+	for _, name := range []string{"oggmux", "avmux_ivf", "mp4mux", "webmmux", "matroskamux", "mpegtsmux"} {
 		t.Run(name, func(t *testing.T) {
 			m, err := newMuxer(name)
 			require.NoError(t, err)
@@ -37,6 +37,14 @@ func TestNewMuxer_InvalidMuxer(t *testing.T) {
 	require.True(t, strings.Contains(err.Error(), "not a muxer"), "unexpected error: %v", err)
 }
 
+func TestNewPassthroughMuxer(t *testing.T) {
+	initGStreamer(t)
+
+	m, err := newPassthroughMuxer()
+	require.NoError(t, err)
+	require.NotNil(t, m.GetRequestPad("unused"))
+}
+
 func TestNewMP3Muxer(t *testing.T) {
 	initGStreamer(t)
 
@@ -0,0 +1,199 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-gst/go-gst/gst"
+	"github.com/go-gst/go-gst/gst/app"
+
+	"github.com/livekit/egress/pkg/config"
+	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/egress/pkg/gstreamer"
+	"github.com/livekit/egress/pkg/types"
+)
+
+const (
+	previewBinName      = "preview"
+	previewQueueLatency = 200 * time.Millisecond
+	previewVideoBitrate = 300 // kbps
+
+	// sampled previews start with one frame per second, the preview sink keeps fewer as the recording grows
+	previewSampleRate = 1
+)
+
+// BuildPreviewBin scales the decoded video down to preview frames, which are collected by the preview sink's
+// appsink. The returned valve lets the sink stop the branch once it has all the frames it needs.
+func BuildPreviewBin(
+	pipeline *gstreamer.Pipeline,
+	p *config.PipelineConfig,
+	callbacks *app.SinkCallbacks,
+) (*gstreamer.Bin, *gst.Element, error) {
+	b := pipeline.NewBin(previewBinName)
+	o := p.GetPreviewConfig()
+
+	queue, err := gstreamer.BuildQueue("preview_queue", previewQueueLatency, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	valve, err := gst.NewElement("valve")
+	if err != nil {
+		return nil, nil, errors.ErrGstPipelineError(err)
+	}
+
+	videoRate, err := gst.NewElement("videorate")
+	if err != nil {
+		return nil, nil, errors.ErrGstPipelineError(err)
+	}
+	if err = videoRate.SetProperty("skip-to-first", true); err != nil {
+		return nil, nil, errors.ErrGstPipelineError(err)
+	}
+
+	videoScale, err := gst.NewElement("videoscale")
+	if err != nil {
+		return nil, nil, errors.ErrGstPipelineError(err)
+	}
+
+	framerate := o.FrameRate
+	if o.Sampled {
+		framerate = previewSampleRate
+	}
+	caps, err := gst.NewElement("capsfilter")
+	if err != nil {
+		return nil, nil, errors.ErrGstPipelineError(err)
+	}
+	if err = caps.SetProperty("caps", gst.NewCapsFromString(fmt.Sprintf(
+		"video/x-raw,framerate=%d/1,format=I420,width=%d,height=%d,pixel-aspect-ratio=1/1",
+		framerate, o.Width, o.Height,
+	))); err != nil {
+		return nil, nil, errors.ErrGstPipelineError(err)
+	}
+
+	appSink, err := app.NewAppSink()
+	if err != nil {
+		return nil, nil, errors.ErrGstPipelineError(err)
+	}
+	appSink.SetCallbacks(callbacks)
+
+	if err = b.AddElements(queue, valve, videoRate, videoScale, caps, appSink.Element); err != nil {
+		return nil, nil, err
+	}
+
+	b.SetGetSrcPad(func(name string) *gst.Pad {
+		return queue.GetStaticPad("sink")
+	})
+	b.SetShouldLink(func(srcBin string) bool {
+		return srcBin != audioBinName
+	})
+
+	return b, valve, nil
+}
+
+// BuildPreviewEncoder builds the pipeline encoding the collected preview frames, which are pushed to its appsrc
+func BuildPreviewEncoder(o *config.PreviewConfig) (*gst.Pipeline, *app.Source, error) {
+	pipeline, err := gst.NewPipeline("preview_encoder")
+	if err != nil {
+		return nil, nil, errors.ErrGstPipelineError(err)
+	}
+
+	src, err := app.NewAppSrc()
+	if err != nil {
+		return nil, nil, errors.ErrGstPipelineError(err)
+	}
+	src.SetArg("format", "time")
+	src.SetCaps(gst.NewCapsFromString(fmt.Sprintf(
+		"video/x-raw,framerate=%d/1,format=I420,width=%d,height=%d,pixel-aspect-ratio=1/1",
+		o.FrameRate, o.Width, o.Height,
+	)))
+
+	enc, err := buildPreviewEncoder(o)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sink, err := gst.NewElement("filesink")
+	if err != nil {
+		return nil, nil, errors.ErrGstPipelineError(err)
+	}
+	if err = sink.SetProperty("location", o.LocalFilepath); err != nil {
+		return nil, nil, errors.ErrGstPipelineError(err)
+	}
+
+	elements := append([]*gst.Element{src.Element}, enc...)
+	elements = append(elements, sink)
+	if err = pipeline.AddMany(elements...); err != nil {
+		return nil, nil, errors.ErrGstPipelineError(err)
+	}
+	if err = gst.ElementLinkMany(elements...); err != nil {
+		return nil, nil, errors.ErrGstPipelineError(err)
+	}
+
+	return pipeline, src, nil
+}
+
+func buildPreviewEncoder(o *config.PreviewConfig) ([]*gst.Element, error) {
+	switch o.OutputType {
+	case types.OutputTypeWebP:
+		enc, err := gst.NewElement("webpenc")
+		if err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		if err = enc.SetProperty("animated", true); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		return []*gst.Element{enc}, nil
+
+	case types.OutputTypeGIF:
+		// gifenc does not accept yuv input
+		videoConvert, err := gst.NewElement("videoconvert")
+		if err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		enc, err := gst.NewElement("gifenc")
+		if err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		// loop forever
+		if err = enc.SetProperty("repeat", -1); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		return []*gst.Element{videoConvert, enc}, nil
+
+	case types.OutputTypeMP4:
+		enc, err := gst.NewElement("x264enc")
+		if err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		enc.SetArg("speed-preset", "veryfast")
+		if err = enc.SetProperty("bitrate", uint(previewVideoBitrate)); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		mux, err := gst.NewElement("mp4mux")
+		if err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		// previews are played from the start as soon as they are loaded
+		if err = mux.SetProperty("faststart", true); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		return []*gst.Element{enc, mux}, nil
+
+	default:
+		return nil, errors.ErrInvalidInput("preview format")
+	}
+}
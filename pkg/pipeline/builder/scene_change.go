@@ -0,0 +1,121 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"time"
+)
+
+const (
+	// frames are compared using the average luma of a grid of cells, which ignores noise and small movements
+	signatureColumns = 64
+	signatureRows    = 36
+)
+
+// SceneChange is posted by the image sink when a frame is captured because the scene changed
+type SceneChange struct {
+	Timestamp uint64  // frame pts, matching the timestamp of the multifilesink message
+	Score     float64 // mean luma difference from the previously captured frame, 0-1
+}
+
+// sceneChangeDetector decides whether a frame differs enough from the last captured frame to be captured
+type sceneChangeDetector struct {
+	threshold   float64
+	minInterval time.Duration
+	maxInterval time.Duration
+
+	captured  bool
+	lastPTS   time.Duration
+	signature []byte
+}
+
+// shouldCapture compares the luma plane of a frame to the last captured frame. It returns true if the frame should be
+// captured, and the difference score if the capture was triggered by a scene change.
+func (d *sceneChangeDetector) shouldCapture(luma []byte, width, height, stride int, pts time.Duration) (bool, float64) {
+	if d.captured && pts-d.lastPTS < d.minInterval {
+		return false, 0
+	}
+
+	signature := lumaSignature(luma, width, height, stride)
+	if !d.captured || len(signature) != len(d.signature) {
+		d.capture(signature, pts)
+		return true, 0
+	}
+
+	score := signatureDifference(signature, d.signature)
+	if score >= d.threshold {
+		d.capture(signature, pts)
+		return true, score
+	}
+	if d.maxInterval > 0 && pts-d.lastPTS >= d.maxInterval {
+		d.capture(signature, pts)
+		return true, 0
+	}
+
+	return false, 0
+}
+
+func (d *sceneChangeDetector) capture(signature []byte, pts time.Duration) {
+	d.captured = true
+	d.lastPTS = pts
+	d.signature = signature
+}
+
+// lumaSignature averages the luma plane over a grid of cells
+func lumaSignature(luma []byte, width, height, stride int) []byte {
+	columns := min(signatureColumns, width)
+	rows := min(signatureRows, height)
+	if columns <= 0 || rows <= 0 || len(luma) < stride*(height-1)+width {
+		return nil
+	}
+
+	signature := make([]byte, columns*rows)
+	for row := 0; row < rows; row++ {
+		y0, y1 := row*height/rows, (row+1)*height/rows
+		for column := 0; column < columns; column++ {
+			x0, x1 := column*width/columns, (column+1)*width/columns
+
+			// every other pixel is enough for an average
+			var sum, count int
+			for y := y0; y < y1; y += 2 {
+				line := luma[y*stride:]
+				for x := x0; x < x1; x += 2 {
+					sum += int(line[x])
+					count++
+				}
+			}
+			signature[row*columns+column] = byte(sum / count)
+		}
+	}
+
+	return signature
+}
+
+// signatureDifference returns the mean absolute difference between two signatures, from 0 to 1
+func signatureDifference(a, b []byte) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 1
+	}
+
+	var sum int
+	for i := range a {
+		diff := int(a[i]) - int(b[i])
+		if diff < 0 {
+			diff = -diff
+		}
+		sum += diff
+	}
+	return float64(sum) / float64(len(a)*255)
+}
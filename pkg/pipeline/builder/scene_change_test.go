@@ -0,0 +1,99 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testFrameWidth  = 128
+	testFrameHeight = 72
+)
+
+// newTestFrame returns a luma plane with the left part of the frame set to fg, and the rest set to bg
+func newTestFrame(fg, bg byte, split int) []byte {
+	frame := make([]byte, testFrameWidth*testFrameHeight)
+	for y := 0; y < testFrameHeight; y++ {
+		for x := 0; x < testFrameWidth; x++ {
+			if x < split {
+				frame[y*testFrameWidth+x] = fg
+			} else {
+				frame[y*testFrameWidth+x] = bg
+			}
+		}
+	}
+	return frame
+}
+
+func TestSceneChangeDetector(t *testing.T) {
+	d := &sceneChangeDetector{
+		threshold:   0.1,
+		minInterval: time.Second,
+		maxInterval: 10 * time.Second,
+	}
+	capture := func(frame []byte, pts time.Duration) (bool, float64) {
+		return d.shouldCapture(frame, testFrameWidth, testFrameHeight, testFrameWidth, pts)
+	}
+
+	slide := newTestFrame(255, 0, 0)
+
+	// the first frame is always captured
+	ok, score := capture(slide, 0)
+	require.True(t, ok)
+	require.Zero(t, score)
+
+	// small changes are ignored
+	ok, _ = capture(newTestFrame(255, 0, 4), time.Second)
+	require.False(t, ok)
+
+	// the next slide is captured, but not before the min interval
+	next := newTestFrame(255, 0, testFrameWidth/2)
+	ok, _ = capture(next, 1500*time.Millisecond)
+	require.True(t, ok)
+	ok, score = capture(slide, 2*time.Second)
+	require.False(t, ok)
+	require.Zero(t, score)
+
+	ok, score = capture(slide, 3*time.Second)
+	require.True(t, ok)
+	require.InDelta(t, 0.5, score, 0.01)
+
+	// an image is captured at least once per max interval
+	ok, _ = capture(slide, 12*time.Second)
+	require.False(t, ok)
+	ok, score = capture(slide, 13*time.Second)
+	require.True(t, ok)
+	require.Zero(t, score)
+}
+
+func TestLumaSignatureStride(t *testing.T) {
+	// padding at the end of each line is ignored
+	const stride = testFrameWidth + 4
+	frame := make([]byte, stride*testFrameHeight)
+	for y := 0; y < testFrameHeight; y++ {
+		for x := testFrameWidth; x < stride; x++ {
+			frame[y*stride+x] = 255
+		}
+	}
+
+	signature := lumaSignature(frame, testFrameWidth, testFrameHeight, stride)
+	require.Len(t, signature, signatureColumns*signatureRows)
+	require.Zero(t, signatureDifference(signature, lumaSignature(make([]byte, testFrameWidth*testFrameHeight), testFrameWidth, testFrameHeight, testFrameWidth)))
+	require.Equal(t, 1.0, signatureDifference(signature, nil))
+}
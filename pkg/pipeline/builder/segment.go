@@ -20,37 +20,79 @@ import (
 	"time"
 
 	"github.com/go-gst/go-gst/gst"
+	"github.com/go-gst/go-gst/gst/app"
 
 	"github.com/livekit/egress/pkg/config"
 	"github.com/livekit/egress/pkg/errors"
 	"github.com/livekit/egress/pkg/gstreamer"
+	"github.com/livekit/egress/pkg/types"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
 )
 
+// HLSPushSplitMuxSinkName routes fragment messages to the hls push sink
+const HLSPushSplitMuxSinkName = "splitmuxsink_hls_push"
+
 type FirstSampleMetadata struct {
 	StartDate int64 // Real time date of the first media sample
 }
 
-func BuildSegmentBin(pipeline *gstreamer.Pipeline, p *config.PipelineConfig) (*gstreamer.Bin, error) {
-	b := pipeline.NewBin("segment")
-	o := p.GetSegmentConfig()
+// fMP4 streams are cut into fragments at each keyframe, and at least once per fmp4FragmentDuration.
+// With low latency HLS, each fragment is a part.
+const fmp4FragmentDuration = time.Second
+
+// BuildSegmentBin muxes MPEG-TS segments with splitmuxsink. fMP4 segments are written by a single mp4mux to the
+// appsink, and cut by the segment sink, which keeps the decode times and sequence numbers continuous.
+func BuildSegmentBin(
+	pipeline *gstreamer.Pipeline,
+	p *config.PipelineConfig,
+	o *config.SegmentConfig,
+	appSinkCallbacks *app.SinkCallbacks,
+) (*gstreamer.Bin, error) {
+	var b *gstreamer.Bin
+	var renditionInput *gst.Element
+	if o.Rendition != nil {
+		// rendition bins are linked to the decoded video, and encode it themselves
+		b = pipeline.NewBin(fmt.Sprintf("%s_%s", renditionBinPrefix, o.RenditionName()))
+
+		var err error
+		if renditionInput, err = addRenditionEncoder(b, p, o.Rendition); err != nil {
+			return nil, err
+		}
+	} else if o.Push != nil {
+		b = pipeline.NewBin("hls_push")
+	} else {
+		b = pipeline.NewBin("segment")
+	}
 
-	var h264ParseFixer *ptsFixer
+	var parseFixer *ptsFixer
 
 	var err error
 	if p.VideoEnabled {
-		h264ParseFixer, err = newPTSFixer("h264parse", "segment:h264")
-		if err != nil {
+		if parseFixer, err = newSegmentParser(p.VideoOutCodec); err != nil {
 			return nil, err
 		}
-
-		if err = b.AddElements(h264ParseFixer.Element); err != nil {
+		if err = b.AddElements(parseFixer.Element); err != nil {
 			return nil, errors.ErrGstPipelineError(err)
 		}
 	}
 
-	sink, err := gst.NewElement("splitmuxsink")
+	if appSinkCallbacks != nil {
+		if err = addFMP4Muxer(b, o, parseFixer, renditionInput, appSinkCallbacks); err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+
+	var sink *gst.Element
+	if o.Rendition != nil {
+		// the name is used to route fragment messages to the rendition's segment sink
+		sink, err = gst.NewElementWithName("splitmuxsink", fmt.Sprintf("splitmuxsink_%s", o.RenditionName()))
+	} else if o.Push != nil {
+		sink, err = gst.NewElementWithName("splitmuxsink", HLSPushSplitMuxSinkName)
+	} else {
+		sink, err = gst.NewElement("splitmuxsink")
+	}
 	if err != nil {
 		return nil, errors.ErrGstPipelineError(err)
 	}
@@ -108,8 +150,10 @@ func BuildSegmentBin(pipeline *gstreamer.Pipeline, p *config.PipelineConfig) (*g
 	b.SetGetSrcPad(func(name string) *gst.Pad {
 		if name == audioBinName {
 			return sink.GetRequestPad("audio_%u")
-		} else if h264ParseFixer != nil {
-			return h264ParseFixer.GetStaticPad("sink")
+		} else if renditionInput != nil {
+			return renditionInput.GetStaticPad("sink")
+		} else if parseFixer != nil {
+			return parseFixer.GetStaticPad("sink")
 		}
 		// Should never happen
 		return nil
@@ -118,3 +162,76 @@ func BuildSegmentBin(pipeline *gstreamer.Pipeline, p *config.PipelineConfig) (*g
 
 	return b, nil
 }
+
+// newSegmentParser returns the parser feeding the muxer, which must output a stream the container can carry
+func newSegmentParser(codec types.MimeType) (*ptsFixer, error) {
+	switch codec {
+	case types.MimeTypeH265:
+		parseFixer, err := newPTSFixer("h265parse", "segment:h265")
+		if err != nil {
+			return nil, err
+		}
+		// insert VPS/SPS/PPS with every IDR so that each segment can be decoded independently
+		if err = parseFixer.SetProperty("config-interval", -1); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		return parseFixer, nil
+	case types.MimeTypeAV1:
+		// the encoder already outputs temporal unit aligned obus, which is all mp4mux accepts
+		return newPTSFixer("av1parse", "segment:av1")
+	default:
+		return newPTSFixer("h264parse", "segment:h264")
+	}
+}
+
+func addFMP4Muxer(
+	b *gstreamer.Bin,
+	o *config.SegmentConfig,
+	parseFixer *ptsFixer,
+	renditionInput *gst.Element,
+	appSinkCallbacks *app.SinkCallbacks,
+) error {
+	fragmentDuration := fmp4FragmentDuration
+	if o.LowLatency() {
+		fragmentDuration = o.PartDuration
+	}
+
+	mux, err := gst.NewElement("mp4mux")
+	if err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	if err = mux.SetProperty("fragment-duration", uint(fragmentDuration.Milliseconds())); err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	if err = mux.SetProperty("streamable", true); err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+
+	appSink, err := app.NewAppSink()
+	if err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	appSink.SetCallbacks(appSinkCallbacks)
+
+	if err = b.AddElements(mux, appSink.Element); err != nil {
+		return err
+	}
+
+	if parseFixer != nil {
+		// segments start with a keyframe
+		addKeyFrameRequestProbe(parseFixer.GetStaticPad("src"), time.Duration(o.SegmentDuration)*time.Second)
+	}
+
+	b.SetGetSrcPad(func(name string) *gst.Pad {
+		if name == audioBinName {
+			return mux.GetRequestPad("audio_%u")
+		} else if renditionInput != nil {
+			return renditionInput.GetStaticPad("sink")
+		} else if parseFixer != nil {
+			return parseFixer.GetStaticPad("sink")
+		}
+		return nil
+	})
+
+	return nil
+}
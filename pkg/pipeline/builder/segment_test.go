@@ -0,0 +1,37 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/egress/pkg/types"
+)
+
+func TestNewSegmentParser(t *testing.T) {
+	initGStreamer(t)
+
+	for codec, factory := range map[types.MimeType]string{
+		types.MimeTypeH264: "h264parse",
+		types.MimeTypeH265: "h265parse",
+		types.MimeTypeAV1:  "av1parse",
+	} {
+		parser, err := newSegmentParser(codec)
+		require.NoError(t, err, codec)
+		require.Equal(t, factory, parser.GetFactory().GetName(), codec)
+	}
+}
@@ -16,6 +16,7 @@ package builder
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/frostbyte73/core"
@@ -54,7 +55,13 @@ type StreamBin struct {
 	Bin        *gstreamer.Bin
 	OutputType types.OutputType
 
-	latency time.Duration
+	latency      time.Duration
+	audioEnabled bool
+	videoEnabled bool
+
+	// rtsp streams are fed from a tee per elementary stream, which rtspclientsink payloads and announces as separate media
+	videoTee *gst.Element
+	audioTee *gst.Element
 }
 
 type Stream struct {
@@ -68,6 +75,12 @@ type Stream struct {
 	disconnectedAt atomic.Time
 	failed         atomic.Bool
 
+	// rtsp streams are linked to the tees directly, since they have more than one input
+	rtsp *rtspBranch
+	// rtspclientsink has no stats. Bytes are counted by the proxy pads, and accepted once the sink returns ok.
+	pushedBytes   atomic.Uint64
+	acceptedBytes atomic.Uint64
+
 	// liveness monitor — detects silently-wedged sinks that stop emitting both data and errors.
 	//
 	// We track OutBytesTotal (not OutBytesAcked) because the latter only advances when
@@ -89,8 +102,27 @@ type Stream struct {
 	monitorStop             core.Fuse
 }
 
+// rtspBranch holds a queue per elementary stream, each linked to a request pad on rtspclientsink
+type rtspBranch struct {
+	bin  *gst.Bin
+	pads []*rtspPad
+}
+
+type rtspPad struct {
+	tee     *gst.Element
+	teePad  *gst.Pad
+	sinkPad *gst.GhostPad
+}
+
 func BuildStreamBin(pipeline *gstreamer.Pipeline, p *config.PipelineConfig, o *config.StreamConfig) (*StreamBin, error) {
 	b := pipeline.NewBin("stream")
+	sb := &StreamBin{
+		Bin:          b,
+		OutputType:   o.OutputType,
+		latency:      p.Latency.PipelineLatency,
+		audioEnabled: p.AudioEnabled,
+		videoEnabled: p.VideoEnabled,
+	}
 
 	var mux *gst.Element
 	var err error
@@ -124,6 +156,12 @@ func BuildStreamBin(pipeline *gstreamer.Pipeline, p *config.PipelineConfig, o *c
 			return nil, errors.ErrGstPipelineError(err)
 		}
 
+	case types.OutputTypeRTSP:
+		if err = sb.buildRTSPTees(); err != nil {
+			return nil, err
+		}
+		return sb, nil
+
 	default:
 		err = errors.ErrInvalidInput("output type")
 	}
@@ -131,6 +169,19 @@ func BuildStreamBin(pipeline *gstreamer.Pipeline, p *config.PipelineConfig, o *c
 		return nil, err
 	}
 
+	tee, err := newStreamTee()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = b.AddElements(mux, tee); err != nil {
+		return nil, err
+	}
+
+	return sb, nil
+}
+
+func newStreamTee() (*gst.Element, error) {
 	tee, err := gst.NewElement("tee")
 	if err != nil {
 		return nil, errors.ErrGstPipelineError(err)
@@ -138,22 +189,82 @@ func BuildStreamBin(pipeline *gstreamer.Pipeline, p *config.PipelineConfig, o *c
 	if err = tee.SetProperty("allow-not-linked", true); err != nil {
 		return nil, errors.ErrGstPipelineError(err)
 	}
+	return tee, nil
+}
 
-	if err = b.AddElements(mux, tee); err != nil {
-		return nil, err
+// buildRTSPTees taps the elementary streams without muxing them, each one parsed into the format rtp payloaders expect
+func (sb *StreamBin) buildRTSPTees() error {
+	var videoElements, audioElements []*gst.Element
+	if sb.videoEnabled {
+		h264Parse, err := gst.NewElement("h264parse")
+		if err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		// resend sps and pps with every keyframe, so clients can start playback at any point
+		if err = h264Parse.SetProperty("config-interval", -1); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		if sb.videoTee, err = newStreamTee(); err != nil {
+			return err
+		}
+		videoElements = []*gst.Element{h264Parse, sb.videoTee}
 	}
 
-	sb := &StreamBin{
-		Bin:        b,
-		OutputType: o.OutputType,
-		latency:    p.Latency.PipelineLatency,
+	if sb.audioEnabled {
+		aacParse, err := gst.NewElement("aacparse")
+		if err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		// rtp payloaders require raw aac instead of adts
+		aacCaps, err := gst.NewElement("capsfilter")
+		if err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		if err = aacCaps.SetProperty("caps", gst.NewCapsFromString("audio/mpeg,stream-format=raw")); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		if sb.audioTee, err = newStreamTee(); err != nil {
+			return err
+		}
+		audioElements = []*gst.Element{aacParse, aacCaps, sb.audioTee}
 	}
 
-	return sb, nil
+	if err := sb.Bin.AddElements(append(videoElements, audioElements...)...); err != nil {
+		return err
+	}
+
+	// audio and video are separate branches
+	sb.Bin.SetLinkFunc(func(_ []*gst.Element) error {
+		for _, elements := range [][]*gst.Element{videoElements, audioElements} {
+			if len(elements) == 0 {
+				continue
+			}
+			if err := gst.ElementLinkMany(elements...); err != nil {
+				return errors.ErrGstPipelineError(err)
+			}
+		}
+		return nil
+	})
+	sb.Bin.SetGetSrcPad(func(srcName string) *gst.Pad {
+		if srcName == audioBinName {
+			if len(audioElements) > 0 {
+				return audioElements[0].GetStaticPad("sink")
+			}
+		} else if len(videoElements) > 0 {
+			return videoElements[0].GetStaticPad("sink")
+		}
+		return nil
+	})
+
+	return nil
 }
 
 func (sb *StreamBin) BuildStream(stream *config.Stream, framerate int32) (*Stream, error) {
 	stream.Name = utils.NewGuid("")
+	if sb.OutputType == types.OutputTypeRTSP {
+		return sb.buildRTSPStream(stream)
+	}
+
 	b := sb.Bin.NewBin(stream.Name)
 
 	queue, err := gstreamer.BuildQueue(fmt.Sprintf("queue_%s", stream.Name), sb.latency, true)
@@ -161,12 +272,6 @@ func (sb *StreamBin) BuildStream(stream *config.Stream, framerate int32) (*Strea
 		return nil, errors.ErrGstPipelineError(err)
 	}
 
-	ss := &Stream{
-		Conf:       stream,
-		Bin:        b,
-		outputType: sb.OutputType,
-	}
-
 	var sink *gst.Element
 	switch sb.OutputType {
 	case types.OutputTypeRTMP:
@@ -186,9 +291,23 @@ func (sb *StreamBin) BuildStream(stream *config.Stream, framerate int32) (*Strea
 		if err != nil {
 			return nil, errors.ErrGstPipelineError(err)
 		}
-		if err = sink.SetProperty("uri", stream.ParsedUrl); err != nil {
+		opts, err := config.ParseSRTUrl(stream.ParsedUrl)
+		if err != nil {
+			return nil, errors.ErrInvalidUrl(stream.RedactedUrl, err.Error())
+		}
+		if err = sink.SetProperty("uri", opts.Uri); err != nil {
 			return nil, errors.ErrGstPipelineError(err)
 		}
+		sink.SetArg("mode", opts.Mode)
+		if opts.Passphrase != "" {
+			if err = sink.SetProperty("passphrase", opts.Passphrase); err != nil {
+				return nil, errors.ErrGstPipelineError(err)
+			}
+			if opts.PBKeyLen != 0 {
+				sink.SetArg("pbkeylen", strconv.Itoa(opts.PBKeyLen))
+			}
+		}
+		// in listener mode, buffers are dropped until a receiver connects
 		if err = sink.SetProperty("wait-for-connection", false); err != nil {
 			return nil, errors.ErrGstPipelineError(err)
 		}
@@ -204,10 +323,17 @@ func (sb *StreamBin) BuildStream(stream *config.Stream, framerate int32) (*Strea
 	if err = sink.SetProperty("sync", false); err != nil {
 		return nil, errors.ErrGstPipelineError(err)
 	}
+
 	if err = b.AddElements(queue, sink); err != nil {
 		return nil, err
 	}
-	ss.sink = sink
+
+	ss := &Stream{
+		Conf:       stream,
+		Bin:        b,
+		outputType: sb.OutputType,
+		sink:       sink,
+	}
 
 	// add a proxy pad between the queue and sink to prevent errors from propagating upstream
 	b.SetLinkFunc(func(_ []*gst.Element) error {
@@ -271,6 +397,174 @@ func (sb *StreamBin) BuildStream(stream *config.Stream, framerate int32) (*Strea
 	return ss, nil
 }
 
+func (sb *StreamBin) buildRTSPStream(stream *config.Stream) (*Stream, error) {
+	sink, err := gst.NewElementWithName("rtspclientsink", fmt.Sprintf("rtspclientsink_%s", stream.Name))
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if err = sink.SetProperty("location", stream.ParsedUrl); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	// interleaved tcp is accepted by most servers, and works through firewalls
+	sink.SetArg("protocols", "tcp")
+
+	bin := gst.NewBin(stream.Name)
+	if err = bin.Add(sink); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+
+	ss := &Stream{
+		Conf:       stream,
+		outputType: sb.OutputType,
+		sink:       sink,
+		rtsp:       &rtspBranch{bin: bin},
+	}
+
+	for _, branch := range []struct {
+		kind string
+		tee  *gst.Element
+	}{{"video", sb.videoTee}, {"audio", sb.audioTee}} {
+		kind, tee := branch.kind, branch.tee
+		if tee == nil {
+			continue
+		}
+
+		queue, err := gstreamer.BuildQueue(fmt.Sprintf("queue_%s_%s", kind, stream.Name), sb.latency, true)
+		if err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		if err = bin.Add(queue); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+
+		// add a proxy pad between the queue and sink to prevent errors from propagating upstream
+		proxy := gst.NewGhostPad(fmt.Sprintf("proxy_%s_%s", kind, stream.Name), sink.GetRequestPad("sink_%u"))
+		proxy.Ref()
+		proxy.ActivateMode(gst.PadModePush, true)
+		ss.setRTSPChainFunctions(proxy)
+		if padReturn := queue.GetStaticPad("src").Link(proxy.Pad); padReturn != gst.PadLinkOK {
+			return nil, errors.ErrPadLinkFailed(queue.GetName(), "proxy", padReturn.String())
+		}
+
+		// the queue is linked to the tee once the stream is added
+		sinkPad := gst.NewGhostPad(fmt.Sprintf("%s_%s_sink", kind, stream.Name), queue.GetStaticPad("sink"))
+		bin.AddPad(sinkPad.Pad)
+		ss.rtsp.pads = append(ss.rtsp.pads, &rtspPad{
+			tee:     tee,
+			sinkPad: sinkPad,
+		})
+	}
+
+	return ss, nil
+}
+
+// setRTSPChainFunctions counts the bytes pushed to rtspclientsink.
+// Errors are reported by rtspclientsink, and the stream is reset or removed.
+func (s *Stream) setRTSPChainFunctions(proxy *gst.GhostPad) {
+	push := func(size uint64, push func() gst.FlowReturn) gst.FlowReturn {
+		s.pushedBytes.Add(size)
+		switch push() {
+		case gst.FlowEOS:
+			return gst.FlowEOS
+		case gst.FlowOK:
+			s.acceptedBytes.Add(size)
+		}
+		return gst.FlowOK
+	}
+
+	proxy.SetChainFunction(func(self *gst.Pad, _ *gst.Object, buffer *gst.Buffer) gst.FlowReturn {
+		buffer.Ref()
+
+		links, _ := self.GetInternalLinks()
+		if len(links) != 1 {
+			return gst.FlowNotLinked
+		}
+		return push(uint64(buffer.GetSize()), func() gst.FlowReturn {
+			return links[0].Push(buffer)
+		})
+	})
+	proxy.SetChainListFunction(func(self *gst.Pad, _ *gst.Object, list *gst.BufferList) gst.FlowReturn {
+		list.Ref()
+
+		links, _ := self.GetInternalLinks()
+		if len(links) != 1 {
+			return gst.FlowNotLinked
+		}
+		return push(uint64(list.CalculateSize()), func() gst.FlowReturn {
+			return links[0].PushList(list)
+		})
+	})
+}
+
+// AddStream links a stream built by BuildStream
+func (sb *StreamBin) AddStream(ss *Stream) error {
+	if ss.rtsp == nil {
+		return sb.Bin.AddSinkBin(ss.Bin)
+	}
+
+	sb.Bin.LockStateShared()
+	defer sb.Bin.UnlockStateShared()
+
+	state := sb.Bin.GetStateLocked()
+	if state > gstreamer.StateRunning {
+		return nil
+	}
+
+	if err := sb.Bin.AddElement(ss.rtsp.bin.Element); err != nil {
+		return err
+	}
+	if state > gstreamer.StateBuilding && !ss.rtsp.bin.SyncStateWithParent() {
+		return errors.ErrGstPipelineError(fmt.Errorf("failed to sync %s state with parent", ss.Conf.Name))
+	}
+
+	for _, pad := range ss.rtsp.pads {
+		pad.teePad = pad.tee.GetRequestPad("src_%u")
+		if padReturn := pad.teePad.Link(pad.sinkPad.Pad); padReturn != gst.PadLinkOK {
+			return errors.ErrPadLinkFailed(pad.tee.GetName(), ss.Conf.Name, padReturn.String())
+		}
+	}
+
+	return nil
+}
+
+// RemoveStream unlinks a stream, and stops it once every input is idle
+func (sb *StreamBin) RemoveStream(ss *Stream) error {
+	if ss.rtsp == nil {
+		return sb.Bin.RemoveSinkBin(ss.Conf.Name)
+	}
+
+	sb.Bin.LockStateShared()
+	defer sb.Bin.UnlockStateShared()
+
+	if sb.Bin.GetStateLocked() > gstreamer.StateRunning {
+		return nil
+	}
+
+	var remaining atomic.Int32
+	remaining.Store(int32(len(ss.rtsp.pads)))
+	for _, pad := range ss.rtsp.pads {
+		pad.teePad.AddProbe(gst.PadProbeTypeIdle, func(_ *gst.Pad, _ *gst.PadProbeInfo) gst.PadProbeReturn {
+			pad.teePad.Unlink(pad.sinkPad.Pad)
+			pad.sinkPad.SendEvent(gst.NewEOSEvent())
+			pad.tee.ReleaseRequestPad(pad.teePad)
+			if remaining.Dec() > 0 {
+				return gst.PadProbeRemove
+			}
+
+			if err := sb.Bin.RemoveElement(ss.rtsp.bin.Element); err != nil {
+				sb.Bin.OnError(err)
+				return gst.PadProbeRemove
+			}
+			if err := ss.rtsp.bin.SetState(gst.StateNull); err != nil {
+				logger.Warnw(fmt.Sprintf("failed to change %s state", ss.Conf.Name), err)
+			}
+			return gst.PadProbeRemove
+		})
+	}
+
+	return nil
+}
+
 func (s *Stream) Reset(streamErr error) (bool, error) {
 	if s.livenessFailed.Load() {
 		return false, nil
@@ -304,16 +598,26 @@ func (s *Stream) Reset(streamErr error) (bool, error) {
 	s.reconnections.Inc()
 	logger.Warnw("resetting stream", streamErr, "url", s.Conf.RedactedUrl)
 
-	if err := s.Bin.SetState(gst.StateNull); err != nil {
+	if err := s.setState(gst.StateNull); err != nil {
 		return false, err
 	}
-	if err := s.Bin.SetState(gst.StatePlaying); err != nil {
+	if err := s.setState(gst.StatePlaying); err != nil {
 		return false, err
 	}
 
 	return true, nil
 }
 
+func (s *Stream) setState(state gst.State) error {
+	if s.rtsp == nil {
+		return s.Bin.SetState(state)
+	}
+	if err := s.rtsp.bin.SetState(state); err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	return nil
+}
+
 const (
 	outBytesTotal = "out-bytes-total"
 	outBytesAcked = "out-bytes-acked"
@@ -323,6 +627,15 @@ const (
 )
 
 func (s *Stream) Stats() (*logging.StreamStats, bool) {
+	if s.outputType == types.OutputTypeRTSP {
+		// rtspclientsink has no stats, bytes are counted by the proxy pads
+		return &logging.StreamStats{
+			Timestamp:     time.Now().Format(time.DateTime),
+			OutBytesTotal: s.pushedBytes.Load(),
+			OutBytesAcked: s.acceptedBytes.Load(),
+		}, true
+	}
+
 	structure, err := s.sink.GetProperty("stats")
 	if err != nil || structure == nil {
 		return nil, false
@@ -33,11 +33,14 @@ import (
 const (
 	videoTestSrcName  = "video_test_src"
 	videoTestSrcDelay = 2 * time.Second
+
+	renditionBinPrefix = "rendition"
 )
 
 type VideoBin struct {
-	bin  *gstreamer.Bin
-	conf *config.PipelineConfig
+	bin       *gstreamer.Bin
+	conf      *config.PipelineConfig
+	rendition *config.Rendition
 
 	mu          deadlock.Mutex
 	nextID      int
@@ -87,8 +90,14 @@ func BuildVideoBin(pipeline *gstreamer.Pipeline, p *config.PipelineConfig) error
 		pipeline.AddOnTrackUnmuted(b.onTrackUnmuted)
 	}
 
+	encodedOutputs := len(p.GetEncodedOutputs())
+	if o := p.GetSegmentConfig(); o != nil {
+		// adaptive bitrate renditions are encoded separately, from the decoded video
+		encodedOutputs -= len(o.Variants)
+	}
+
 	var getPad func() *gst.Pad
-	if len(p.GetEncodedOutputs()) > 1 {
+	if encodedOutputs > 1 {
 		tee, err := gst.NewElementWithName("tee", "video_tee")
 		if err != nil {
 			return errors.ErrGstPipelineError(err)
@@ -101,7 +110,7 @@ func BuildVideoBin(pipeline *gstreamer.Pipeline, p *config.PipelineConfig) error
 		getPad = func() *gst.Pad {
 			return tee.GetRequestPad("src_%u")
 		}
-	} else if len(p.GetEncodedOutputs()) > 0 {
+	} else if encodedOutputs > 0 {
 		queue, err := b.buildVideoQueue("video_queue")
 		if err != nil {
 			return err
@@ -116,7 +125,7 @@ func BuildVideoBin(pipeline *gstreamer.Pipeline, p *config.PipelineConfig) error
 	}
 
 	b.bin.SetGetSinkPad(func(name string) *gst.Pad {
-		if strings.HasPrefix(name, "image") {
+		if strings.HasPrefix(name, "image") || strings.HasPrefix(name, renditionBinPrefix) || name == previewBinName {
 			return b.rawVideoTee.GetRequestPad("src_%u")
 		} else if getPad != nil {
 			return getPad()
@@ -577,7 +586,6 @@ func (b *VideoBin) addEncoder() error {
 	}
 
 	switch b.conf.VideoOutCodec {
-	// we only encode h264, the rest are too slow
 	case types.MimeTypeH264:
 		x264Enc, err := gst.NewElement("x264enc")
 		if err != nil {
@@ -593,35 +601,22 @@ func (b *VideoBin) addEncoder() error {
 		}
 
 		var options []string
-		disabledSceneCut := false
 		// Streaming outputs always set KeyFrameInterval, so this effectively disables scenecut for RTMP/SRT.
-		if b.conf.KeyFrameInterval != 0 {
-			keyframeInterval := uint(b.conf.KeyFrameInterval * float64(b.conf.Framerate))
+		if keyframeInterval := b.keyframeInterval(); keyframeInterval != 0 {
 			if err = x264Enc.SetProperty("key-int-max", keyframeInterval); err != nil {
 				return errors.ErrGstPipelineError(err)
 			}
-			options = append(options, "scenecut=0")
-			disabledSceneCut = true
 		}
-
-		bufCapacity := uint(2000) // 2s
-		if b.conf.GetSegmentConfig() != nil {
+		if b.conf.KeyFrameInterval != 0 || b.conf.GetSegmentConfig() != nil {
 			// avoid key frames other than at segments boundaries as splitmuxsink can become inconsistent otherwise
-			if !disabledSceneCut {
-				options = append(options, "scenecut=0")
-				disabledSceneCut = true
-			}
-			bufCapacity = uint(time.Duration(b.conf.GetSegmentConfig().SegmentDuration) * (time.Second / time.Millisecond))
-		}
-		if bufCapacity > 10000 {
-			// Max value allowed by gstreamer
-			bufCapacity = 10000
+			options = append(options, "scenecut=0")
 		}
-		if err = x264Enc.SetProperty("vbv-buf-capacity", bufCapacity); err != nil {
+
+		if err = x264Enc.SetProperty("vbv-buf-capacity", b.bufferCapacity()); err != nil {
 			return errors.ErrGstPipelineError(err)
 		}
 
-		if err = x264Enc.SetProperty("bitrate", uint(b.conf.VideoBitrate)); err != nil {
+		if err = x264Enc.SetProperty("bitrate", uint(b.videoBitrate())); err != nil {
 			return errors.ErrGstPipelineError(err)
 		}
 
@@ -651,6 +646,102 @@ func (b *VideoBin) addEncoder() error {
 		}
 		return nil
 
+	case types.MimeTypeH265:
+		x265Enc, err := gst.NewElement("x265enc")
+		if err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+
+		x265Enc.SetArg("speed-preset", "veryfast")
+
+		if keyframeInterval := b.keyframeInterval(); keyframeInterval != 0 {
+			if err = x265Enc.SetProperty("key-int-max", int(keyframeInterval)); err != nil {
+				return errors.ErrGstPipelineError(err)
+			}
+		}
+		if err = x265Enc.SetProperty("bitrate", uint(b.videoBitrate())); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+
+		// x265 expects the vbv buffer in kbits rather than milliseconds
+		options := []string{
+			fmt.Sprintf("vbv-maxrate=%d", b.videoBitrate()),
+			fmt.Sprintf("vbv-bufsize=%d", uint(b.videoBitrate())*b.bufferCapacity()/1000),
+			"repeat-headers=1",
+		}
+		if b.conf.VideoEncoderThreads > 0 {
+			options = append(options, fmt.Sprintf("pools=%d", b.conf.VideoEncoderThreads))
+		}
+		if b.conf.KeyFrameInterval != 0 || b.conf.GetSegmentConfig() != nil {
+			// avoid key frames other than at segments boundaries as splitmuxsink can become inconsistent otherwise
+			options = append(options, "scenecut=0")
+		}
+		if err = x265Enc.SetProperty("option-string", strings.Join(options, ":")); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+
+		caps, err := gst.NewElement("capsfilter")
+		if err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		if err = caps.SetProperty("caps", gst.NewCapsFromString(fmt.Sprintf(
+			"video/x-h265,profile=%s",
+			b.conf.VideoProfile,
+		))); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+
+		// x265enc only produces byte-stream, mp4mux requires hvc1
+		h265Parse, err := gst.NewElement("h265parse")
+		if err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+
+		return b.bin.AddElements(x265Enc, caps, h265Parse)
+
+	case types.MimeTypeVP8:
+		vp8Enc, err := gst.NewElement("vp8enc")
+		if err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		if err = vp8Enc.SetProperty("deadline", int64(1)); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		if err = vp8Enc.SetProperty("cpu-used", 4); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		if err = vp8Enc.SetProperty("max-quantizer", 56); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		if err = vp8Enc.SetProperty("min-quantizer", 2); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		if b.conf.VideoEncoderThreads > 0 {
+			if err = vp8Enc.SetProperty("threads", int(b.conf.VideoEncoderThreads)); err != nil {
+				return errors.ErrGstPipelineError(err)
+			}
+		}
+
+		// constant bitrate, with the same buffer sizing as x264
+		vp8Enc.SetArg("end-usage", "cbr")
+		if err = vp8Enc.SetProperty("target-bitrate", int(b.videoBitrate()*1000)); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		if err = vp8Enc.SetProperty("buffer-size", int(b.bufferCapacity())); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+
+		if keyframeInterval := b.keyframeInterval(); keyframeInterval != 0 {
+			if err = vp8Enc.SetProperty("keyframe-max-dist", int(keyframeInterval)); err != nil {
+				return errors.ErrGstPipelineError(err)
+			}
+		} else if b.conf.GetSegmentConfig() != nil {
+			// only place key frames when splitmuxsink requests them at segment boundaries
+			vp8Enc.SetArg("keyframe-mode", "disabled")
+		}
+
+		return b.bin.AddElement(vp8Enc)
+
 	case types.MimeTypeVP9:
 		vp9Enc, err := gst.NewElement("vp9enc")
 		if err != nil {
@@ -677,17 +768,160 @@ func (b *VideoBin) addEncoder() error {
 		if err = vp9Enc.SetProperty("min-quantizer", 2); err != nil {
 			return errors.ErrGstPipelineError(err)
 		}
-		if err = b.bin.AddElement(vp9Enc); err != nil {
-			return err
+		if b.conf.VideoEncoderThreads > 0 {
+			if err = vp9Enc.SetProperty("threads", int(b.conf.VideoEncoderThreads)); err != nil {
+				return errors.ErrGstPipelineError(err)
+			}
+		}
+
+		// constant bitrate, with the same buffer sizing as x264
+		vp9Enc.SetArg("end-usage", "cbr")
+		if err = vp9Enc.SetProperty("target-bitrate", int(b.videoBitrate()*1000)); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		if err = vp9Enc.SetProperty("buffer-size", int(b.bufferCapacity())); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+
+		if keyframeInterval := b.keyframeInterval(); keyframeInterval != 0 {
+			if err = vp9Enc.SetProperty("keyframe-max-dist", int(keyframeInterval)); err != nil {
+				return errors.ErrGstPipelineError(err)
+			}
+		} else if b.conf.GetSegmentConfig() != nil {
+			// only place key frames when splitmuxsink requests them at segment boundaries
+			vp9Enc.SetArg("keyframe-mode", "disabled")
+		}
+
+		return b.bin.AddElement(vp9Enc)
+
+	case types.MimeTypeAV1:
+		av1Enc, err := gst.NewElement("svtav1enc")
+		if err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		// presets range from 0 (slowest) to 13 (fastest), 10+ are intended for real-time encoding
+		if err = av1Enc.SetProperty("preset", uint(10)); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		if b.conf.VideoEncoderThreads > 0 {
+			if err = av1Enc.SetProperty("logical-processors", b.conf.VideoEncoderThreads); err != nil {
+				return errors.ErrGstPipelineError(err)
+			}
+		}
+		if err = av1Enc.SetProperty("target-bitrate", uint(b.videoBitrate())); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+
+		// constant bitrate, with the same buffer sizing as x264. svt-av1 only supports cbr with the
+		// low delay prediction structure, and fails to initialize otherwise
+		options := []string{
+			"rc=2",
+			"pred-struct=1",
+			fmt.Sprintf("buf-sz=%d", b.bufferCapacity()),
+		}
+		if keyframeInterval := b.keyframeInterval(); keyframeInterval != 0 {
+			if err = av1Enc.SetProperty("intra-period-length", int(keyframeInterval)); err != nil {
+				return errors.ErrGstPipelineError(err)
+			}
+		}
+		if b.conf.KeyFrameInterval != 0 || b.conf.GetSegmentConfig() != nil {
+			// avoid key frames other than at segments boundaries as splitmuxsink can become inconsistent otherwise
+			options = append(options, "scd=0")
+		}
+		if err = av1Enc.SetProperty("parameters-string", strings.Join(options, ":")); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+
+		// mp4mux requires temporal unit aligned obu streams
+		av1Parse, err := gst.NewElement("av1parse")
+		if err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+
+		caps, err := gst.NewElement("capsfilter")
+		if err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		if err = caps.SetProperty("caps", gst.NewCapsFromString(
+			"video/x-av1,stream-format=obu-stream,alignment=tu",
+		)); err != nil {
+			return errors.ErrGstPipelineError(err)
 		}
 
-		fallthrough
+		return b.bin.AddElements(av1Enc, av1Parse, caps)
 
 	default:
 		return errors.ErrNotSupported(fmt.Sprintf("%s encoding", b.conf.VideoOutCodec))
 	}
 }
 
+// addRenditionEncoder scales the decoded video down to an adaptive bitrate rendition and encodes it into bin.
+// It returns the first element, to be linked to the decoded video.
+func addRenditionEncoder(bin *gstreamer.Bin, p *config.PipelineConfig, r *config.Rendition) (*gst.Element, error) {
+	b := &VideoBin{
+		bin:       bin,
+		conf:      p,
+		rendition: r,
+	}
+
+	videoQueue, err := b.buildVideoQueue("rendition_input_queue")
+	if err != nil {
+		return nil, err
+	}
+
+	videoScale, err := gst.NewElement("videoscale")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+
+	caps, err := gst.NewElement("capsfilter")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if err = caps.SetProperty("caps", gst.NewCapsFromString(fmt.Sprintf(
+		"video/x-raw,format=I420,width=%d,height=%d,pixel-aspect-ratio=1/1",
+		r.Width, r.Height,
+	))); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+
+	if err = bin.AddElements(videoQueue, videoScale, caps); err != nil {
+		return nil, err
+	}
+
+	if err = b.addEncoder(); err != nil {
+		return nil, err
+	}
+
+	return videoQueue, nil
+}
+
+// videoBitrate returns the target bitrate, in kbps
+func (b *VideoBin) videoBitrate() int32 {
+	if b.rendition != nil {
+		return b.rendition.VideoBitrate
+	}
+	return b.conf.VideoBitrate
+}
+
+// keyframeInterval returns the maximum distance between key frames, in frames, or 0 if unset
+func (b *VideoBin) keyframeInterval() uint {
+	return uint(b.conf.KeyFrameInterval * float64(b.conf.Framerate))
+}
+
+// bufferCapacity returns the encoder rate control buffer size, in milliseconds
+func (b *VideoBin) bufferCapacity() uint {
+	bufCapacity := uint(2000) // 2s
+	if o := b.conf.GetSegmentConfig(); o != nil {
+		bufCapacity = uint(time.Duration(o.SegmentDuration) * (time.Second / time.Millisecond))
+	}
+	if bufCapacity > 10000 {
+		// Max value allowed by gstreamer
+		bufCapacity = 10000
+	}
+	return bufCapacity
+}
+
 func (b *VideoBin) addDecodedVideoSink() error {
 	var err error
 	b.rawVideoTee, err = gst.NewElement("tee")
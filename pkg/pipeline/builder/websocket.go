@@ -18,8 +18,10 @@ import (
 	"github.com/go-gst/go-gst/gst"
 	"github.com/go-gst/go-gst/gst/app"
 
+	"github.com/livekit/egress/pkg/config"
 	"github.com/livekit/egress/pkg/errors"
 	"github.com/livekit/egress/pkg/gstreamer"
+	"github.com/livekit/egress/pkg/types"
 )
 
 func BuildWebsocketBin(pipeline *gstreamer.Pipeline, appSinkCallbacks *app.SinkCallbacks) (*gstreamer.Bin, error) {
@@ -41,3 +43,13 @@ func BuildWebsocketBin(pipeline *gstreamer.Pipeline, appSinkCallbacks *app.SinkC
 
 	return b, nil
 }
+
+// WebsocketAudioFormat returns the sample rate and channel count of websocket audio
+func WebsocketAudioFormat(p *config.PipelineConfig) (int32, int) {
+	switch p.AudioOutCodec {
+	case types.MimeTypePCMU, types.MimeTypePCMA:
+		return audioSampleRate(p), 1
+	default:
+		return audioSampleRate(p), 2
+	}
+}
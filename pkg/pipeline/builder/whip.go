@@ -0,0 +1,121 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"github.com/go-gst/go-gst/gst"
+	"github.com/go-gst/go-gst/gst/app"
+
+	"github.com/livekit/egress/pkg/config"
+	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/egress/pkg/gstreamer"
+	"github.com/livekit/egress/pkg/types"
+)
+
+const whipBinName = "whip"
+
+// BuildWHIPBin hands the encoded audio and video to the whip sink, which packetizes them for webrtc.
+// The video app sink is returned for keyframe requests.
+func BuildWHIPBin(
+	pipeline *gstreamer.Pipeline,
+	p *config.PipelineConfig,
+	audioCallbacks, videoCallbacks *app.SinkCallbacks,
+) (*gstreamer.Bin, *gst.Element, error) {
+	b := pipeline.NewBin(whipBinName)
+
+	var audioSink, videoSink *gst.Element
+	var videoElements []*gst.Element
+	if p.VideoEnabled {
+		if p.VideoOutCodec == types.MimeTypeH264 {
+			// webrtc packetizes annex b access units, with parameter sets in front of every key frame
+			h264Parse, err := gst.NewElement("h264parse")
+			if err != nil {
+				return nil, nil, errors.ErrGstPipelineError(err)
+			}
+			if err = h264Parse.SetProperty("config-interval", -1); err != nil {
+				return nil, nil, errors.ErrGstPipelineError(err)
+			}
+
+			caps, err := gst.NewElement("capsfilter")
+			if err != nil {
+				return nil, nil, errors.ErrGstPipelineError(err)
+			}
+			if err = caps.SetProperty("caps", gst.NewCapsFromString(
+				"video/x-h264,stream-format=byte-stream,alignment=au",
+			)); err != nil {
+				return nil, nil, errors.ErrGstPipelineError(err)
+			}
+
+			videoElements = append(videoElements, h264Parse, caps)
+		}
+
+		appSink, err := newWHIPAppSink("whip_video", videoCallbacks)
+		if err != nil {
+			return nil, nil, err
+		}
+		videoSink = appSink.Element
+		videoElements = append(videoElements, videoSink)
+
+		if err = b.AddElements(videoElements...); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if p.AudioEnabled {
+		appSink, err := newWHIPAppSink("whip_audio", audioCallbacks)
+		if err != nil {
+			return nil, nil, err
+		}
+		audioSink = appSink.Element
+
+		if err = b.AddElement(audioSink); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// audio and video are separate branches, only the video elements are chained
+	b.SetLinkFunc(func(_ []*gst.Element) error {
+		if len(videoElements) > 1 {
+			if err := gst.ElementLinkMany(videoElements...); err != nil {
+				return errors.ErrGstPipelineError(err)
+			}
+		}
+		return nil
+	})
+	b.SetGetSrcPad(func(srcName string) *gst.Pad {
+		if srcName == audioBinName {
+			if audioSink != nil {
+				return audioSink.GetStaticPad("sink")
+			}
+		} else if len(videoElements) > 0 {
+			return videoElements[0].GetStaticPad("sink")
+		}
+		return nil
+	})
+
+	return b, videoSink, nil
+}
+
+func newWHIPAppSink(name string, callbacks *app.SinkCallbacks) (*app.Sink, error) {
+	appSink, err := app.NewAppSink()
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	appSink.SetCallbacks(callbacks)
+	if err = appSink.SetProperty("name", name); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	return appSink, nil
+}
@@ -21,6 +21,7 @@ import (
 	"path"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -177,6 +178,25 @@ func newController(conf *config.PipelineConfig, ipcServiceClient ipc.EgressServi
 	}
 	c.callbacks.SetOnError(c.OnError)
 	c.callbacks.SetOnEOSSent(c.onEOSSent)
+	c.callbacks.SetOnEOSRequested(func(reason string) {
+		c.SendEOS(context.Background(), reason)
+	})
+	c.callbacks.SetOnStreamFailed(func(egressType types.EgressType, stream *config.Stream, streamErr error) {
+		if err := c.streamFailed(context.Background(), egressType, stream, streamErr); err != nil {
+			c.OnError(err)
+		}
+	})
+	c.callbacks.SetOnStreamFinished(func(egressType types.EgressType, stream *config.Stream) {
+		ctx := context.Background()
+		if err := c.streamFinished(ctx, egressType, stream); err != nil {
+			c.OnError(err)
+			return
+		}
+		c.streamUpdated(ctx)
+	})
+	c.callbacks.SetOnStreamRetry(func(stream *config.Stream) {
+		c.trackStreamRetry(context.Background(), stream)
+	})
 	c.callbacks.SetOnDebugDotRequest(func(reason string) {
 		if !c.Debug.EnableProfiling {
 			return
@@ -415,7 +435,7 @@ func (c *Controller) UpdateStream(ctx context.Context, req *livekit.UpdateStream
 			continue
 		}
 
-		if err = c.streamFinished(ctx, stream); err != nil {
+		if err = c.streamFinished(ctx, types.EgressTypeStream, stream); err != nil {
 			errs.AppendErr(err)
 		}
 	}
@@ -424,12 +444,12 @@ func (c *Controller) UpdateStream(ctx context.Context, req *livekit.UpdateStream
 	return errs.ToError()
 }
 
-func (c *Controller) streamFinished(ctx context.Context, stream *config.Stream) error {
+func (c *Controller) streamFinished(ctx context.Context, egressType types.EgressType, stream *config.Stream) error {
 	stream.StreamInfo.Status = livekit.StreamInfo_FINISHED
 	stream.UpdateEndTime(time.Now().UnixNano())
 
 	// remove output
-	o := c.GetStreamConfig()
+	o := c.getStreamConfig(egressType)
 	o.Streams.Delete(stream.ParsedUrl)
 	c.OutputCount.Dec()
 
@@ -445,16 +465,16 @@ func (c *Controller) streamFinished(ctx context.Context, stream *config.Stream)
 		"duration", stream.StreamInfo.Duration,
 	)
 
-	return c.getStreamSink().RemoveStream(stream)
+	return c.removeStream(egressType, stream)
 }
 
-func (c *Controller) streamFailed(ctx context.Context, stream *config.Stream, streamErr error) error {
+func (c *Controller) streamFailed(ctx context.Context, egressType types.EgressType, stream *config.Stream, streamErr error) error {
 	stream.StreamInfo.Status = livekit.StreamInfo_FAILED
 	stream.StreamInfo.Error = streamErr.Error()
 	stream.UpdateEndTime(time.Now().UnixNano())
 
 	// remove output
-	o := c.GetStreamConfig()
+	o := c.getStreamConfig(egressType)
 	o.Streams.Delete(stream.ParsedUrl)
 	c.OutputCount.Dec()
 
@@ -470,19 +490,45 @@ func (c *Controller) streamFailed(ctx context.Context, stream *config.Stream, st
 		"error", streamErr)
 
 	c.streamUpdated(ctx)
+	return c.removeStream(egressType, stream)
+}
+
+func (c *Controller) getStreamConfig(egressType types.EgressType) *config.StreamConfig {
+	switch egressType {
+	case types.EgressTypeWebsocket:
+		return c.GetWebsocketConfig()
+	case types.EgressTypeWHIP:
+		return c.GetWHIPConfig()
+	case types.EgressTypeHLSPush:
+		return c.GetHLSPushConfig()
+	default:
+		return c.GetStreamConfig()
+	}
+}
+
+// removeStream removes a stream from the stream sink. Other sinks have a single stream, and stop sending to it
+// before reporting it.
+func (c *Controller) removeStream(egressType types.EgressType, stream *config.Stream) error {
+	if egressType != types.EgressTypeStream {
+		return nil
+	}
 	return c.getStreamSink().RemoveStream(stream)
 }
 
 func (c *Controller) trackStreamRetry(ctx context.Context, stream *config.Stream) {
 	now := time.Now()
+	c.mu.Lock()
 	stream.StreamInfo.LastRetryAt = now.UnixNano()
 	stream.StreamInfo.Retries++
+	retries := stream.StreamInfo.Retries
+	c.mu.Unlock()
+
 	if !stream.ShouldSendRetryUpdate(now, streamRetryUpdateInterval) {
 		return
 	}
 	logger.Infow("retrying stream update",
 		"url", stream.RedactedUrl,
-		"retries", stream.StreamInfo.Retries,
+		"retries", retries,
 	)
 
 	c.streamUpdated(ctx)
@@ -560,7 +606,7 @@ func (c *Controller) sendEOS() {
 		logger.Debugw("eos timer firing")
 		for egressType, si := range c.sinks {
 			switch egressType {
-			case types.EgressTypeFile, types.EgressTypeSegments, types.EgressTypeImages:
+			case types.EgressTypeFile, types.EgressTypeSegments, types.EgressTypeImages, types.EgressTypeHLSPush:
 				for _, s := range si {
 					if !c.eosReceived.IsBroken() && !s.EOSReceived() {
 						c.OnError(errors.ErrPipelineFrozen)
@@ -672,7 +718,7 @@ func (c *Controller) startSessionLimitTimer(ctx context.Context) {
 		switch egressType {
 		case types.EgressTypeFile:
 			t = c.FileOutputMaxDuration
-		case types.EgressTypeStream, types.EgressTypeWebsocket:
+		case types.EgressTypeStream, types.EgressTypeWebsocket, types.EgressTypeWHIP, types.EgressTypeHLSPush:
 			t = c.StreamOutputMaxDuration
 		case types.EgressTypeSegments:
 			t = c.SegmentOutputMaxDuration
@@ -856,7 +902,7 @@ func (c *Controller) updateStartTime(startedAt int64) {
 		}
 
 		switch egressType {
-		case types.EgressTypeStream, types.EgressTypeWebsocket:
+		case types.EgressTypeStream, types.EgressTypeWebsocket, types.EgressTypeWHIP, types.EgressTypeHLSPush:
 			streamConfig := o[0].(*config.StreamConfig)
 			if streamConfig.OutputType == types.OutputTypeRTMP {
 				// rtmp has special start time handling
@@ -965,7 +1011,7 @@ func (c *Controller) updateEndTime() {
 			continue
 		}
 		switch egressType {
-		case types.EgressTypeStream, types.EgressTypeWebsocket:
+		case types.EgressTypeStream, types.EgressTypeWebsocket, types.EgressTypeWHIP, types.EgressTypeHLSPush:
 			streamConfig := o[0].(*config.StreamConfig)
 			streamConfig.Streams.Range(func(_, s any) bool {
 				stream := s.(*config.Stream)
@@ -1058,13 +1104,32 @@ func (c *Controller) getStreamSink() *sink.StreamSink {
 	return s[0].(*sink.StreamSink)
 }
 
-func (c *Controller) getSegmentSink() *sink.SegmentSink {
+func (c *Controller) getSegmentSink(name string) *sink.SegmentSink {
+	if name == builder.HLSPushSplitMuxSinkName {
+		if s := c.sinks[types.EgressTypeHLSPush]; len(s) > 0 {
+			return s[0].(*sink.HLSPushSink).SegmentSink
+		}
+		return nil
+	}
+
 	s := c.sinks[types.EgressTypeSegments]
 	if len(s) == 0 {
 		return nil
 	}
 
-	return s[0].(*sink.SegmentSink)
+	// adaptive bitrate renditions use named splitmuxsinks, the main output's is unnamed
+	rendition := ""
+	if strings.HasPrefix(name, "splitmuxsink_") {
+		rendition = name[len("splitmuxsink_"):]
+	}
+
+	for _, si := range s {
+		if ss := si.(*sink.SegmentSink); ss.RenditionName() == rendition {
+			return ss
+		}
+	}
+
+	return nil
 }
 
 func (c *Controller) getImageSink(name string) *sink.ImageSink {
@@ -0,0 +1,162 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-gst/go-gst/gst"
+
+	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/protocol/logger"
+)
+
+const (
+	// the remux reads and writes the whole file, its timeout grows with the file size
+	fastStartMinTimeout    = 30 * time.Second
+	fastStartMinThroughput = 10 << 20 // bytes per second
+)
+
+// remuxFastStart rewrites a fragmented mp4 in place as a regular mp4, with the moov atom at the start of the file
+func remuxFastStart(filepath string, audio, video bool) error {
+	info, err := os.Stat(filepath)
+	if err != nil {
+		return err
+	}
+
+	tmpFilepath := filepath + ".faststart"
+	pipeline, err := buildFastStartPipeline(filepath, tmpFilepath, audio, video)
+	if err == nil {
+		err = runFastStartPipeline(pipeline, fastStartTimeout(info.Size()))
+	}
+	if err != nil {
+		_ = os.Remove(tmpFilepath)
+		return err
+	}
+	return os.Rename(tmpFilepath, filepath)
+}
+
+func fastStartTimeout(size int64) time.Duration {
+	return fastStartMinTimeout + time.Duration(size/fastStartMinThroughput)*time.Second
+}
+
+func buildFastStartPipeline(src, dst string, audio, video bool) (*gst.Pipeline, error) {
+	pipeline, err := gst.NewPipeline("faststart")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+
+	fileSrc, err := gst.NewElement("filesrc")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if err = fileSrc.SetProperty("location", src); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+
+	demux, err := gst.NewElement("qtdemux")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+
+	mux, err := gst.NewElement("mp4mux")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if err = mux.SetProperty("faststart", true); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+
+	fileSink, err := gst.NewElement("filesink")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if err = fileSink.SetProperty("location", dst); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+
+	if err = pipeline.AddMany(fileSrc, demux, mux, fileSink); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if err = fileSrc.Link(demux); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if err = mux.Link(fileSink); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+
+	// only the recorded streams get a muxer pad, or the muxer would wait for them forever
+	queues := make(map[string]*gst.Element)
+	for kind, enabled := range map[string]bool{"audio": audio, "video": video} {
+		if !enabled {
+			continue
+		}
+		queue, err := gst.NewElement("queue")
+		if err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		if err = pipeline.Add(queue); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		if padReturn := queue.GetStaticPad("src").Link(mux.GetRequestPad(kind + "_%u")); padReturn != gst.PadLinkOK {
+			return nil, errors.ErrPadLinkFailed(queue.GetName(), mux.GetName(), padReturn.String())
+		}
+		queues[kind] = queue
+	}
+
+	// demuxer pads are named audio_0 and video_0
+	if _, err = demux.Connect("pad-added", func(_ *gst.Element, pad *gst.Pad) {
+		kind, _, _ := strings.Cut(pad.GetName(), "_")
+		queue, ok := queues[kind]
+		if !ok || queue.GetStaticPad("sink").IsLinked() {
+			logger.Debugw("ignoring demuxed stream", "pad", pad.GetName())
+			return
+		}
+		if padReturn := pad.Link(queue.GetStaticPad("sink")); padReturn != gst.PadLinkOK {
+			logger.Warnw("failed to link demuxed stream", nil, "pad", pad.GetName(), "result", padReturn.String())
+		}
+	}); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+
+	return pipeline, nil
+}
+
+func runFastStartPipeline(pipeline *gst.Pipeline, timeout time.Duration) error {
+	defer func() {
+		_ = pipeline.SetState(gst.StateNull)
+	}()
+
+	if err := pipeline.SetState(gst.StatePlaying); err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	return waitForEOS(pipeline, timeout)
+}
+
+// waitForEOS waits for a standalone pipeline to finish
+func waitForEOS(pipeline *gst.Pipeline, timeout time.Duration) error {
+	msg := pipeline.GetPipelineBus().TimedPopFiltered(gst.ClockTime(timeout), gst.MessageEOS|gst.MessageError)
+	switch {
+	case msg == nil:
+		return errors.ErrGstPipelineError(fmt.Errorf("%s timed out", pipeline.GetName()))
+	case msg.Type() == gst.MessageError:
+		return errors.ErrGstPipelineError(msg.ParseError())
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFastStartTimeout(t *testing.T) {
+	require.Equal(t, fastStartMinTimeout, fastStartTimeout(0))
+	require.Equal(t, fastStartMinTimeout, fastStartTimeout(fastStartMinThroughput-1))
+
+	// a 6000MiB recording gets 10 more minutes
+	require.Equal(t, fastStartMinTimeout+10*time.Minute, fastStartTimeout(600*fastStartMinThroughput))
+}
@@ -81,6 +81,16 @@ func (s *FileSink) UploadManifest(filepath string) (string, bool, error) {
 }
 
 func (s *FileSink) Close() error {
+	if s.IsFragmented() && s.FastStart {
+		start := time.Now()
+		// the fragmented file is still playable, upload it as is if remuxing fails
+		if err := remuxFastStart(s.LocalFilepath, s.conf.AudioEnabled, s.conf.VideoEnabled); err != nil {
+			logger.Warnw("fast start remux failed", err)
+		} else {
+			logger.Debugw("fast start remux completed", "duration", time.Since(start))
+		}
+	}
+
 	start := time.Now()
 	location, size, err := s.Upload(s.LocalFilepath, s.StorageFilepath, s.OutputType, false)
 	if err != nil {
@@ -0,0 +1,472 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+const (
+	// trun and tfhd flags
+	trunDataOffsetPresent        = 0x000001
+	trunFirstSampleFlagsPresent  = 0x000004
+	trunSampleDurationPresent    = 0x000100
+	trunSampleSizePresent        = 0x000200
+	trunSampleFlagsPresent       = 0x000400
+	trunCompositionOffsetPresent = 0x000800
+	tfhdBaseDataOffsetPresent    = 0x000001
+	tfhdSampleDescriptionPresent = 0x000002
+	tfhdDefaultDurationPresent   = 0x000008
+	tfhdDefaultSizePresent       = 0x000010
+	tfhdDefaultFlagsPresent      = 0x000020
+
+	sampleIsNonSyncSample = 0x00010000
+)
+
+type fmp4Track struct {
+	id              uint32
+	handler         string
+	timescale       uint32
+	defaultDuration uint32
+	defaultFlags    uint32
+}
+
+// fmp4Fragment is a moof box and its mdat box, along with any boxes preceding them
+type fmp4Fragment struct {
+	data      []byte
+	trackID   uint32
+	startTime time.Duration // decode time of the first sample
+	duration  time.Duration
+	sync      bool // the first sample is a sync sample
+}
+
+func (f *fmp4Fragment) endTime() time.Duration {
+	return f.startTime + f.duration
+}
+
+// fmp4Reader splits the stream written by mp4mux in streamable fragmented mode into its init segment
+// (ftyp and moov boxes), and its fragments. The fragments are not modified, which keeps their
+// decode times and sequence numbers continuous across segments.
+type fmp4Reader struct {
+	buf     []byte
+	init    []byte
+	pending []byte
+	moof    *fmp4Fragment
+
+	tracks    map[uint32]*fmp4Track
+	mainTrack uint32
+
+	onInit     func(init []byte) error
+	onFragment func(f *fmp4Fragment) error
+}
+
+func newFMP4Reader(onInit func([]byte) error, onFragment func(*fmp4Fragment) error) *fmp4Reader {
+	return &fmp4Reader{
+		tracks:     make(map[uint32]*fmp4Track),
+		onInit:     onInit,
+		onFragment: onFragment,
+	}
+}
+
+func (r *fmp4Reader) Write(p []byte) error {
+	r.buf = append(r.buf, p...)
+
+	offset := 0
+	defer func() {
+		r.buf = append(r.buf[:0], r.buf[offset:]...)
+	}()
+
+	for {
+		boxType, headerSize, size, err := readBoxHeader(r.buf[offset:])
+		if err != nil {
+			return err
+		}
+		if size == 0 || uint64(len(r.buf)-offset) < size {
+			// incomplete box
+			return nil
+		}
+
+		box := r.buf[offset : offset+int(size)]
+		offset += int(size)
+
+		if err = r.handleBox(boxType, box, box[headerSize:]); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *fmp4Reader) handleBox(boxType string, box, payload []byte) error {
+	switch boxType {
+	case "ftyp":
+		r.init = append(r.init, box...)
+
+	case "moov":
+		if err := r.parseMoov(payload); err != nil {
+			return err
+		}
+		r.init = append(r.init, box...)
+		return r.onInit(r.init)
+
+	case "moof":
+		if len(r.tracks) == 0 {
+			return fmt.Errorf("moof box before moov box")
+		}
+		moof, err := r.parseMoof(payload)
+		if err != nil {
+			return err
+		}
+		r.moof = moof
+		r.pending = append(r.pending, box...)
+
+	case "mdat":
+		if r.moof == nil {
+			return fmt.Errorf("mdat box without moof box")
+		}
+		f := r.moof
+		f.data = append(r.pending, box...)
+		r.moof = nil
+		r.pending = nil
+		return r.onFragment(f)
+
+	case "free", "skip", "mfra":
+		// padding and random access index, not needed by segments
+
+	default:
+		// styp, sidx, prft and emsg boxes are kept with the following fragment
+		r.pending = append(r.pending, box...)
+	}
+
+	return nil
+}
+
+func (r *fmp4Reader) parseMoov(payload []byte) error {
+	err := forEachBox(payload, func(boxType string, b []byte) error {
+		switch boxType {
+		case "trak":
+			track, err := parseTrak(b)
+			if err != nil {
+				return err
+			}
+			if existing, ok := r.tracks[track.id]; ok {
+				// trex boxes might come first
+				existing.handler = track.handler
+				existing.timescale = track.timescale
+			} else {
+				r.tracks[track.id] = track
+			}
+			if r.mainTrack == 0 || (track.handler == "vide" && r.tracks[r.mainTrack].handler != "vide") {
+				// segments are cut at video keyframes
+				r.mainTrack = track.id
+			}
+
+		case "mvex":
+			return forEachBox(b, func(boxType string, b []byte) error {
+				if boxType != "trex" {
+					return nil
+				}
+				if len(b) < 24 {
+					return fmt.Errorf("invalid trex box")
+				}
+				id := binary.BigEndian.Uint32(b[4:])
+				track, ok := r.tracks[id]
+				if !ok {
+					track = &fmp4Track{id: id}
+					r.tracks[id] = track
+				}
+				track.defaultDuration = binary.BigEndian.Uint32(b[12:])
+				track.defaultFlags = binary.BigEndian.Uint32(b[20:])
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, track := range r.tracks {
+		if track.timescale == 0 {
+			return fmt.Errorf("missing timescale for track %d", track.id)
+		}
+	}
+	if r.mainTrack == 0 {
+		return fmt.Errorf("no tracks found")
+	}
+	return nil
+}
+
+func parseTrak(payload []byte) (*fmp4Track, error) {
+	track := &fmp4Track{}
+	err := forEachBox(payload, func(boxType string, b []byte) error {
+		switch boxType {
+		case "tkhd":
+			// version 1 uses 64 bit creation and modification times
+			offset := 12
+			if len(b) > 0 && b[0] == 1 {
+				offset = 20
+			}
+			if len(b) < offset+4 {
+				return fmt.Errorf("invalid tkhd box")
+			}
+			track.id = binary.BigEndian.Uint32(b[offset:])
+
+		case "mdia":
+			return forEachBox(b, func(boxType string, b []byte) error {
+				switch boxType {
+				case "mdhd":
+					offset := 12
+					if len(b) > 0 && b[0] == 1 {
+						offset = 20
+					}
+					if len(b) < offset+4 {
+						return fmt.Errorf("invalid mdhd box")
+					}
+					track.timescale = binary.BigEndian.Uint32(b[offset:])
+				case "hdlr":
+					if len(b) < 12 {
+						return fmt.Errorf("invalid hdlr box")
+					}
+					track.handler = string(b[8:12])
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if track.id == 0 {
+		return nil, fmt.Errorf("missing track id")
+	}
+	return track, nil
+}
+
+// parseMoof returns the timing of the main track's fragment, or the first track's when the main track is missing
+func (r *fmp4Reader) parseMoof(payload []byte) (*fmp4Fragment, error) {
+	var fragment *fmp4Fragment
+	err := forEachBox(payload, func(boxType string, b []byte) error {
+		if boxType != "traf" {
+			return nil
+		}
+		f, err := r.parseTraf(b)
+		if err != nil {
+			return err
+		}
+		if fragment == nil || (f.trackID == r.mainTrack && fragment.trackID != r.mainTrack) {
+			fragment = f
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if fragment == nil {
+		return nil, fmt.Errorf("moof box without traf box")
+	}
+	return fragment, nil
+}
+
+func (r *fmp4Reader) parseTraf(payload []byte) (*fmp4Fragment, error) {
+	var track *fmp4Track
+	var defaultDuration, defaultFlags uint32
+	var decodeTime, duration uint64
+	var sync, first = true, true
+
+	err := forEachBox(payload, func(boxType string, b []byte) error {
+		switch boxType {
+		case "tfhd":
+			if len(b) < 8 {
+				return fmt.Errorf("invalid tfhd box")
+			}
+			flags := binary.BigEndian.Uint32(b) & 0xffffff
+			id := binary.BigEndian.Uint32(b[4:])
+			var ok bool
+			if track, ok = r.tracks[id]; !ok {
+				return fmt.Errorf("unknown track %d", id)
+			}
+			defaultDuration = track.defaultDuration
+			defaultFlags = track.defaultFlags
+
+			offset := 8
+			if flags&tfhdBaseDataOffsetPresent != 0 {
+				offset += 8
+			}
+			if flags&tfhdSampleDescriptionPresent != 0 {
+				offset += 4
+			}
+			if flags&tfhdDefaultDurationPresent != 0 {
+				if len(b) < offset+4 {
+					return fmt.Errorf("invalid tfhd box")
+				}
+				defaultDuration = binary.BigEndian.Uint32(b[offset:])
+				offset += 4
+			}
+			if flags&tfhdDefaultSizePresent != 0 {
+				offset += 4
+			}
+			if flags&tfhdDefaultFlagsPresent != 0 {
+				if len(b) < offset+4 {
+					return fmt.Errorf("invalid tfhd box")
+				}
+				defaultFlags = binary.BigEndian.Uint32(b[offset:])
+			}
+
+		case "tfdt":
+			if len(b) < 8 {
+				return fmt.Errorf("invalid tfdt box")
+			}
+			if b[0] == 1 {
+				if len(b) < 12 {
+					return fmt.Errorf("invalid tfdt box")
+				}
+				decodeTime = binary.BigEndian.Uint64(b[4:])
+			} else {
+				decodeTime = uint64(binary.BigEndian.Uint32(b[4:]))
+			}
+
+		case "trun":
+			if track == nil {
+				return fmt.Errorf("trun box before tfhd box")
+			}
+			d, s, err := parseTrun(b, defaultDuration, defaultFlags)
+			if err != nil {
+				return err
+			}
+			if first {
+				sync = s
+				first = false
+			}
+			duration += d
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if track == nil {
+		return nil, fmt.Errorf("traf box without tfhd box")
+	}
+
+	return &fmp4Fragment{
+		trackID:   track.id,
+		startTime: mediaTime(decodeTime, track.timescale),
+		duration:  mediaTime(duration, track.timescale),
+		sync:      sync,
+	}, nil
+}
+
+// parseTrun returns the total duration of the samples, and whether the first sample is a sync sample
+func parseTrun(b []byte, defaultDuration, defaultFlags uint32) (uint64, bool, error) {
+	if len(b) < 8 {
+		return 0, false, fmt.Errorf("invalid trun box")
+	}
+	flags := binary.BigEndian.Uint32(b) & 0xffffff
+	count := binary.BigEndian.Uint32(b[4:])
+
+	offset := 8
+	if flags&trunDataOffsetPresent != 0 {
+		offset += 4
+	}
+	firstFlags := defaultFlags
+	if flags&trunFirstSampleFlagsPresent != 0 {
+		if len(b) < offset+4 {
+			return 0, false, fmt.Errorf("invalid trun box")
+		}
+		firstFlags = binary.BigEndian.Uint32(b[offset:])
+		offset += 4
+	}
+
+	entrySize := 0
+	for _, f := range []uint32{trunSampleDurationPresent, trunSampleSizePresent, trunSampleFlagsPresent, trunCompositionOffsetPresent} {
+		if flags&f != 0 {
+			entrySize += 4
+		}
+	}
+	if uint64(len(b)-offset) < uint64(count)*uint64(entrySize) {
+		return 0, false, fmt.Errorf("invalid trun box")
+	}
+
+	var duration uint64
+	for i := uint32(0); i < count; i++ {
+		entry := offset
+		if flags&trunSampleDurationPresent != 0 {
+			duration += uint64(binary.BigEndian.Uint32(b[entry:]))
+			entry += 4
+		} else {
+			duration += uint64(defaultDuration)
+		}
+		if flags&trunSampleSizePresent != 0 {
+			entry += 4
+		}
+		if i == 0 && flags&trunSampleFlagsPresent != 0 && flags&trunFirstSampleFlagsPresent == 0 {
+			firstFlags = binary.BigEndian.Uint32(b[entry:])
+		}
+		offset += entrySize
+	}
+
+	return duration, count > 0 && firstFlags&sampleIsNonSyncSample == 0, nil
+}
+
+// readBoxHeader returns the type, header size and total size of the box at the start of b.
+// The size is 0 if the header is incomplete.
+func readBoxHeader(b []byte) (string, int, uint64, error) {
+	if len(b) < 8 {
+		return "", 0, 0, nil
+	}
+	size := uint64(binary.BigEndian.Uint32(b))
+	boxType := string(b[4:8])
+	headerSize := 8
+
+	switch size {
+	case 0:
+		return "", 0, 0, fmt.Errorf("unbounded %s box", boxType)
+	case 1:
+		if len(b) < 16 {
+			return "", 0, 0, nil
+		}
+		size = binary.BigEndian.Uint64(b[8:])
+		headerSize = 16
+	}
+	if size < uint64(headerSize) {
+		return "", 0, 0, fmt.Errorf("invalid %s box size %d", boxType, size)
+	}
+	return boxType, headerSize, size, nil
+}
+
+// forEachBox calls f with the payload of each child box. Full box payloads start with their version and flags.
+func forEachBox(b []byte, f func(boxType string, payload []byte) error) error {
+	for len(b) > 0 {
+		boxType, headerSize, size, err := readBoxHeader(b)
+		if err != nil {
+			return err
+		}
+		if size == 0 || uint64(len(b)) < size {
+			return fmt.Errorf("truncated %s box", boxType)
+		}
+		if err = f(boxType, b[headerSize:size]); err != nil {
+			return err
+		}
+		b = b[size:]
+	}
+	return nil
+}
+
+func mediaTime(t uint64, timescale uint32) time.Duration {
+	ts := uint64(timescale)
+	return time.Duration(t/ts)*time.Second + time.Duration(t%ts)*time.Second/time.Duration(ts)
+}
@@ -0,0 +1,229 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBox(boxType string, payload string) []byte {
+	b := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(b, uint32(8+len(payload)))
+	copy(b[4:], boxType)
+	return append(b, payload...)
+}
+
+func newTestFullBox(boxType string, version byte, flags uint32, payload []byte) []byte {
+	b := binary.BigEndian.AppendUint32(nil, uint32(version)<<24|flags)
+	return newTestBox(boxType, string(append(b, payload...)))
+}
+
+func newTestTrak(id uint32, handler string, timescale uint32) []byte {
+	tkhd := newTestFullBox("tkhd", 0, 0, binary.BigEndian.AppendUint32(make([]byte, 8), id))
+	mdhd := newTestFullBox("mdhd", 0, 0, binary.BigEndian.AppendUint32(make([]byte, 8), timescale))
+	hdlr := newTestFullBox("hdlr", 0, 0, append(make([]byte, 4), handler...))
+	mdia := newTestBox("mdia", string(append(mdhd, hdlr...)))
+	return newTestBox("trak", string(append(tkhd, mdia...)))
+}
+
+func newTestTrex(id, defaultDuration uint32) []byte {
+	b := binary.BigEndian.AppendUint32(nil, id)
+	b = binary.BigEndian.AppendUint32(b, 1)
+	b = binary.BigEndian.AppendUint32(b, defaultDuration)
+	b = binary.BigEndian.AppendUint32(b, 0)
+	b = binary.BigEndian.AppendUint32(b, 0)
+	return newTestFullBox("trex", 0, 0, b)
+}
+
+// newTestFragment returns a moof and mdat with samples of the default duration
+func newTestFragment(seq, id uint32, decodeTime uint64, samples uint32, sync bool) []byte {
+	mfhd := newTestFullBox("mfhd", 0, 0, binary.BigEndian.AppendUint32(nil, seq))
+	tfhd := newTestFullBox("tfhd", 0, 0, binary.BigEndian.AppendUint32(nil, id))
+	tfdt := newTestFullBox("tfdt", 1, 0, binary.BigEndian.AppendUint64(nil, decodeTime))
+	firstFlags := uint32(sampleIsNonSyncSample)
+	if sync {
+		firstFlags = 0
+	}
+	trun := binary.BigEndian.AppendUint32(nil, samples)
+	trun = binary.BigEndian.AppendUint32(trun, firstFlags)
+	traf := newTestBox("traf", string(append(append(tfhd, tfdt...), newTestFullBox("trun", 0, trunFirstSampleFlagsPresent, trun)...)))
+	moof := newTestBox("moof", string(append(mfhd, traf...)))
+	return append(moof, newTestBox("mdat", fmt.Sprintf("samples %d", seq))...)
+}
+
+// newTestStream returns 30fps video with 1s fragments and keyframes every 2s, and 48kHz audio lagging behind
+// by one fragment
+func newTestStream() ([]byte, [][]byte) {
+	header := newTestBox("ftyp", "iso6")
+	header = append(header, newTestBox("moov", string(append(append(
+		newTestTrak(1, "vide", 90000),
+		newTestTrak(2, "soun", 48000)...),
+		newTestBox("mvex", string(append(newTestTrex(1, 3000), newTestTrex(2, 1024)...)))...,
+	)))...)
+
+	var fragments [][]byte
+	seq := uint32(1)
+	for i := 0; i < 5; i++ {
+		video := newTestFragment(seq, 1, uint64(i)*90000, 30, i%2 == 0)
+		audio := newTestFragment(seq+1, 2, uint64(i)*48000, 46, true)
+		seq += 2
+		fragments = append(fragments, video, audio)
+	}
+	// swap each audio fragment with the next video fragment
+	for i := 1; i+1 < len(fragments); i += 2 {
+		fragments[i], fragments[i+1] = fragments[i+1], fragments[i]
+	}
+	return header, fragments
+}
+
+// writeTestStream writes the stream in uneven chunks, and closes the segmenter
+func writeTestStream(t *testing.T, segmenter *fmp4Segmenter, header []byte, fragments [][]byte) {
+	stream := header
+	for _, f := range fragments {
+		stream = append(stream, f...)
+	}
+	for len(stream) > 0 {
+		n := min(37, len(stream))
+		require.NoError(t, segmenter.Write(stream[:n]))
+		stream = stream[n:]
+	}
+	require.NoError(t, segmenter.Close())
+}
+
+func requireTestFile(t *testing.T, filepath string, fragments [][]byte, indexes []int) {
+	var expected []byte
+	for _, idx := range indexes {
+		expected = append(expected, fragments[idx]...)
+	}
+	b, err := os.ReadFile(filepath)
+	require.NoError(t, err)
+	require.Equal(t, expected, b, filepath)
+}
+
+func TestFMP4Segmenter(t *testing.T) {
+	dir := t.TempDir()
+
+	var init []byte
+	var opened, closed []time.Duration
+	segmenter := newFMP4Segmenter(fmp4SegmenterConfig{
+		segmentDuration: 2 * time.Second,
+		segmentPath: func(index int, _ time.Duration) string {
+			return path.Join(dir, fmt.Sprintf("playlist_%05d.m4s", index))
+		},
+		onInit: func(b []byte) error {
+			init = b
+			return nil
+		},
+		onSegmentOpened: func(_ string, startTime time.Duration) error {
+			opened = append(opened, startTime)
+			return nil
+		},
+		onSegmentClosed: func(_ string, endTime time.Duration) error {
+			closed = append(closed, endTime)
+			return nil
+		},
+	})
+
+	header, fragments := newTestStream()
+	writeTestStream(t, segmenter, header, fragments)
+
+	require.Equal(t, header, init)
+	require.Equal(t, []time.Duration{0, 2 * time.Second, 4 * time.Second}, opened)
+	require.Equal(t, []time.Duration{2 * time.Second, 4 * time.Second, 5 * time.Second}, closed)
+
+	// fragments are written to the segment covering their start time, unmodified
+	expected := [][]int{{0, 1, 2, 4}, {3, 5, 6, 8}, {7, 9}}
+	for i, indexes := range expected {
+		requireTestFile(t, path.Join(dir, fmt.Sprintf("playlist_%05d.m4s", i)), fragments, indexes)
+	}
+}
+
+func TestFMP4SegmenterParts(t *testing.T) {
+	dir := t.TempDir()
+
+	type part struct {
+		endTime     time.Duration
+		independent bool
+		nextPart    string
+	}
+	var events []string
+	var parts []part
+	segmenter := newFMP4Segmenter(fmp4SegmenterConfig{
+		segmentDuration: 2 * time.Second,
+		segmentPath: func(index int, _ time.Duration) string {
+			return path.Join(dir, fmt.Sprintf("playlist_%05d.m4s", index))
+		},
+		partPath: func(index int) string {
+			return path.Join(dir, fmt.Sprintf("playlist_part%05d.m4s", index))
+		},
+		onInit: func(_ []byte) error {
+			return nil
+		},
+		onSegmentOpened: func(filepath string, _ time.Duration) error {
+			events = append(events, "open "+path.Base(filepath))
+			return nil
+		},
+		onSegmentClosed: func(filepath string, _ time.Duration) error {
+			events = append(events, "close "+path.Base(filepath))
+			return nil
+		},
+		onPartOpened: func(filepath string, _ time.Duration) error {
+			events = append(events, "open "+path.Base(filepath))
+			return nil
+		},
+		onPartClosed: func(filepath string, endTime time.Duration, independent bool, nextPart string) error {
+			events = append(events, "close "+path.Base(filepath))
+			parts = append(parts, part{endTime, independent, path.Base(nextPart)})
+			return nil
+		},
+	})
+
+	header, fragments := newTestStream()
+	writeTestStream(t, segmenter, header, fragments)
+
+	// parts are closed before their segment, and the next part is always known
+	require.Equal(t, []string{
+		"open playlist_00000.m4s", "open playlist_part00000.m4s",
+		"close playlist_part00000.m4s", "open playlist_part00001.m4s",
+		"close playlist_part00001.m4s", "close playlist_00000.m4s", "open playlist_00001.m4s", "open playlist_part00002.m4s",
+		"close playlist_part00002.m4s", "open playlist_part00003.m4s",
+		"close playlist_part00003.m4s", "close playlist_00001.m4s", "open playlist_00002.m4s", "open playlist_part00004.m4s",
+		"close playlist_part00004.m4s", "close playlist_00002.m4s",
+	}, events)
+	require.Equal(t, []part{
+		{time.Second, true, "playlist_part00001.m4s"},
+		{2 * time.Second, false, "playlist_part00002.m4s"},
+		{3 * time.Second, true, "playlist_part00003.m4s"},
+		{4 * time.Second, false, "playlist_part00004.m4s"},
+		{5 * time.Second, true, "playlist_part00005.m4s"},
+	}, parts)
+
+	// each segment is made of its parts, lagging audio fragments are added to the current part
+	expected := [][]int{{0}, {1, 2}, {3, 4}, {5, 6}, {7, 8, 9}}
+	for i, indexes := range expected {
+		requireTestFile(t, path.Join(dir, fmt.Sprintf("playlist_part%05d.m4s", i)), fragments, indexes)
+	}
+	expected = [][]int{{0, 1, 2}, {3, 4, 5, 6}, {7, 8, 9}}
+	for i, indexes := range expected {
+		requireTestFile(t, path.Join(dir, fmt.Sprintf("playlist_%05d.m4s", i)), fragments, indexes)
+	}
+}
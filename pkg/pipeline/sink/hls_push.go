@@ -0,0 +1,194 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/frostbyte73/core"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/egress/pkg/config"
+	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/egress/pkg/gstreamer"
+	"github.com/livekit/egress/pkg/stats"
+	"github.com/livekit/egress/pkg/types"
+	"github.com/livekit/protocol/logger"
+)
+
+const (
+	hlsPushRequestTimeout = 10 * time.Second
+	hlsPushMaxAttempts    = 4
+	hlsPushMinBackoff     = 500 * time.Millisecond
+	hlsPushMaxBackoff     = 4 * time.Second
+)
+
+// HLSPushSink segments the stream like a SegmentSink, and uploads segments and playlists to an http origin
+type HLSPushSink struct {
+	*SegmentSink
+}
+
+func newHLSPushSink(
+	p *gstreamer.Pipeline,
+	conf *config.PipelineConfig,
+	o *config.StreamConfig,
+	callbacks *gstreamer.Callbacks,
+	monitor *stats.HandlerMonitor,
+) (*HLSPushSink, error) {
+	var stream *config.Stream
+	o.Streams.Range(func(_, s any) bool {
+		stream = s.(*config.Stream)
+		return false
+	})
+	if stream == nil || o.Segments == nil {
+		return nil, errors.ErrInvalidInput("hls push url")
+	}
+
+	pusher := newHLSPusher(o.Segments.Push, stream.RedactedUrl, func(err error) {
+		logger.Warnw("hls push failed", err, "url", stream.RedactedUrl)
+		callbacks.OnStreamFailed(types.EgressTypeHLSPush, stream, err)
+	})
+
+	segmentSink, err := buildSegmentSink(p, conf, o.Segments, pusher, callbacks, monitor)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HLSPushSink{
+		SegmentSink: segmentSink,
+	}, nil
+}
+
+// UploadManifest is a no-op, the manifest is not pushed to the origin
+func (s *HLSPushSink) UploadManifest(_ string) (string, bool, error) {
+	return "", false, nil
+}
+
+// hlsPusher uploads files to an hls origin, retrying with backoff
+type hlsPusher struct {
+	conf        *config.HLSPushConfig
+	redactedUrl string
+	httpClient  *http.Client
+	onFailure   func(error)
+
+	disabled atomic.Bool
+	failed   core.Fuse
+}
+
+func newHLSPusher(conf *config.HLSPushConfig, redactedUrl string, onFailure func(error)) *hlsPusher {
+	return &hlsPusher{
+		conf:        conf,
+		redactedUrl: redactedUrl,
+		httpClient:  &http.Client{Timeout: hlsPushRequestTimeout},
+		onFailure:   onFailure,
+	}
+}
+
+func (u *hlsPusher) DisableUploads() {
+	u.disabled.Store(true)
+}
+
+func (u *hlsPusher) Upload(
+	localFilepath, storageFilepath string,
+	outputType types.OutputType,
+	deleteAfterUpload bool,
+) (string, int64, error) {
+	if deleteAfterUpload {
+		defer func() {
+			_ = os.Remove(localFilepath)
+		}()
+	}
+	if u.disabled.Load() || u.failed.IsBroken() {
+		// the stream has already failed, and the error has been reported
+		return "", 0, nil
+	}
+
+	data, err := os.ReadFile(localFilepath)
+	if err != nil {
+		return "", 0, err
+	}
+
+	backoff := hlsPushMinBackoff
+	for attempt := 1; ; attempt++ {
+		err = u.push(u.conf.FileUrl(storageFilepath), outputType, data)
+		if err == nil {
+			return storageFilepath, int64(len(data)), nil
+		}
+		if attempt == hlsPushMaxAttempts || !isRetryablePushError(err) {
+			break
+		}
+
+		logger.Debugw("retrying hls push", "error", err, "url", u.redactedUrl, "file", storageFilepath, "attempt", attempt)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, hlsPushMaxBackoff)
+	}
+
+	// the stream is failed by the controller, which only fails the egress if no other outputs remain
+	u.failed.Once(func() {
+		u.onFailure(err)
+	})
+	return "", 0, nil
+}
+
+func (u *hlsPusher) push(fileUrl string, outputType types.OutputType, data []byte) error {
+	req, err := http.NewRequest(u.conf.Method, fileUrl, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	for key, values := range u.conf.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", string(outputType))
+
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &hlsPushError{statusCode: resp.StatusCode, status: resp.Status}
+	}
+	return nil
+}
+
+type hlsPushError struct {
+	statusCode int
+	status     string
+}
+
+func (e *hlsPushError) Error() string {
+	return fmt.Sprintf("hls origin returned %s", e.status)
+}
+
+// isRetryablePushError returns false for client errors, which will fail again
+func isRetryablePushError(err error) bool {
+	var pushErr *hlsPushError
+	if !errors.As(err, &pushErr) {
+		return true
+	}
+	switch pushErr.statusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	default:
+		return pushErr.statusCode >= 500
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/egress/pkg/config"
+	"github.com/livekit/egress/pkg/types"
+)
+
+// testHLSOrigin stores pushed files, and fails the first n requests with the given status
+type testHLSOrigin struct {
+	failStatus int
+	failCount  atomic.Int32
+
+	mu       sync.Mutex
+	files    map[string]string
+	headers  map[string]http.Header
+	requests int
+}
+
+func (o *testHLSOrigin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.requests++
+
+	if o.failCount.Dec() >= 0 {
+		w.WriteHeader(o.failStatus)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	key := r.Method + " " + r.URL.RequestURI()
+	o.files[key] = string(body)
+	o.headers[key] = r.Header.Clone()
+	w.WriteHeader(http.StatusCreated)
+}
+
+func newTestHLSOrigin(t *testing.T, failStatus int, failCount int32) (*testHLSOrigin, *httptest.Server) {
+	origin := &testHLSOrigin{
+		failStatus: failStatus,
+		files:      make(map[string]string),
+		headers:    make(map[string]http.Header),
+	}
+	origin.failCount.Store(failCount)
+	server := httptest.NewServer(origin)
+	t.Cleanup(server.Close)
+	return origin, server
+}
+
+func writeTestFile(t *testing.T, name, content string) string {
+	filepath := path.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(filepath, []byte(content), 0644))
+	return filepath
+}
+
+func TestHLSPusher(t *testing.T) {
+	origin, server := newTestHLSOrigin(t, http.StatusServiceUnavailable, 1)
+	rawUrl := strings.Replace(server.URL, "http://", "hls+http://user:pass@", 1) + "/live/stream.m3u8"
+	push, err := config.ParseHLSPushUrl(rawUrl)
+	require.NoError(t, err)
+
+	var failures atomic.Int32
+	pusher := newHLSPusher(push, "", func(error) { failures.Inc() })
+
+	// the first request is retried after a 503
+	segment := writeTestFile(t, "stream_00000.ts", "segment")
+	location, size, err := pusher.Upload(segment, "stream_00000.ts", types.OutputTypeTS, true)
+	require.NoError(t, err)
+	require.Equal(t, "stream_00000.ts", location)
+	require.Equal(t, int64(7), size)
+	require.NoFileExists(t, segment)
+
+	playlist := writeTestFile(t, "stream.m3u8", "#EXTM3U")
+	_, _, err = pusher.Upload(playlist, "stream.m3u8", types.OutputTypeHLS, false)
+	require.NoError(t, err)
+	require.FileExists(t, playlist)
+
+	origin.mu.Lock()
+	defer origin.mu.Unlock()
+	require.Equal(t, 3, origin.requests)
+	require.Equal(t, "segment", origin.files["PUT /live/stream_00000.ts"])
+	require.Equal(t, "#EXTM3U", origin.files["PUT /live/stream.m3u8"])
+	header := origin.headers["PUT /live/stream_00000.ts"]
+	require.Equal(t, "Basic dXNlcjpwYXNz", header.Get("Authorization"))
+	require.Equal(t, string(types.OutputTypeTS), header.Get("Content-Type"))
+	require.Equal(t, string(types.OutputTypeHLS), origin.headers["PUT /live/stream.m3u8"].Get("Content-Type"))
+	require.Zero(t, failures.Load())
+}
+
+func TestHLSPusherFileParam(t *testing.T) {
+	origin, server := newTestHLSOrigin(t, 0, 0)
+	rawUrl := strings.Replace(server.URL, "http://", "hls+http://", 1) +
+		"/http_upload_hls?cid=streamkey&copy=0&file=stream.m3u8&method=post&header=X-Api-Key:secret"
+	push, err := config.ParseHLSPushUrl(rawUrl)
+	require.NoError(t, err)
+	require.Equal(t, "stream.m3u8", push.Playlist)
+
+	pusher := newHLSPusher(push, "", func(error) {})
+	segment := writeTestFile(t, "stream_00000.ts", "segment")
+	_, _, err = pusher.Upload(segment, "stream_00000.ts", types.OutputTypeTS, true)
+	require.NoError(t, err)
+
+	origin.mu.Lock()
+	defer origin.mu.Unlock()
+	require.Equal(t, "segment", origin.files["POST /http_upload_hls?cid=streamkey&copy=0&file=stream_00000.ts"])
+	header := origin.headers["POST /http_upload_hls?cid=streamkey&copy=0&file=stream_00000.ts"]
+	require.Empty(t, header.Get("Authorization"))
+	require.Equal(t, "secret", header.Get("X-Api-Key"))
+}
+
+func TestHLSPusherFailure(t *testing.T) {
+	origin, server := newTestHLSOrigin(t, http.StatusForbidden, 10)
+	rawUrl := strings.Replace(server.URL, "http://", "hls+http://token@", 1) + "/live/stream.m3u8"
+	push, err := config.ParseHLSPushUrl(rawUrl)
+	require.NoError(t, err)
+
+	var failures atomic.Int32
+	pusher := newHLSPusher(push, "", func(error) { failures.Inc() })
+
+	// client errors are not retried, and fail the stream instead of the egress
+	segment := writeTestFile(t, "stream_00000.ts", "segment")
+	_, _, err = pusher.Upload(segment, "stream_00000.ts", types.OutputTypeTS, true)
+	require.NoError(t, err)
+	require.NoFileExists(t, segment)
+	require.Equal(t, int32(1), failures.Load())
+
+	// once failed, nothing else is pushed
+	segment = writeTestFile(t, "stream_00001.ts", "segment")
+	_, _, err = pusher.Upload(segment, "stream_00001.ts", types.OutputTypeTS, true)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failures.Load())
+
+	origin.mu.Lock()
+	defer origin.mu.Unlock()
+	require.Equal(t, 1, origin.requests)
+}
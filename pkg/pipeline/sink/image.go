@@ -16,6 +16,7 @@ package sink
 
 import (
 	"fmt"
+	"os"
 	"path"
 	"strings"
 	"time"
@@ -45,13 +46,25 @@ type ImageSink struct {
 	startTime        time.Time
 	startRunningTime uint64
 
+	sprites     *spriteSheetWriter
+	spriteStart time.Time
+
+	// perceptual hash of the previous upload, used to skip duplicates
+	lastUploadHash uint64
+	lastUploadPath string
+
+	// set by SceneChanged, and consumed by NewImage. Both are called from the pipeline's bus thread
+	sceneChangeTimestamp uint64
+	sceneChangeScore     float64
+
 	createdImages chan *imageUpdate
 	done          core.Fuse
 }
 
 type imageUpdate struct {
-	timestamp uint64
-	filename  string
+	timestamp  uint64
+	filename   string
+	sceneScore float64 // set if the image was captured because the scene changed
 }
 
 // covers frame bursts that outpace the capture interval: videorate's
@@ -62,6 +75,14 @@ func imageQueueCapacity(maxUploadQueue int, captureInterval uint32) int {
 	return max((maxUploadQueue*60)/int(captureInterval), minPendingUploads)
 }
 
+// minCaptureInterval returns the shortest time between images, in seconds
+func minCaptureInterval(o *config.ImageConfig) uint32 {
+	if o.IsSceneChange() {
+		return max(uint32(o.MinCaptureInterval/time.Second), 1)
+	}
+	return o.CaptureInterval
+}
+
 func newImageSink(
 	p *gstreamer.Pipeline,
 	conf *config.PipelineConfig,
@@ -82,7 +103,7 @@ func newImageSink(
 		return nil, err
 	}
 
-	return &ImageSink{
+	s := &ImageSink{
 		base: &base{
 			bin: imageBin,
 		},
@@ -92,8 +113,16 @@ func newImageSink(
 
 		conf:          conf,
 		callbacks:     callbacks,
-		createdImages: make(chan *imageUpdate, imageQueueCapacity(conf.MaxUploadQueue, o.CaptureInterval)),
-	}, nil
+		createdImages: make(chan *imageUpdate, imageQueueCapacity(conf.MaxUploadQueue, minCaptureInterval(o))),
+	}
+	if o.IsSpriteSheet() {
+		s.sprites = newSpriteSheetWriter(
+			o.LocalDir, o.ImagePrefix, o.OutputType,
+			o.SpriteColumns, o.SpriteRows, time.Duration(o.CaptureInterval)*time.Second, o.Quality,
+		)
+	}
+
+	return s, nil
 }
 
 func (s *ImageSink) Start() error {
@@ -123,7 +152,9 @@ func (s *ImageSink) Start() error {
 }
 
 func (s *ImageSink) handleNewImage(update *imageUpdate) error {
-	s.ImagesInfo.ImageCount++
+	if s.sprites != nil {
+		return s.handleSpriteFrame(update)
+	}
 
 	filename := update.filename
 	ts := s.getImageTime(update.timestamp)
@@ -145,18 +176,119 @@ func (s *ImageSink) handleNewImage(update *imageUpdate) error {
 
 	imageStoragePath := path.Join(s.StorageDir, filename)
 
+	var hash uint64
+	var hashed bool
+	if s.Dedupe {
+		var duplicate bool
+		hash, hashed, duplicate = s.checkDuplicate(imageLocalPath)
+		if duplicate {
+			_ = os.Remove(imageLocalPath)
+			if s.conf.Manifest != nil {
+				s.conf.Manifest.AddDuplicateImage(imageStoragePath, ts, s.lastUploadPath)
+			}
+			return nil
+		}
+	}
+
+	if s.EmbedMetadata {
+		if err := embedImageMetadata(imageLocalPath, s.OutputType, s.getImageMetadata(ts)); err != nil {
+			logger.Warnw("failed to embed image metadata", err)
+		}
+	}
+
+	s.ImagesInfo.ImageCount++
+
 	location, _, err := s.Upload(imageLocalPath, imageStoragePath, s.OutputType, true)
 	if err != nil {
 		return err
 	}
+	if hashed {
+		s.lastUploadHash = hash
+		s.lastUploadPath = imageStoragePath
+	}
 
 	if s.conf.Manifest != nil {
-		s.conf.Manifest.AddImage(imageStoragePath, ts, location)
+		if update.sceneScore > 0 {
+			s.conf.Manifest.AddSceneChangeImage(imageStoragePath, ts, location, update.sceneScore)
+		} else {
+			s.conf.Manifest.AddImage(imageStoragePath, ts, location)
+		}
+	}
+
+	return nil
+}
+
+// checkDuplicate returns the perceptual hash of an image, and whether it matches the previous upload.
+// Images which cannot be hashed are uploaded.
+func (s *ImageSink) checkDuplicate(filepath string) (uint64, bool, bool) {
+	img, err := decodeImage(filepath)
+	if err != nil {
+		logger.Warnw("failed to decode image for deduplication", err)
+		return 0, false, false
+	}
+
+	hash := perceptualHash(img)
+	duplicate := s.lastUploadPath != "" && hashDistance(hash, s.lastUploadHash) <= s.DedupeThreshold
+	return hash, true, duplicate
+}
+
+func (s *ImageSink) getImageMetadata(ts time.Time) *imageMetadata {
+	md := &imageMetadata{
+		CaptureTime:         ts,
+		EgressID:            s.conf.Info.EgressId,
+		RoomName:            s.conf.Info.RoomName,
+		ParticipantIdentity: s.conf.Identity,
+		TrackID:             s.conf.VideoTrackID,
+	}
+	if md.TrackID == "" {
+		md.TrackID = s.conf.TrackID
+	}
+	return md
+}
+
+// handleSpriteFrame adds a frame to the current sprite sheet, and uploads the sheet once it is full
+func (s *ImageSink) handleSpriteFrame(update *imageUpdate) error {
+	ts := s.getImageTime(update.timestamp)
+	if s.spriteStart.IsZero() {
+		s.spriteStart = ts
+	}
+
+	framePath := path.Join(s.LocalDir, update.filename)
+	filename, err := s.sprites.AddFrame(framePath, ts.Sub(s.startTime))
+	// frames are only uploaded as part of a sheet
+	_ = os.Remove(framePath)
+	if err != nil || filename == "" {
+		return err
 	}
 
+	return s.uploadSpriteSheet(filename)
+}
+
+func (s *ImageSink) uploadSpriteSheet(filename string) error {
+	s.ImagesInfo.ImageCount++
+
+	storagePath := path.Join(s.StorageDir, filename)
+	location, _, err := s.Upload(path.Join(s.LocalDir, filename), storagePath, s.OutputType, true)
+	if err != nil {
+		return err
+	}
+	if s.conf.Manifest != nil {
+		s.conf.Manifest.AddImage(storagePath, s.spriteStart, location)
+	}
+	s.spriteStart = time.Time{}
 	return nil
 }
 
+func (s *ImageSink) uploadThumbnailTrack() (string, error) {
+	filename, err := s.sprites.WriteVTT()
+	if err != nil {
+		return "", err
+	}
+
+	location, _, err := s.Upload(path.Join(s.LocalDir, filename), path.Join(s.StorageDir, filename), types.OutputTypeVTT, false)
+	return location, err
+}
+
 func (s *ImageSink) getImageTime(pts uint64) time.Time {
 	if !s.initialized {
 		s.startTime = time.Now()
@@ -174,18 +306,30 @@ func (s *ImageSink) NewImage(filepath string, ts uint64) error {
 
 	filename := filepath[len(s.LocalDir)+1:]
 
-	// never block: this is called from the pipeline's bus thread
-	select {
-	case s.createdImages <- &imageUpdate{
+	update := &imageUpdate{
 		filename:  filename,
 		timestamp: ts,
-	}:
+	}
+	if s.sceneChangeScore > 0 && s.sceneChangeTimestamp == ts {
+		update.sceneScore = s.sceneChangeScore
+		s.sceneChangeScore = 0
+	}
+
+	// never block: this is called from the pipeline's bus thread
+	select {
+	case s.createdImages <- update:
 		return nil
 	default:
 		return errors.ErrUploadQueueFull("image", cap(s.createdImages))
 	}
 }
 
+// SceneChanged records the difference score of the next image, which was captured because the scene changed
+func (s *ImageSink) SceneChanged(ts uint64, score float64) {
+	s.sceneChangeTimestamp = ts
+	s.sceneChangeScore = score
+}
+
 func (s *ImageSink) UploadManifest(filepath string) (string, bool, error) {
 	if s.DisableManifest && !s.conf.Info.BackupStorageUsed {
 		return "", false, nil
@@ -204,5 +348,29 @@ func (s *ImageSink) Close() error {
 	close(s.createdImages)
 	<-s.done.Watch()
 
+	if s.sprites != nil {
+		return s.closeSpriteSheets()
+	}
+	return nil
+}
+
+func (s *ImageSink) closeSpriteSheets() error {
+	filename, err := s.sprites.Flush()
+	if err != nil {
+		return err
+	}
+	if filename != "" {
+		if err = s.uploadSpriteSheet(filename); err != nil {
+			return err
+		}
+	}
+
+	location, err := s.uploadThumbnailTrack()
+	if err != nil {
+		return err
+	}
+	if s.conf.Manifest != nil {
+		s.conf.Manifest.AddFile(path.Join(s.StorageDir, s.ImagePrefix+types.FileExtensionVTT), location)
+	}
 	return nil
 }
@@ -0,0 +1,272 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/egress/pkg/types"
+)
+
+const (
+	exifSoftware = "LiveKit Egress"
+	xmpNamespace = "http://ns.livekit.io/egress/1.0/"
+
+	exifTypeASCII = 2
+	exifTypeLong  = 4
+
+	exifTagImageDescription   = 0x010e
+	exifTagSoftware           = 0x0131
+	exifTagDateTime           = 0x0132
+	exifTagExifIFDPointer     = 0x8769
+	exifTagDateTimeOriginal   = 0x9003
+	exifTagOffsetTimeOriginal = 0x9011
+	exifTagSubSecTimeOriginal = 0x9291
+)
+
+var (
+	jpegExifHeader = []byte("Exif\x00\x00")
+	jpegXMPHeader  = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	pngSignature   = []byte("\x89PNG\r\n\x1a\n")
+)
+
+// imageMetadata identifies the source of a captured image
+type imageMetadata struct {
+	CaptureTime         time.Time
+	EgressID            string
+	RoomName            string
+	ParticipantIdentity string
+	TrackID             string
+}
+
+// embedImageMetadata writes the metadata into a jpeg or png image as EXIF and XMP
+func embedImageMetadata(filepath string, outputType types.OutputType, md *imageMetadata) error {
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return err
+	}
+
+	switch outputType {
+	case types.OutputTypeJPEG:
+		data, err = embedJPEGMetadata(data, md)
+	case types.OutputTypePNG:
+		data, err = embedPNGMetadata(data, md)
+	default:
+		err = errors.ErrNotSupported(fmt.Sprintf("%s metadata", outputType))
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath, data, 0644)
+}
+
+// embedJPEGMetadata inserts APP1 EXIF and XMP segments after the SOI marker and JFIF header
+func embedJPEGMetadata(data []byte, md *imageMetadata) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return nil, errors.New("invalid jpeg")
+	}
+
+	pos := 2
+	if data[2] == 0xff && data[3] == 0xe0 {
+		if len(data) < 6 {
+			return nil, errors.New("invalid jpeg")
+		}
+		pos += 2 + int(binary.BigEndian.Uint16(data[4:6]))
+		if pos > len(data) {
+			return nil, errors.New("invalid jpeg")
+		}
+	}
+
+	exif, err := jpegSegment(0xe1, slices.Concat(jpegExifHeader, buildExif(md)))
+	if err != nil {
+		return nil, err
+	}
+	xmp, err := jpegSegment(0xe1, slices.Concat(jpegXMPHeader, buildXMP(md)))
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(data)+len(exif)+len(xmp))
+	out = append(out, data[:pos]...)
+	out = append(out, exif...)
+	out = append(out, xmp...)
+	return append(out, data[pos:]...), nil
+}
+
+func jpegSegment(marker byte, payload []byte) ([]byte, error) {
+	// the length includes itself
+	if len(payload)+2 > 0xffff {
+		return nil, errors.New("jpeg segment too large")
+	}
+	segment := []byte{0xff, marker, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...), nil
+}
+
+// embedPNGMetadata inserts eXIf and XMP iTXt chunks after the IHDR chunk
+func embedPNGMetadata(data []byte, md *imageMetadata) ([]byte, error) {
+	// signature, then IHDR length, type, 13 bytes of data and crc
+	const ihdrEnd = 8 + 4 + 4 + 13 + 4
+	if len(data) < ihdrEnd || !bytes.Equal(data[:8], pngSignature) || string(data[12:16]) != "IHDR" {
+		return nil, errors.New("invalid png")
+	}
+
+	// keyword, null separator, no compression, empty language tag and translated keyword, then the text
+	itxt := append([]byte("XML:com.adobe.xmp\x00\x00\x00\x00\x00"), buildXMP(md)...)
+
+	out := make([]byte, 0, len(data)+1024)
+	out = append(out, data[:ihdrEnd]...)
+	out = append(out, pngChunk("eXIf", buildExif(md))...)
+	out = append(out, pngChunk("iTXt", itxt)...)
+	return append(out, data[ihdrEnd:]...), nil
+}
+
+func pngChunk(chunkType string, payload []byte) []byte {
+	chunk := make([]byte, 8, 12+len(payload))
+	binary.BigEndian.PutUint32(chunk, uint32(len(payload)))
+	copy(chunk[4:], chunkType)
+	chunk = append(chunk, payload...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+type exifEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+// exifASCII encodes a 7-bit ASCII value, other characters are replaced
+func exifASCII(tag uint16, s string) exifEntry {
+	value := make([]byte, 0, len(s)+1)
+	for _, r := range s {
+		if r < 0x20 || r > 0x7e {
+			r = '?'
+		}
+		value = append(value, byte(r))
+	}
+	value = append(value, 0)
+	return exifEntry{tag: tag, typ: exifTypeASCII, count: uint32(len(value)), value: value}
+}
+
+func exifLong(tag uint16, v uint32) exifEntry {
+	return exifEntry{tag: tag, typ: exifTypeLong, count: 1, value: binary.LittleEndian.AppendUint32(nil, v)}
+}
+
+// buildExif returns a little endian TIFF structure with the capture time and the source IDs. EXIF strings are ASCII,
+// so names which may contain any UTF-8 are only written to XMP.
+func buildExif(md *imageMetadata) []byte {
+	t := md.CaptureTime.UTC()
+	dateTime := t.Format("2006:01:02 15:04:05")
+
+	ifd0 := []exifEntry{
+		exifASCII(exifTagImageDescription, describeSource(md)),
+		exifASCII(exifTagSoftware, exifSoftware),
+		exifASCII(exifTagDateTime, dateTime),
+		exifLong(exifTagExifIFDPointer, 0),
+	}
+	exifIFD := []exifEntry{
+		exifASCII(exifTagDateTimeOriginal, dateTime),
+		exifASCII(exifTagOffsetTimeOriginal, "+00:00"),
+		exifASCII(exifTagSubSecTimeOriginal, fmt.Sprintf("%03d", t.Nanosecond()/int(time.Millisecond))),
+	}
+
+	// the exif ifd follows ifd0, whose size does not depend on the pointer value
+	const headerSize = 8
+	exifOffset := headerSize + len(buildIFD(ifd0, headerSize))
+	ifd0[len(ifd0)-1] = exifLong(exifTagExifIFDPointer, uint32(exifOffset))
+
+	tiff := []byte{'I', 'I', 0x2a, 0, headerSize, 0, 0, 0}
+	tiff = append(tiff, buildIFD(ifd0, headerSize)...)
+	return append(tiff, buildIFD(exifIFD, uint32(exifOffset))...)
+}
+
+// buildIFD encodes entries sorted by tag, followed by values which do not fit in an entry.
+// offset is the position of the ifd from the start of the TIFF header.
+func buildIFD(entries []exifEntry, offset uint32) []byte {
+	dataOffset := offset + 2 + uint32(len(entries))*12 + 4
+
+	ifd := binary.LittleEndian.AppendUint16(nil, uint16(len(entries)))
+	var data []byte
+	for _, e := range entries {
+		ifd = binary.LittleEndian.AppendUint16(ifd, e.tag)
+		ifd = binary.LittleEndian.AppendUint16(ifd, e.typ)
+		ifd = binary.LittleEndian.AppendUint32(ifd, e.count)
+		if len(e.value) <= 4 {
+			value := make([]byte, 4)
+			copy(value, e.value)
+			ifd = append(ifd, value...)
+		} else {
+			ifd = binary.LittleEndian.AppendUint32(ifd, dataOffset+uint32(len(data)))
+			data = append(data, e.value...)
+			// values start on a word boundary
+			if len(data)%2 == 1 {
+				data = append(data, 0)
+			}
+		}
+	}
+	// no next ifd
+	ifd = binary.LittleEndian.AppendUint32(ifd, 0)
+
+	return append(ifd, data...)
+}
+
+func describeSource(md *imageMetadata) string {
+	fields := []string{"egress_id=" + md.EgressID}
+	if md.TrackID != "" {
+		fields = append(fields, "track_id="+md.TrackID)
+	}
+	return strings.Join(fields, " ")
+}
+
+func buildXMP(md *imageMetadata) []byte {
+	var b bytes.Buffer
+	b.WriteString("<?xpacket begin=\"\uFEFF\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n")
+	b.WriteString(" <rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">\n")
+	b.WriteString("  <rdf:Description rdf:about=\"\"\n")
+	b.WriteString("    xmlns:xmp=\"http://ns.adobe.com/xap/1.0/\"\n")
+	b.WriteString("    xmlns:livekit=\"" + xmpNamespace + "\"\n")
+	writeXMPAttribute(&b, "xmp:CreateDate", md.CaptureTime.UTC().Format("2006-01-02T15:04:05.000Z07:00"))
+	writeXMPAttribute(&b, "xmp:CreatorTool", exifSoftware)
+	writeXMPAttribute(&b, "livekit:EgressID", md.EgressID)
+	writeXMPAttribute(&b, "livekit:RoomName", md.RoomName)
+	writeXMPAttribute(&b, "livekit:ParticipantIdentity", md.ParticipantIdentity)
+	writeXMPAttribute(&b, "livekit:TrackID", md.TrackID)
+	b.WriteString("  />\n")
+	b.WriteString(" </rdf:RDF>\n")
+	b.WriteString("</x:xmpmeta>\n")
+	b.WriteString("<?xpacket end=\"w\"?>")
+	return b.Bytes()
+}
+
+func writeXMPAttribute(b *bytes.Buffer, name, value string) {
+	if value == "" {
+		return
+	}
+	b.WriteString("    " + name + "=\"")
+	_ = xml.EscapeText(b, []byte(value))
+	b.WriteString("\"\n")
+}
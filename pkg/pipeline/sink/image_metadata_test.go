@@ -0,0 +1,150 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/egress/pkg/types"
+)
+
+func newTestMetadata() *imageMetadata {
+	return &imageMetadata{
+		CaptureTime:         time.Date(2026, 3, 14, 15, 9, 26, 535e6, time.UTC),
+		EgressID:            "EG_test",
+		RoomName:            "room <1>",
+		ParticipantIdentity: "speaker ü",
+		TrackID:             "TR_video",
+	}
+}
+
+// readExifString returns an ASCII value from a little endian TIFF ifd
+func readExifString(t *testing.T, tiff []byte, offset uint32, tag uint16) string {
+	count := binary.LittleEndian.Uint16(tiff[offset:])
+	for i := uint32(0); i < uint32(count); i++ {
+		entry := tiff[offset+2+i*12:]
+		if binary.LittleEndian.Uint16(entry) != tag {
+			continue
+		}
+		n := binary.LittleEndian.Uint32(entry[4:])
+		value := entry[8:12]
+		if n > 4 {
+			value = tiff[binary.LittleEndian.Uint32(entry[8:]):]
+		}
+		return string(value[:n-1])
+	}
+	t.Fatalf("tag %#x not found", tag)
+	return ""
+}
+
+func requireExif(t *testing.T, tiff []byte) {
+	require.Equal(t, "II", string(tiff[:2]))
+	ifd0 := binary.LittleEndian.Uint32(tiff[4:])
+	require.Equal(t, "2026:03:14 15:09:26", readExifString(t, tiff, ifd0, exifTagDateTime))
+	require.Equal(t, exifSoftware, readExifString(t, tiff, ifd0, exifTagSoftware))
+	require.Equal(t,
+		"egress_id=EG_test track_id=TR_video",
+		readExifString(t, tiff, ifd0, exifTagImageDescription),
+	)
+
+	// the exif pointer is the last entry of ifd0
+	count := binary.LittleEndian.Uint16(tiff[ifd0:])
+	pointer := tiff[ifd0+2+uint32(count-1)*12:]
+	require.Equal(t, uint16(exifTagExifIFDPointer), binary.LittleEndian.Uint16(pointer))
+	exifIFD := binary.LittleEndian.Uint32(pointer[8:])
+	require.Equal(t, "2026:03:14 15:09:26", readExifString(t, tiff, exifIFD, exifTagDateTimeOriginal))
+	require.Equal(t, "535", readExifString(t, tiff, exifIFD, exifTagSubSecTimeOriginal))
+}
+
+func requireXMP(t *testing.T, data []byte) {
+	require.Contains(t, string(data), `xmp:CreateDate="2026-03-14T15:09:26.535Z"`)
+	require.Contains(t, string(data), `livekit:EgressID="EG_test"`)
+	require.Contains(t, string(data), `livekit:RoomName="room &lt;1&gt;"`)
+	require.Contains(t, string(data), `livekit:ParticipantIdentity="speaker ü"`)
+	require.Contains(t, string(data), `livekit:TrackID="TR_video"`)
+}
+
+func TestEmbedJPEGMetadata(t *testing.T) {
+	filepath := path.Join(t.TempDir(), "image.jpeg")
+	var b bytes.Buffer
+	require.NoError(t, jpeg.Encode(&b, newGradientImage(64, 36, false), nil))
+	require.NoError(t, os.WriteFile(filepath, b.Bytes(), 0644))
+
+	require.NoError(t, embedImageMetadata(filepath, types.OutputTypeJPEG, newTestMetadata()))
+	data, err := os.ReadFile(filepath)
+	require.NoError(t, err)
+
+	// the image is still decodable
+	_, err = jpeg.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	// the exif segment follows SOI
+	require.Equal(t, []byte{0xff, 0xd8, 0xff, 0xe1}, data[:4])
+	exifLength := int(binary.BigEndian.Uint16(data[4:]))
+	require.Equal(t, jpegExifHeader, data[6:12])
+	requireExif(t, data[12:4+exifLength])
+
+	// followed by the xmp segment
+	xmp := data[4+exifLength:]
+	require.Equal(t, []byte{0xff, 0xe1}, xmp[:2])
+	require.True(t, bytes.HasPrefix(xmp[4:], jpegXMPHeader))
+	requireXMP(t, xmp[:binary.BigEndian.Uint16(xmp[2:])+2])
+}
+
+func TestEmbedPNGMetadata(t *testing.T) {
+	filepath := path.Join(t.TempDir(), "image.png")
+	var b bytes.Buffer
+	require.NoError(t, png.Encode(&b, newGradientImage(64, 36, false)))
+	require.NoError(t, os.WriteFile(filepath, b.Bytes(), 0644))
+
+	require.NoError(t, embedImageMetadata(filepath, types.OutputTypePNG, newTestMetadata()))
+	data, err := os.ReadFile(filepath)
+	require.NoError(t, err)
+
+	// the decoder verifies the checksum of every chunk
+	_, err = png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	exif := data[33:]
+	require.Equal(t, "eXIf", string(exif[4:8]))
+	exifLength := binary.BigEndian.Uint32(exif)
+	requireExif(t, exif[8:8+exifLength])
+
+	itxt := exif[12+exifLength:]
+	require.Equal(t, "iTXt", string(itxt[4:8]))
+	require.True(t, bytes.HasPrefix(itxt[8:], []byte("XML:com.adobe.xmp\x00")))
+	requireXMP(t, itxt[8:8+binary.BigEndian.Uint32(itxt)])
+}
+
+func TestEmbedWebPMetadata(t *testing.T) {
+	filepath := path.Join(t.TempDir(), "image.webp")
+	require.NoError(t, os.WriteFile(filepath, []byte("RIFF"), 0644))
+	require.Error(t, embedImageMetadata(filepath, types.OutputTypeWebP, newTestMetadata()))
+}
+
+func TestExifASCII(t *testing.T) {
+	e := exifASCII(exifTagImageDescription, "room ü")
+	require.Equal(t, []byte("room ?\x00"), e.value)
+	require.Equal(t, uint32(7), e.count)
+}
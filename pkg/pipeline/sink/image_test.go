@@ -16,6 +16,8 @@ package sink
 
 import (
 	"fmt"
+	"image"
+	"image/jpeg"
 	"os"
 	"path"
 	"testing"
@@ -172,3 +174,47 @@ func TestImageQueueCapacity(t *testing.T) {
 	require.Equal(t, minPendingUploads, imageQueueCapacity(60, 7200))
 	require.Equal(t, minPendingUploads, imageQueueCapacity(0, 10))
 }
+
+func TestImageSinkDedupe(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	s, errCh := newTestImageSink(t, 4)
+	s.StorageDir = "images-out"
+	s.Dedupe = true
+	s.conf.Manifest = &config.Manifest{}
+
+	// the second image matches the first, the third does not
+	filenames := []string{"img_00001.jpeg", "img_00002.jpeg", "img_00003.jpeg"}
+	for i, img := range []image.Image{
+		newGradientImage(64, 36, false),
+		newGradientImage(64, 36, false),
+		newGradientImage(64, 36, true),
+	} {
+		f, err := os.Create(path.Join(s.LocalDir, filenames[i]))
+		require.NoError(t, err)
+		require.NoError(t, jpeg.Encode(f, img, nil))
+		require.NoError(t, f.Close())
+	}
+
+	require.NoError(t, s.Start())
+	for _, name := range filenames {
+		requireNewImage(t, s, path.Join(s.LocalDir, name))
+	}
+	requireClose(t, s)
+
+	require.EqualValues(t, 2, s.ImagesInfo.ImageCount)
+	_, err := os.Stat(path.Join(s.StorageDir, filenames[1]))
+	require.True(t, os.IsNotExist(err), "duplicate image uploaded")
+
+	require.Len(t, s.conf.Manifest.Images, 3)
+	require.Empty(t, s.conf.Manifest.Images[0].DuplicateOf)
+	require.Equal(t, path.Join(s.StorageDir, filenames[0]), s.conf.Manifest.Images[1].DuplicateOf)
+	require.Empty(t, s.conf.Manifest.Images[1].Location)
+	require.Empty(t, s.conf.Manifest.Images[2].DuplicateOf)
+
+	select {
+	case err := <-errCh:
+		t.Fatalf("unexpected error: %v", err)
+	default:
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package m3u8
+
+import (
+	"container/list"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parts are only listed for the most recent segments, older segments are listed as whole segments
+const partSegmentHistory = 3
+
+type LowLatencyPlaylistWriter interface {
+	PlaylistWriter
+
+	// AppendPart adds a partial segment to the segment in progress. Independent parts start with a key frame,
+	// and preloadHint is the uri of the next part, if known.
+	AppendPart(dateTime time.Time, duration float64, filename string, independent bool, preloadHint string) error
+}
+
+type lowLatencyPlaylistWriter struct {
+	basePlaylistWriter
+
+	windowSize   int
+	mediaSeq     int
+	partTarget   float64
+	partHoldBack float64
+
+	segments    *list.List
+	current     *lowLatencySegment
+	preloadHint string
+}
+
+type lowLatencySegment struct {
+	dateTime time.Time
+	duration float64
+	filename string
+	parts    []string
+}
+
+// NewLowLatencyPlaylistWriter creates a live playlist with a sliding window of windowSize segments, publishing
+// partial segments of up to partTarget seconds as they are completed. Players stay partHoldBack seconds
+// behind the live edge.
+func NewLowLatencyPlaylistWriter(filename string, targetDuration int, windowSize int, initSegment string, partTarget, partHoldBack float64) (LowLatencyPlaylistWriter, error) {
+	return &lowLatencyPlaylistWriter{
+		basePlaylistWriter: basePlaylistWriter{
+			filename:       filename,
+			targetDuration: targetDuration,
+			initSegment:    initSegment,
+		},
+		windowSize:   windowSize,
+		partTarget:   partTarget,
+		partHoldBack: partHoldBack,
+		segments:     list.New(),
+	}, nil
+}
+
+func (p *lowLatencyPlaylistWriter) AppendPart(dateTime time.Time, duration float64, filename string, independent bool, preloadHint string) error {
+	if p.current == nil {
+		p.current = &lowLatencySegment{dateTime: dateTime}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("#EXT-X-PART:DURATION=")
+	sb.WriteString(strconv.FormatFloat(duration, 'f', 3, 32))
+	fmt.Fprintf(&sb, ",URI=\"%s\"", filename)
+	if independent {
+		sb.WriteString(",INDEPENDENT=YES")
+	}
+	sb.WriteString("\n")
+	p.current.parts = append(p.current.parts, sb.String())
+	p.preloadHint = preloadHint
+
+	return p.writePlaylist(false)
+}
+
+func (p *lowLatencyPlaylistWriter) Append(dateTime time.Time, duration float64, filename string) error {
+	segment := p.current
+	if segment == nil {
+		segment = &lowLatencySegment{}
+	}
+	segment.dateTime = dateTime
+	segment.duration = duration
+	segment.filename = filename
+
+	// the preload hint is kept, it is the first part of the next segment
+	p.segments.PushBack(segment)
+	p.current = nil
+
+	for p.segments.Len() > p.windowSize {
+		p.segments.Remove(p.segments.Front())
+		p.mediaSeq++
+	}
+
+	return p.writePlaylist(false)
+}
+
+func (p *lowLatencyPlaylistWriter) Close() error {
+	return p.writePlaylist(true)
+}
+
+func (p *lowLatencyPlaylistWriter) writePlaylist(closed bool) error {
+	f, err := os.Create(p.filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(p.generatePlaylist(closed))
+	return err
+}
+
+func (p *lowLatencyPlaylistWriter) generatePlaylist(closed bool) string {
+	var sb strings.Builder
+	sb.WriteString("#EXTM3U\n")
+	sb.WriteString("#EXT-X-VERSION:9\n")
+	fmt.Fprintf(&sb, "#EXT-X-TARGETDURATION:%d\n", p.targetDuration)
+	// CAN-BLOCK-RELOAD is not declared: object storage cannot hold playlist requests until the next part,
+	// so players poll the live playlist instead of sending _HLS_msn and _HLS_part requests
+	fmt.Fprintf(&sb, "#EXT-X-SERVER-CONTROL:PART-HOLD-BACK=%s\n", strconv.FormatFloat(p.partHoldBack, 'f', 3, 32))
+	fmt.Fprintf(&sb, "#EXT-X-PART-INF:PART-TARGET=%s\n", strconv.FormatFloat(p.partTarget, 'f', 3, 32))
+	fmt.Fprintf(&sb, "#EXT-X-MEDIA-SEQUENCE:%d\n", p.mediaSeq)
+	sb.WriteString(p.createMapEntry())
+
+	partHistoryStart := p.segments.Len() - partSegmentHistory
+	i := 0
+	for elem := p.segments.Front(); elem != nil; elem = elem.Next() {
+		segment := elem.Value.(*lowLatencySegment)
+		if i >= partHistoryStart {
+			sb.WriteString("#EXT-X-PROGRAM-DATE-TIME:")
+			sb.WriteString(segment.dateTime.UTC().Format("2006-01-02T15:04:05.999Z07:00"))
+			sb.WriteString("\n")
+			for _, part := range segment.parts {
+				sb.WriteString(part)
+			}
+			sb.WriteString("#EXTINF:")
+			sb.WriteString(strconv.FormatFloat(segment.duration, 'f', 3, 32))
+			sb.WriteString(",\n")
+			sb.WriteString(segment.filename)
+			sb.WriteString("\n")
+		} else {
+			sb.WriteString(p.createSegmentEntry(segment.dateTime, segment.duration, segment.filename))
+		}
+		i++
+	}
+
+	if closed {
+		sb.WriteString("#EXT-X-ENDLIST\n")
+		return sb.String()
+	}
+
+	if p.current != nil {
+		sb.WriteString("#EXT-X-PROGRAM-DATE-TIME:")
+		sb.WriteString(p.current.dateTime.UTC().Format("2006-01-02T15:04:05.999Z07:00"))
+		sb.WriteString("\n")
+		for _, part := range p.current.parts {
+			sb.WriteString(part)
+		}
+	}
+	if p.preloadHint != "" {
+		fmt.Fprintf(&sb, "#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"%s\"\n", p.preloadHint)
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,80 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package m3u8
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLowLatencyPlaylistWriter(t *testing.T) {
+	playlistName := "playlist.m3u8"
+
+	w, err := NewLowLatencyPlaylistWriter(playlistName, 2, 3, "playlist_init.mp4", 1, 2.5)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = os.Remove(playlistName) })
+
+	now := time.Unix(0, 1683154504814142000)
+	start := now
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, w.AppendPart(now, 1, fmt.Sprintf("playlist_part%05d.m4s", i), i == 0, fmt.Sprintf("playlist_part%05d.m4s", i+1)))
+		now = now.Add(time.Second)
+	}
+	require.NoError(t, w.Append(start, 2, "playlist_00000.m4s"))
+
+	// the preload hint is kept across segments
+	b, err := os.ReadFile(playlistName)
+	require.NoError(t, err)
+	require.Contains(t, string(b), "#EXTINF:2.000,\nplaylist_00000.m4s\n#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"playlist_part00002.m4s\"\n")
+
+	require.NoError(t, w.AppendPart(now, 1, "playlist_part00002.m4s", true, "playlist_part00003.m4s"))
+
+	b, err = os.ReadFile(playlistName)
+	require.NoError(t, err)
+
+	expected := "#EXTM3U\n#EXT-X-VERSION:9\n#EXT-X-TARGETDURATION:2\n#EXT-X-SERVER-CONTROL:PART-HOLD-BACK=2.500\n#EXT-X-PART-INF:PART-TARGET=1.000\n#EXT-X-MEDIA-SEQUENCE:0\n" +
+		"#EXT-X-MAP:URI=\"playlist_init.mp4\"\n" +
+		"#EXT-X-PROGRAM-DATE-TIME:2023-05-03T22:55:04.814Z\n" +
+		"#EXT-X-PART:DURATION=1.000,URI=\"playlist_part00000.m4s\",INDEPENDENT=YES\n" +
+		"#EXT-X-PART:DURATION=1.000,URI=\"playlist_part00001.m4s\"\n" +
+		"#EXTINF:2.000,\nplaylist_00000.m4s\n" +
+		"#EXT-X-PROGRAM-DATE-TIME:2023-05-03T22:55:06.814Z\n" +
+		"#EXT-X-PART:DURATION=1.000,URI=\"playlist_part00002.m4s\",INDEPENDENT=YES\n" +
+		"#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"playlist_part00003.m4s\"\n"
+	require.Equal(t, expected, string(b))
+
+	// the window slides past the segments with parts, and no preload hint is written once closed
+	for i := 1; i < 5; i++ {
+		require.NoError(t, w.Append(start.Add(time.Duration(i)*2*time.Second), 2, fmt.Sprintf("playlist_0000%d.m4s", i)))
+	}
+	require.NoError(t, w.Close())
+
+	b, err = os.ReadFile(playlistName)
+	require.NoError(t, err)
+
+	expected = "#EXTM3U\n#EXT-X-VERSION:9\n#EXT-X-TARGETDURATION:2\n#EXT-X-SERVER-CONTROL:PART-HOLD-BACK=2.500\n#EXT-X-PART-INF:PART-TARGET=1.000\n#EXT-X-MEDIA-SEQUENCE:2\n" +
+		"#EXT-X-MAP:URI=\"playlist_init.mp4\"\n" +
+		"#EXT-X-PROGRAM-DATE-TIME:2023-05-03T22:55:08.814Z\n#EXTINF:2.000,\nplaylist_00002.m4s\n" +
+		"#EXT-X-PROGRAM-DATE-TIME:2023-05-03T22:55:10.814Z\n#EXTINF:2.000,\nplaylist_00003.m4s\n" +
+		"#EXT-X-PROGRAM-DATE-TIME:2023-05-03T22:55:12.814Z\n#EXTINF:2.000,\nplaylist_00004.m4s\n" +
+		"#EXT-X-ENDLIST\n"
+	require.Equal(t, expected, string(b))
+}
@@ -0,0 +1,61 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package m3u8
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+type Variant struct {
+	URI       string
+	Bandwidth int // bits per second
+	Width     int32
+	Height    int32
+	Codecs    string
+	FrameRate int32
+}
+
+// WriteMasterPlaylist writes a multivariant playlist listing each variant playlist, from highest to lowest bandwidth
+func WriteMasterPlaylist(filename string, variants []*Variant) error {
+	sorted := make([]*Variant, len(variants))
+	copy(sorted, variants)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Bandwidth > sorted[j].Bandwidth
+	})
+
+	var sb strings.Builder
+	sb.WriteString("#EXTM3U\n")
+	sb.WriteString("#EXT-X-INDEPENDENT-SEGMENTS\n")
+	for _, v := range sorted {
+		fmt.Fprintf(&sb, "#EXT-X-STREAM-INF:BANDWIDTH=%d", v.Bandwidth)
+		if v.Width > 0 && v.Height > 0 {
+			fmt.Fprintf(&sb, ",RESOLUTION=%dx%d", v.Width, v.Height)
+		}
+		if v.Codecs != "" {
+			fmt.Fprintf(&sb, ",CODECS=\"%s\"", v.Codecs)
+		}
+		if v.FrameRate > 0 {
+			fmt.Fprintf(&sb, ",FRAME-RATE=%d.000", v.FrameRate)
+		}
+		sb.WriteString("\n")
+		sb.WriteString(v.URI)
+		sb.WriteString("\n")
+	}
+
+	return os.WriteFile(filename, []byte(sb.String()), 0644)
+}
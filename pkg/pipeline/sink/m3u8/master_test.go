@@ -0,0 +1,42 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package m3u8
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteMasterPlaylist(t *testing.T) {
+	playlistName := "master.m3u8"
+	t.Cleanup(func() { _ = os.Remove(playlistName) })
+
+	require.NoError(t, WriteMasterPlaylist(playlistName, []*Variant{
+		{URI: "playlist_360p.m3u8", Bandwidth: 928000, Width: 640, Height: 360, Codecs: "avc1.4d4029,mp4a.40.2", FrameRate: 30},
+		{URI: "playlist.m3u8", Bandwidth: 4628000, Width: 1920, Height: 1080, Codecs: "avc1.4d4029,mp4a.40.2", FrameRate: 30},
+		{URI: "playlist_720p.m3u8", Bandwidth: 2628000, Width: 1280, Height: 720, Codecs: "avc1.4d4029,mp4a.40.2", FrameRate: 30},
+	}))
+
+	b, err := os.ReadFile(playlistName)
+	require.NoError(t, err)
+
+	expected := "#EXTM3U\n#EXT-X-INDEPENDENT-SEGMENTS\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=4628000,RESOLUTION=1920x1080,CODECS=\"avc1.4d4029,mp4a.40.2\",FRAME-RATE=30.000\nplaylist.m3u8\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=2628000,RESOLUTION=1280x720,CODECS=\"avc1.4d4029,mp4a.40.2\",FRAME-RATE=30.000\nplaylist_720p.m3u8\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=928000,RESOLUTION=640x360,CODECS=\"avc1.4d4029,mp4a.40.2\",FRAME-RATE=30.000\nplaylist_360p.m3u8\n"
+	require.Equal(t, expected, string(b))
+}
@@ -39,6 +39,7 @@ type PlaylistWriter interface {
 type basePlaylistWriter struct {
 	filename       string
 	targetDuration int
+	initSegment    string
 }
 
 type eventPlaylistWriter struct {
@@ -58,19 +59,35 @@ type livePlaylistWriter struct {
 func (p *basePlaylistWriter) createHeader(plType PlaylistType) string {
 	var sb strings.Builder
 	sb.WriteString("#EXTM3U\n")
-	sb.WriteString("#EXT-X-VERSION:4\n")
+	if p.initSegment != "" {
+		// EXT-X-MAP without EXT-X-I-FRAMES-ONLY requires version 6, CMAF is usually paired with 7
+		sb.WriteString("#EXT-X-VERSION:7\n")
+	} else {
+		sb.WriteString("#EXT-X-VERSION:4\n")
+	}
 	if plType != PlaylistTypeLive {
 		fmt.Fprintf(&sb, "#EXT-X-PLAYLIST-TYPE:%s\n", plType)
 	}
-	sb.WriteString("#EXT-X-ALLOW-CACHE:NO\n")
+	if p.initSegment == "" {
+		// EXT-X-ALLOW-CACHE was removed in version 7
+		sb.WriteString("#EXT-X-ALLOW-CACHE:NO\n")
+	}
 	fmt.Fprintf(&sb, "#EXT-X-TARGETDURATION:%d\n", p.targetDuration)
 	if plType != PlaylistTypeLive {
 		sb.WriteString("#EXT-X-MEDIA-SEQUENCE:0\n")
+		sb.WriteString(p.createMapEntry())
 	}
 
 	return sb.String()
 }
 
+func (p *basePlaylistWriter) createMapEntry() string {
+	if p.initSegment == "" {
+		return ""
+	}
+	return fmt.Sprintf("#EXT-X-MAP:URI=\"%s\"\n", p.initSegment)
+}
+
 func (p *basePlaylistWriter) createSegmentEntry(dateTime time.Time, duration float64, filename string) string {
 	var sb strings.Builder
 
@@ -85,11 +102,14 @@ func (p *basePlaylistWriter) createSegmentEntry(dateTime time.Time, duration flo
 	return sb.String()
 }
 
-func NewEventPlaylistWriter(filename string, targetDuration int) (PlaylistWriter, error) {
+// NewEventPlaylistWriter creates an EVENT playlist. initSegment is the EXT-X-MAP uri for fMP4 segments, and should
+// be empty for MPEG-TS.
+func NewEventPlaylistWriter(filename string, targetDuration int, initSegment string) (PlaylistWriter, error) {
 	p := &eventPlaylistWriter{
 		basePlaylistWriter: basePlaylistWriter{
 			filename:       filename,
 			targetDuration: targetDuration,
+			initSegment:    initSegment,
 		},
 	}
 
@@ -130,11 +150,12 @@ func (p *eventPlaylistWriter) Close() error {
 	return err
 }
 
-func NewLivePlaylistWriter(filename string, targetDuration int, windowSize int) (PlaylistWriter, error) {
+func NewLivePlaylistWriter(filename string, targetDuration int, windowSize int, initSegment string) (PlaylistWriter, error) {
 	p := &livePlaylistWriter{
 		basePlaylistWriter: basePlaylistWriter{
 			filename:       filename,
 			targetDuration: targetDuration,
+			initSegment:    initSegment,
 		},
 		windowSize:           windowSize,
 		livePlaylistSegments: list.New(),
@@ -184,6 +205,7 @@ func (p *livePlaylistWriter) generatePlaylist() string {
 	var sb strings.Builder
 	sb.WriteString(p.livePlaylistHeader)
 	fmt.Fprintf(&sb, "#EXT-X-MEDIA-SEQUENCE:%d\n", p.mediaSeq)
+	sb.WriteString(p.createMapEntry())
 	for elem := p.livePlaylistSegments.Front(); elem != nil; elem = elem.Next() {
 		segmentStr := elem.Value.(string)
 		sb.WriteString(segmentStr)
@@ -0,0 +1,289 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mpd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"time"
+)
+
+type ManifestType string
+
+const (
+	ManifestTypeStatic  ManifestType = "static"
+	ManifestTypeDynamic ManifestType = "dynamic"
+
+	timescale = 1000
+)
+
+// ManifestWriter lists segments by their media time, the decode time of their first sample.
+// The date time of the first segment sets the manifest's availability start time.
+type ManifestWriter interface {
+	Append(dateTime time.Time, startTime time.Duration, duration float64, filename string) error
+	Close() error
+}
+
+// Representation describes the muxed audio/video segments referenced by the manifest
+type Representation struct {
+	MimeType  string
+	Codecs    string
+	Bandwidth int
+	Width     int32
+	Height    int32
+}
+
+type segment struct {
+	startTime time.Duration
+	duration  time.Duration
+	filename  string
+}
+
+type baseManifestWriter struct {
+	filename       string
+	targetDuration int
+	initSegment    string
+	representation Representation
+
+	availabilityStartTime time.Time
+	segments              []*segment
+}
+
+type eventManifestWriter struct {
+	baseManifestWriter
+}
+
+type liveManifestWriter struct {
+	baseManifestWriter
+
+	windowSize int
+}
+
+// NewEventManifestWriter creates a manifest listing every segment. It is dynamic while the egress is running,
+// and becomes static once closed.
+func NewEventManifestWriter(filename string, targetDuration int, initSegment string, representation Representation) (ManifestWriter, error) {
+	return &eventManifestWriter{
+		baseManifestWriter: baseManifestWriter{
+			filename:       filename,
+			targetDuration: targetDuration,
+			initSegment:    initSegment,
+			representation: representation,
+		},
+	}, nil
+}
+
+func (m *eventManifestWriter) Append(dateTime time.Time, startTime time.Duration, duration float64, filename string) error {
+	m.append(dateTime, startTime, duration, filename)
+	return m.write(ManifestTypeDynamic, 0)
+}
+
+func (m *eventManifestWriter) Close() error {
+	return m.write(ManifestTypeStatic, 0)
+}
+
+// NewLiveManifestWriter creates a dynamic manifest with a sliding window of windowSize segments
+func NewLiveManifestWriter(filename string, targetDuration int, windowSize int, initSegment string, representation Representation) (ManifestWriter, error) {
+	return &liveManifestWriter{
+		baseManifestWriter: baseManifestWriter{
+			filename:       filename,
+			targetDuration: targetDuration,
+			initSegment:    initSegment,
+			representation: representation,
+		},
+		windowSize: windowSize,
+	}, nil
+}
+
+func (m *liveManifestWriter) Append(dateTime time.Time, startTime time.Duration, duration float64, filename string) error {
+	m.append(dateTime, startTime, duration, filename)
+	if len(m.segments) > m.windowSize {
+		m.segments = m.segments[len(m.segments)-m.windowSize:]
+	}
+	return m.write(ManifestTypeDynamic, time.Duration(m.windowSize*m.targetDuration)*time.Second)
+}
+
+func (m *liveManifestWriter) Close() error {
+	return m.write(ManifestTypeStatic, 0)
+}
+
+func (m *baseManifestWriter) append(dateTime time.Time, startTime time.Duration, duration float64, filename string) {
+	if m.availabilityStartTime.IsZero() {
+		// the date time of media time 0
+		m.availabilityStartTime = dateTime.Add(-startTime)
+	}
+	m.segments = append(m.segments, &segment{
+		startTime: startTime,
+		duration:  time.Duration(duration * float64(time.Second)),
+		filename:  filename,
+	})
+}
+
+func (m *baseManifestWriter) write(manifestType ManifestType, timeShiftBufferDepth time.Duration) error {
+	b, err := xml.MarshalIndent(m.generateManifest(manifestType, timeShiftBufferDepth), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(m.filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err = f.WriteString(xml.Header); err != nil {
+		return err
+	}
+	if _, err = f.Write(b); err != nil {
+		return err
+	}
+	_, err = f.WriteString("\n")
+	return err
+}
+
+func (m *baseManifestWriter) generateManifest(manifestType ManifestType, timeShiftBufferDepth time.Duration) *mpd {
+	segmentList := &segmentList{
+		Timescale: timescale,
+		Timeline:  &segmentTimeline{},
+	}
+	if m.initSegment != "" {
+		segmentList.Initialization = &initialization{SourceURL: m.initSegment}
+	}
+
+	var end time.Duration
+	for _, s := range m.segments {
+		end = s.startTime + s.duration
+		// rounded the same way as the next segment's start, so that the timeline has no gaps
+		segmentList.Timeline.S = append(segmentList.Timeline.S, &timelineSegment{
+			T: s.startTime.Milliseconds(),
+			D: end.Milliseconds() - s.startTime.Milliseconds(),
+		})
+		segmentList.SegmentURLs = append(segmentList.SegmentURLs, &segmentURL{Media: s.filename})
+	}
+
+	manifest := &mpd{
+		XMLNS:         "urn:mpeg:dash:schema:mpd:2011",
+		Profiles:      "urn:mpeg:dash:profile:isoff-main:2011",
+		Type:          manifestType,
+		MinBufferTime: formatDuration(time.Duration(m.targetDuration) * time.Second),
+		Period: &period{
+			ID:    "0",
+			Start: formatDuration(0),
+			AdaptationSet: &adaptationSet{
+				ID:               0,
+				MimeType:         m.representation.MimeType,
+				SegmentAlignment: true,
+				Representation: &representation{
+					ID:          "0",
+					Codecs:      m.representation.Codecs,
+					Bandwidth:   m.representation.Bandwidth,
+					Width:       m.representation.Width,
+					Height:      m.representation.Height,
+					SegmentList: segmentList,
+				},
+			},
+		},
+	}
+
+	switch manifestType {
+	case ManifestTypeDynamic:
+		manifest.AvailabilityStartTime = formatDateTime(m.availabilityStartTime)
+		manifest.PublishTime = formatDateTime(time.Now())
+		manifest.MinimumUpdatePeriod = formatDuration(time.Duration(m.targetDuration) * time.Second)
+		if timeShiftBufferDepth > 0 {
+			manifest.TimeShiftBufferDepth = formatDuration(timeShiftBufferDepth)
+		}
+	case ManifestTypeStatic:
+		if len(m.segments) > 0 {
+			// a closed sliding window starts at its first segment
+			first := m.segments[0].startTime
+			segmentList.PresentationTimeOffset = first.Milliseconds()
+			manifest.MediaPresentationDuration = formatDuration(end - first)
+		} else {
+			manifest.MediaPresentationDuration = formatDuration(0)
+		}
+	}
+
+	return manifest
+}
+
+func formatDuration(d time.Duration) string {
+	return fmt.Sprintf("PT%.3fS", d.Seconds())
+}
+
+func formatDateTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.999Z07:00")
+}
+
+type mpd struct {
+	XMLName                   xml.Name     `xml:"MPD"`
+	XMLNS                     string       `xml:"xmlns,attr"`
+	Profiles                  string       `xml:"profiles,attr"`
+	Type                      ManifestType `xml:"type,attr"`
+	AvailabilityStartTime     string       `xml:"availabilityStartTime,attr,omitempty"`
+	PublishTime               string       `xml:"publishTime,attr,omitempty"`
+	MediaPresentationDuration string       `xml:"mediaPresentationDuration,attr,omitempty"`
+	MinimumUpdatePeriod       string       `xml:"minimumUpdatePeriod,attr,omitempty"`
+	TimeShiftBufferDepth      string       `xml:"timeShiftBufferDepth,attr,omitempty"`
+	MinBufferTime             string       `xml:"minBufferTime,attr"`
+	Period                    *period      `xml:"Period"`
+}
+
+type period struct {
+	ID            string         `xml:"id,attr"`
+	Start         string         `xml:"start,attr"`
+	AdaptationSet *adaptationSet `xml:"AdaptationSet"`
+}
+
+type adaptationSet struct {
+	ID               int             `xml:"id,attr"`
+	MimeType         string          `xml:"mimeType,attr"`
+	SegmentAlignment bool            `xml:"segmentAlignment,attr"`
+	Representation   *representation `xml:"Representation"`
+}
+
+type representation struct {
+	ID          string       `xml:"id,attr"`
+	Codecs      string       `xml:"codecs,attr,omitempty"`
+	Bandwidth   int          `xml:"bandwidth,attr"`
+	Width       int32        `xml:"width,attr,omitempty"`
+	Height      int32        `xml:"height,attr,omitempty"`
+	SegmentList *segmentList `xml:"SegmentList"`
+}
+
+type segmentList struct {
+	Timescale              int              `xml:"timescale,attr"`
+	PresentationTimeOffset int64            `xml:"presentationTimeOffset,attr,omitempty"`
+	Initialization         *initialization  `xml:"Initialization,omitempty"`
+	Timeline               *segmentTimeline `xml:"SegmentTimeline"`
+	SegmentURLs            []*segmentURL    `xml:"SegmentURL"`
+}
+
+type initialization struct {
+	SourceURL string `xml:"sourceURL,attr"`
+}
+
+type segmentTimeline struct {
+	S []*timelineSegment `xml:"S"`
+}
+
+type timelineSegment struct {
+	T int64 `xml:"t,attr"`
+	D int64 `xml:"d,attr"`
+}
+
+type segmentURL struct {
+	Media string `xml:"media,attr"`
+}
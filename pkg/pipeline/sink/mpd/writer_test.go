@@ -0,0 +1,115 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mpd
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testRepresentation = Representation{
+	MimeType:  "video/mp4",
+	Codecs:    "avc1.4d4029,mp4a.40.2",
+	Bandwidth: 3128000,
+	Width:     1280,
+	Height:    720,
+}
+
+func TestEventManifestWriter(t *testing.T) {
+	manifestName := "manifest.mpd"
+
+	w, err := NewEventManifestWriter(manifestName, 6, "manifest_init.mp4", testRepresentation)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = os.Remove(manifestName) })
+
+	now := time.Unix(0, 1683154504814142000)
+	duration := 5.994
+
+	// segments are listed by media time, regardless of when they were closed
+	for i := 0; i < 2; i++ {
+		startTime := time.Duration(i) * 5994 * time.Millisecond
+		require.NoError(t, w.Append(now, startTime, duration, fmt.Sprintf("manifest_0000%d.m4s", i)))
+		now = now.Add(time.Millisecond * 6100)
+	}
+
+	b, err := os.ReadFile(manifestName)
+	require.NoError(t, err)
+	require.Contains(t, string(b), `type="dynamic" availabilityStartTime="2023-05-03T22:55:04.814Z"`)
+	require.Contains(t, string(b), `minimumUpdatePeriod="PT6.000S"`)
+	require.NotContains(t, string(b), "timeShiftBufferDepth")
+
+	require.NoError(t, w.Close())
+
+	b, err = os.ReadFile(manifestName)
+	require.NoError(t, err)
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-main:2011" type="static" mediaPresentationDuration="PT11.988S" minBufferTime="PT6.000S">
+  <Period id="0" start="PT0.000S">
+    <AdaptationSet id="0" mimeType="video/mp4" segmentAlignment="true">
+      <Representation id="0" codecs="avc1.4d4029,mp4a.40.2" bandwidth="3128000" width="1280" height="720">
+        <SegmentList timescale="1000">
+          <Initialization sourceURL="manifest_init.mp4"></Initialization>
+          <SegmentTimeline>
+            <S t="0" d="5994"></S>
+            <S t="5994" d="5994"></S>
+          </SegmentTimeline>
+          <SegmentURL media="manifest_00000.m4s"></SegmentURL>
+          <SegmentURL media="manifest_00001.m4s"></SegmentURL>
+        </SegmentList>
+      </Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+	require.Equal(t, expected, string(b))
+}
+
+func TestLiveManifestWriter(t *testing.T) {
+	manifestName := "live.mpd"
+
+	w, err := NewLiveManifestWriter(manifestName, 6, 1, "manifest_init.mp4", testRepresentation)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = os.Remove(manifestName) })
+
+	now := time.Unix(0, 1683154504814142000)
+	duration := 5.994
+
+	// segments are listed by media time, regardless of when they were closed
+	for i := 0; i < 2; i++ {
+		startTime := time.Duration(i) * 5994 * time.Millisecond
+		require.NoError(t, w.Append(now, startTime, duration, fmt.Sprintf("manifest_0000%d.m4s", i)))
+		now = now.Add(time.Millisecond * 6100)
+	}
+
+	b, err := os.ReadFile(manifestName)
+	require.NoError(t, err)
+	require.Contains(t, string(b), `timeShiftBufferDepth="PT6.000S"`)
+	require.Contains(t, string(b), `<S t="5994" d="5994"></S>`)
+	require.NotContains(t, string(b), "manifest_00000.m4s")
+
+	require.NoError(t, w.Close())
+
+	b, err = os.ReadFile(manifestName)
+	require.NoError(t, err)
+	require.Contains(t, string(b), `type="static" mediaPresentationDuration="PT5.994S"`)
+	require.Contains(t, string(b), `presentationTimeOffset="5994"`)
+}
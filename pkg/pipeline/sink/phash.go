@@ -0,0 +1,67 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"image"
+	"image/color"
+	"math/bits"
+)
+
+const (
+	// the hash compares horizontally adjacent cells of a 9x8 grid
+	hashColumns = 9
+	hashRows    = 8
+
+	// luminance is sampled on a grid within each cell, which is enough to ignore compression noise
+	hashSamplesPerCell = 8
+)
+
+// perceptualHash computes a 64 bit difference hash, which changes little when the image is re-encoded or slightly altered
+func perceptualHash(img image.Image) uint64 {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	var cells [hashRows][hashColumns]int
+	for row := 0; row < hashRows; row++ {
+		for column := 0; column < hashColumns; column++ {
+			var sum int
+			for sy := 0; sy < hashSamplesPerCell; sy++ {
+				y := bounds.Min.Y + (row*hashSamplesPerCell+sy)*height/(hashRows*hashSamplesPerCell)
+				for sx := 0; sx < hashSamplesPerCell; sx++ {
+					x := bounds.Min.X + (column*hashSamplesPerCell+sx)*width/(hashColumns*hashSamplesPerCell)
+					sum += int(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+				}
+			}
+			cells[row][column] = sum
+		}
+	}
+
+	var hash uint64
+	for row := 0; row < hashRows; row++ {
+		for column := 0; column < hashColumns-1; column++ {
+			hash <<= 1
+			if cells[row][column] > cells[row][column+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// hashDistance returns the number of bits which differ between two hashes
+func hashDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
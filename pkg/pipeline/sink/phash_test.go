@@ -0,0 +1,56 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newGradientImage returns an image which gets brighter from left to right, or from right to left if reversed
+func newGradientImage(width, height int, reversed bool) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := uint8(x * 255 / width)
+			if reversed {
+				v = 255 - v
+			}
+			img.Set(x, y, color.RGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+	return img
+}
+
+func TestPerceptualHash(t *testing.T) {
+	img := newGradientImage(320, 180, false)
+	hash := perceptualHash(img)
+
+	// re-encoding and resizing barely change the hash
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 50}))
+	decoded, err := jpeg.Decode(&buf)
+	require.NoError(t, err)
+	require.LessOrEqual(t, hashDistance(hash, perceptualHash(decoded)), 2)
+	require.LessOrEqual(t, hashDistance(hash, perceptualHash(newGradientImage(640, 360, false))), 2)
+
+	// a different image does not match
+	require.Greater(t, hashDistance(hash, perceptualHash(newGradientImage(320, 180, true))), 32)
+}
@@ -0,0 +1,237 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-gst/go-gst/gst"
+	"github.com/go-gst/go-gst/gst/app"
+
+	"github.com/livekit/egress/pkg/config"
+	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/egress/pkg/gstreamer"
+	"github.com/livekit/egress/pkg/pipeline/builder"
+	"github.com/livekit/egress/pkg/pipeline/sink/uploader"
+	"github.com/livekit/egress/pkg/stats"
+	"github.com/livekit/protocol/logger"
+)
+
+// the preview is encoded from a few seconds of small frames
+const previewEncodeTimeout = 30 * time.Second
+
+type PreviewSink struct {
+	*base
+	*config.PreviewConfig
+	*uploader.Uploader
+
+	conf  *config.PipelineConfig
+	valve *gst.Element
+
+	mu      sync.Mutex
+	frames  *previewFrames
+	stopped bool
+}
+
+func newPreviewSink(
+	p *gstreamer.Pipeline,
+	conf *config.PipelineConfig,
+	o *config.PreviewConfig,
+	monitor *stats.HandlerMonitor,
+) (*PreviewSink, error) {
+	u, err := uploader.New(o.StorageConfig, conf.BackupConfig, monitor, conf.StorageObserver, conf.Info)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &PreviewSink{
+		PreviewConfig: o,
+		Uploader:      u,
+		conf:          conf,
+		frames:        newPreviewFrames(o.FrameCount(), o.Sampled),
+	}
+
+	previewBin, valve, err := builder.BuildPreviewBin(p, conf, &app.SinkCallbacks{
+		NewSampleFunc: s.handleSample,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = p.AddSinkBin(previewBin); err != nil {
+		return nil, err
+	}
+	s.base = &base{bin: previewBin}
+	s.valve = valve
+
+	return s, nil
+}
+
+func (s *PreviewSink) handleSample(appSink *app.Sink) gst.FlowReturn {
+	sample := appSink.PullSample()
+	if sample == nil {
+		return gst.FlowOK
+	}
+	buffer := sample.GetBuffer()
+	if buffer == nil {
+		return gst.FlowOK
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.frames.next() {
+		s.frames.add(buffer.Bytes())
+	}
+	if !s.stopped && s.frames.complete() {
+		// the preview has all of its frames, stop scaling video for it
+		s.stopped = true
+		if err := s.valve.SetProperty("drop", true); err != nil {
+			logger.Warnw("failed to stop preview branch", err)
+		}
+	}
+	return gst.FlowOK
+}
+
+func (s *PreviewSink) Start() error {
+	return nil
+}
+
+func (s *PreviewSink) UploadManifest(_ string) (string, bool, error) {
+	// the preview is listed in the file output's manifest
+	return "", false, nil
+}
+
+// Close encodes and uploads the preview. The recording is complete without it, so failures are only logged.
+func (s *PreviewSink) Close() error {
+	s.mu.Lock()
+	frames := s.frames.result()
+	s.mu.Unlock()
+
+	if len(frames) == 0 {
+		logger.Warnw("preview not written", nil, "reason", "no frames")
+		return nil
+	}
+	if err := s.encode(frames); err != nil {
+		logger.Warnw("preview not written", err)
+		_ = os.Remove(s.LocalFilepath)
+		return nil
+	}
+
+	location, size, err := s.Upload(s.LocalFilepath, s.StorageFilepath, s.OutputType, false)
+	if err != nil {
+		logger.Warnw("preview upload failed", err)
+		return nil
+	}
+	logger.Debugw("preview upload completed", "location", location, "bytes", size)
+
+	if s.conf.Manifest != nil {
+		s.conf.Manifest.AddFile(s.StorageFilepath, location)
+	}
+	return nil
+}
+
+// encode plays the frames back at the preview frame rate, and ends the stream once they have all been pushed
+func (s *PreviewSink) encode(frames [][]byte) error {
+	pipeline, src, err := builder.BuildPreviewEncoder(s.PreviewConfig)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = pipeline.SetState(gst.StateNull)
+	}()
+
+	if err = pipeline.SetState(gst.StatePlaying); err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+
+	frameDuration := time.Second / time.Duration(s.FrameRate)
+	for i, frame := range frames {
+		buffer := gst.NewBufferFromBytes(frame)
+		buffer.SetPresentationTimestamp(gst.ClockTime(uint64(time.Duration(i) * frameDuration)))
+		buffer.SetDuration(gst.ClockTime(uint64(frameDuration)))
+		if flow := src.PushBuffer(buffer); flow != gst.FlowOK {
+			return errors.ErrGstPipelineError(errors.New(flow.String()))
+		}
+	}
+	if flow := src.EndStream(); flow != gst.FlowOK {
+		return errors.ErrGstPipelineError(errors.New(flow.String()))
+	}
+
+	return waitForEOS(pipeline, previewEncodeTimeout)
+}
+
+// previewFrames collects the preview's frames. Previews of the start of the recording keep the first frames.
+// Sampled previews keep every frame until they hold twice the preview's frames, then drop every other frame
+// and only keep every other frame from then on, so that the frames stay evenly spread across the recording
+// and memory stays bounded however long it is.
+type previewFrames struct {
+	count   int
+	sampled bool
+
+	frames [][]byte
+	stride int
+	index  int
+}
+
+func newPreviewFrames(count int, sampled bool) *previewFrames {
+	return &previewFrames{
+		count:   count,
+		sampled: sampled,
+		stride:  1,
+	}
+}
+
+// next returns true if the next frame should be kept
+func (f *previewFrames) next() bool {
+	if !f.sampled {
+		return len(f.frames) < f.count
+	}
+	keep := f.index%f.stride == 0
+	f.index++
+	return keep
+}
+
+func (f *previewFrames) add(frame []byte) {
+	f.frames = append(f.frames, frame)
+	if !f.sampled || len(f.frames) < 2*f.count {
+		return
+	}
+
+	for i := range f.count {
+		f.frames[i] = f.frames[2*i]
+	}
+	clear(f.frames[f.count:])
+	f.frames = f.frames[:f.count]
+	f.stride *= 2
+}
+
+// complete returns true once no more frames are needed
+func (f *previewFrames) complete() bool {
+	return !f.sampled && len(f.frames) >= f.count
+}
+
+// result returns up to count frames, evenly spread across the collected frames
+func (f *previewFrames) result() [][]byte {
+	if len(f.frames) <= f.count {
+		return f.frames
+	}
+	frames := make([][]byte, f.count)
+	for i := range frames {
+		frames[i] = f.frames[i*len(f.frames)/f.count]
+	}
+	return frames
+}
@@ -0,0 +1,57 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func collectPreviewFrames(f *previewFrames, total int) []int {
+	for i := 0; i < total; i++ {
+		if f.next() {
+			f.add([]byte{byte(i)})
+		}
+	}
+	var kept []int
+	for _, frame := range f.result() {
+		kept = append(kept, int(frame[0]))
+	}
+	return kept
+}
+
+func TestPreviewFramesStart(t *testing.T) {
+	f := newPreviewFrames(3, false)
+	require.False(t, f.complete())
+	require.Equal(t, []int{0, 1, 2}, collectPreviewFrames(f, 10))
+	require.True(t, f.complete())
+}
+
+func TestPreviewFramesSampled(t *testing.T) {
+	// shorter recordings keep every frame
+	require.Equal(t, []int{0, 1}, collectPreviewFrames(newPreviewFrames(4, true), 2))
+
+	// frames are spread across the whole recording
+	f := newPreviewFrames(4, true)
+	require.Equal(t, []int{0, 8, 16, 24}, collectPreviewFrames(f, 30))
+	require.Equal(t, 8, f.stride)
+	require.LessOrEqual(t, len(f.frames), 8)
+	require.False(t, f.complete())
+
+	f = newPreviewFrames(4, true)
+	require.Equal(t, []int{0, 32, 64, 96}, collectPreviewFrames(f, 128))
+	require.Equal(t, 32, f.stride)
+}
@@ -0,0 +1,346 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/go-gst/go-gst/gst"
+	"github.com/go-gst/go-gst/gst/app"
+
+	"github.com/livekit/egress/pkg/types"
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	// segments are cut at the first keyframe after this much of the target duration
+	fmp4SegmentTolerance = 100 * time.Millisecond
+	// fragments of other tracks are added to the segment covering their start time, until they catch up
+	maxOpenFMP4Segments = 3
+)
+
+type fmp4Segment struct {
+	filepath  string
+	file      *os.File
+	startTime time.Duration
+	endTime   time.Duration // set once the next segment starts
+}
+
+// fmp4Part is a low latency HLS part, which holds the fragments starting with a single main track fragment
+type fmp4Part struct {
+	filepath    string
+	file        *os.File
+	independent bool
+}
+
+type fmp4SegmenterConfig struct {
+	segmentDuration time.Duration
+	segmentPath     func(index int, startTime time.Duration) string
+	// parts are only written for low latency HLS
+	partPath func(index int) string
+
+	onInit          func(init []byte) error
+	onSegmentOpened func(filepath string, startTime time.Duration) error
+	onSegmentClosed func(filepath string, endTime time.Duration) error
+	onPartOpened    func(filepath string, startTime time.Duration) error
+	onPartClosed    func(filepath string, endTime time.Duration, independent bool, nextPart string) error
+}
+
+// fmp4Segmenter writes the fragments of a continuous fMP4 stream into segment files. A new segment is started by
+// the first keyframe fragment of the main track once the current segment has reached the target duration.
+// With low latency HLS, each main track fragment also starts a new part, and segments are closed as soon as the
+// next one starts so that every segment is made of its parts.
+type fmp4Segmenter struct {
+	*fmp4Reader
+	fmp4SegmenterConfig
+
+	segments       []*fmp4Segment
+	segmentCount   int
+	part           *fmp4Part
+	partCount      int
+	lastStartTimes map[uint32]time.Duration
+	endTime        time.Duration
+}
+
+func newFMP4Segmenter(conf fmp4SegmenterConfig) *fmp4Segmenter {
+	s := &fmp4Segmenter{
+		fmp4SegmenterConfig: conf,
+		lastStartTimes:      make(map[uint32]time.Duration),
+	}
+	s.fmp4Reader = newFMP4Reader(conf.onInit, s.handleFragment)
+	return s
+}
+
+func (s *fmp4Segmenter) lowLatency() bool {
+	return s.partPath != nil
+}
+
+func (s *fmp4Segmenter) handleFragment(f *fmp4Fragment) error {
+	if f.trackID == s.mainTrack {
+		if err := s.closePart(f.startTime); err != nil {
+			return err
+		}
+		current := s.currentSegment()
+		if current == nil || (f.sync && f.startTime-current.startTime >= s.segmentDuration-fmp4SegmentTolerance) {
+			if err := s.openSegment(f.startTime); err != nil {
+				return err
+			}
+		}
+		if err := s.openPart(f.startTime, f.sync); err != nil {
+			return err
+		}
+	} else if len(s.segments) == 0 {
+		if err := s.openSegment(f.startTime); err != nil {
+			return err
+		}
+		if err := s.openPart(f.startTime, false); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.segmentForFragment(f).file.Write(f.data); err != nil {
+		return err
+	}
+	if s.part != nil {
+		if _, err := s.part.file.Write(f.data); err != nil {
+			return err
+		}
+	}
+
+	s.lastStartTimes[f.trackID] = f.startTime
+	s.endTime = max(s.endTime, f.endTime())
+
+	return s.closeCompleteSegments()
+}
+
+func (s *fmp4Segmenter) currentSegment() *fmp4Segment {
+	if len(s.segments) == 0 {
+		return nil
+	}
+	return s.segments[len(s.segments)-1]
+}
+
+func (s *fmp4Segmenter) openSegment(startTime time.Duration) error {
+	if current := s.currentSegment(); current != nil {
+		current.endTime = startTime
+	}
+	if s.lowLatency() {
+		// segments are made of whole parts, lagging fragments of other tracks are added to the next segment
+		for len(s.segments) > 0 {
+			if err := s.closeSegment(startTime); err != nil {
+				return err
+			}
+		}
+	}
+
+	filepath := s.segmentPath(s.segmentCount, startTime)
+	file, err := os.Create(filepath)
+	if err != nil {
+		return err
+	}
+	s.segmentCount++
+	s.segments = append(s.segments, &fmp4Segment{
+		filepath:  filepath,
+		file:      file,
+		startTime: startTime,
+	})
+
+	return s.onSegmentOpened(filepath, startTime)
+}
+
+func (s *fmp4Segmenter) openPart(startTime time.Duration, independent bool) error {
+	if !s.lowLatency() {
+		return nil
+	}
+
+	filepath := s.partPath(s.partCount)
+	file, err := os.Create(filepath)
+	if err != nil {
+		return err
+	}
+	s.partCount++
+	s.part = &fmp4Part{
+		filepath:    filepath,
+		file:        file,
+		independent: independent,
+	}
+
+	return s.onPartOpened(filepath, startTime)
+}
+
+// closePart closes the current part. The name of the next part is always known, since parts are numbered
+// independently of segments.
+func (s *fmp4Segmenter) closePart(endTime time.Duration) error {
+	if s.part == nil {
+		return nil
+	}
+	part := s.part
+	s.part = nil
+
+	if err := part.file.Close(); err != nil {
+		return err
+	}
+	return s.onPartClosed(part.filepath, endTime, part.independent, s.partPath(s.partCount))
+}
+
+// segmentForFragment returns the open segment covering the fragment's start time
+func (s *fmp4Segmenter) segmentForFragment(f *fmp4Fragment) *fmp4Segment {
+	for _, segment := range s.segments {
+		if segment.endTime == 0 || f.startTime < segment.endTime {
+			return segment
+		}
+	}
+	return s.currentSegment()
+}
+
+// closeCompleteSegments closes segments once every track has moved past them
+func (s *fmp4Segmenter) closeCompleteSegments() error {
+	for len(s.segments) > 1 {
+		segment := s.segments[0]
+		if len(s.segments) <= maxOpenFMP4Segments {
+			for _, t := range s.lastStartTimes {
+				if t < segment.endTime {
+					return nil
+				}
+			}
+		}
+		if err := s.closeSegment(segment.endTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *fmp4Segmenter) closeSegment(endTime time.Duration) error {
+	segment := s.segments[0]
+	s.segments = s.segments[1:]
+
+	if err := segment.file.Close(); err != nil {
+		return err
+	}
+	return s.onSegmentClosed(segment.filepath, endTime)
+}
+
+// Close closes the last part and all open segments, the last one ending with the last fragment
+func (s *fmp4Segmenter) Close() error {
+	if err := s.closePart(s.endTime); err != nil {
+		return err
+	}
+	for len(s.segments) > 0 {
+		endTime := s.segments[0].endTime
+		if len(s.segments) == 1 {
+			endTime = max(s.endTime, s.segments[0].startTime)
+		}
+		if err := s.closeSegment(endTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SegmentSink) newFMP4SinkCallbacks() *app.SinkCallbacks {
+	conf := fmp4SegmenterConfig{
+		segmentDuration: time.Duration(s.SegmentDuration) * time.Second,
+		segmentPath:     s.fmp4SegmentPath,
+		onInit:          s.writeInitSegment,
+		onSegmentOpened: func(filepath string, startTime time.Duration) error {
+			return s.FragmentOpened(filepath, uint64(startTime))
+		},
+		onSegmentClosed: func(filepath string, endTime time.Duration) error {
+			return s.FragmentClosed(filepath, uint64(endTime))
+		},
+	}
+	if s.LowLatency() {
+		conf.partPath = s.fmp4PartPath
+		conf.onPartOpened = func(filepath string, startTime time.Duration) error {
+			return s.FragmentOpened(filepath, uint64(startTime))
+		}
+		conf.onPartClosed = func(filepath string, endTime time.Duration, independent bool, nextPart string) error {
+			return s.PartClosed(filepath, uint64(endTime), independent, nextPart)
+		}
+	}
+	s.segmenter = newFMP4Segmenter(conf)
+
+	return &app.SinkCallbacks{
+		EOSFunc: func(_ *app.Sink) {
+			s.closeSegmenter()
+		},
+		NewSampleFunc: func(appSink *app.Sink) gst.FlowReturn {
+			sample := appSink.PullSample()
+			if sample == nil {
+				return gst.FlowOK
+			}
+			buffer := sample.GetBuffer()
+			if buffer == nil {
+				return gst.FlowOK
+			}
+
+			s.segmenterLock.Lock()
+			defer s.segmenterLock.Unlock()
+
+			if s.segmenterClosed {
+				return gst.FlowEOS
+			}
+			if err := s.segmenter.Write(buffer.Map(gst.MapRead).Bytes()); err != nil {
+				s.callbacks.OnError(err)
+				return gst.FlowError
+			}
+			return gst.FlowOK
+		},
+	}
+}
+
+// fmp4SegmentPath names segments the same way as splitmuxsink does for MPEG-TS
+func (s *SegmentSink) fmp4SegmentPath(index int, startTime time.Duration) string {
+	var filename string
+	switch s.SegmentSuffix {
+	case livekit.SegmentedFileSuffix_TIMESTAMP:
+		s.segmentLock.Lock()
+		ts := s.startTime.Add(startTime)
+		s.segmentLock.Unlock()
+		filename = fmt.Sprintf("%s_%s%03d", s.SegmentPrefix, ts.Format("20060102150405"), ts.UnixMilli()%1000)
+	default:
+		filename = fmt.Sprintf("%s_%05d", s.SegmentPrefix, index)
+	}
+	return path.Join(s.LocalDir, filename+string(types.FileExtensionM4S))
+}
+
+// fmp4PartPath numbers parts across segments, so that the next part can always be hinted
+func (s *SegmentSink) fmp4PartPath(index int) string {
+	return path.Join(s.LocalDir, fmt.Sprintf("%s_part%05d%s", s.SegmentPrefix, index, types.FileExtensionM4S))
+}
+
+// writeInitSegment is called once the muxer has written its header, when the first samples reach it
+func (s *SegmentSink) writeInitSegment(init []byte) error {
+	s.UpdateStartDate(time.Now())
+	return os.WriteFile(path.Join(s.LocalDir, s.InitSegmentFilename()), init, 0644)
+}
+
+// closeSegmenter closes the last segment, on EOS or when the sink is closed
+func (s *SegmentSink) closeSegmenter() {
+	s.segmenterLock.Lock()
+	defer s.segmenterLock.Unlock()
+
+	if s.segmenterClosed {
+		return
+	}
+	s.segmenterClosed = true
+	if err := s.segmenter.Close(); err != nil {
+		s.callbacks.OnError(err)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/livekit/protocol/logger"
+)
+
+// PartClosed queues a low latency HLS part for upload. Parts are uploaded before the segment containing them.
+func (s *SegmentSink) PartClosed(filepath string, endTime uint64, independent bool, nextPart string) error {
+	return s.queueClosedSegment(filepath, SegmentUpdate{
+		endTime:     endTime,
+		isPart:      true,
+		independent: independent,
+		nextPart:    path.Base(nextPart),
+	})
+}
+
+func (s *SegmentSink) uploadPart(update SegmentUpdate) {
+	// keep part and playlist updates in order
+	s.playlistUpdates <- update
+
+	partLocalPath := path.Join(s.LocalDir, update.filename)
+	partStoragePath := path.Join(s.StorageDir, update.filename)
+
+	// upload in parallel
+	go func() {
+		defer close(update.uploadComplete)
+
+		if _, _, err := s.Upload(partLocalPath, partStoragePath, s.outputType, true); err != nil {
+			s.callbacks.OnError(err)
+		}
+	}()
+}
+
+func (s *SegmentSink) handlePartUpdate(update SegmentUpdate) error {
+	s.segmentLock.Lock()
+	t, ok := s.openSegmentsStartTime[update.filename]
+	if !ok {
+		s.segmentLock.Unlock()
+		return fmt.Errorf("no open part with the name %s", update.filename)
+	}
+	delete(s.openSegmentsStartTime, update.filename)
+	s.segmentLock.Unlock()
+
+	duration := float64(time.Duration(update.endTime-t)) / float64(time.Second)
+	partStartTime := s.startTime.Add(time.Duration(t - s.startRunningTime))
+
+	// do not update playlist until upload is complete
+	<-update.uploadComplete
+
+	s.playlistLock.Lock()
+	defer s.playlistLock.Unlock()
+
+	if err := s.lowLatencyPlaylist.AppendPart(partStartTime, duration, update.filename, update.independent, update.nextPart); err != nil {
+		return err
+	}
+	s.openParts = append(s.openParts, update.filename)
+
+	// ignore playlist upload failures until close
+	_ = s.uploadLivePlaylist()
+	return nil
+}
+
+// deleteExpiredParts deletes the parts of the oldest segment once it has left the live playlist window.
+// Parts are only listed for the most recent segments, so players no longer request them.
+func (s *SegmentSink) deleteExpiredParts() {
+	s.windowParts = append(s.windowParts, s.openParts)
+	s.openParts = nil
+	if len(s.windowParts) <= defaultLivePlaylistWindow {
+		return
+	}
+
+	parts := s.windowParts[0]
+	s.windowParts = s.windowParts[1:]
+
+	deleter, ok := s.segmentUploader.(partDeleter)
+	if !ok || len(parts) == 0 {
+		return
+	}
+
+	storagePaths := make([]string, 0, len(parts))
+	for _, part := range parts {
+		storagePaths = append(storagePaths, path.Join(s.StorageDir, part))
+	}
+	go func() {
+		if err := deleter.Delete(storagePaths); err != nil {
+			logger.Warnw("failed to delete parts", err, "count", len(parts))
+		}
+	}()
+}
@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/frostbyte73/core"
+	"github.com/go-gst/go-gst/gst/app"
 	"github.com/linkdata/deadlock"
 
 	"github.com/livekit/egress/pkg/config"
@@ -28,6 +29,7 @@ import (
 	"github.com/livekit/egress/pkg/gstreamer"
 	"github.com/livekit/egress/pkg/pipeline/builder"
 	"github.com/livekit/egress/pkg/pipeline/sink/m3u8"
+	"github.com/livekit/egress/pkg/pipeline/sink/mpd"
 	"github.com/livekit/egress/pkg/pipeline/sink/uploader"
 	"github.com/livekit/egress/pkg/stats"
 	"github.com/livekit/egress/pkg/types"
@@ -38,30 +40,56 @@ const (
 	defaultLivePlaylistWindow = 5
 )
 
+// segmentUploader uploads segments and playlists, to storage or to an hls push origin
+type segmentUploader interface {
+	Upload(localFilepath, storageFilepath string, outputType types.OutputType, deleteAfterUpload bool) (string, int64, error)
+	DisableUploads()
+}
+
+// partDeleter removes low latency HLS parts once their segment has left the live playlist
+type partDeleter interface {
+	Delete(storageFilepaths []string) error
+}
+
 type SegmentSink struct {
 	*base
-	*uploader.Uploader
+	segmentUploader
 	*config.SegmentConfig
 
 	conf             *config.PipelineConfig
 	manifestPlaylist *config.Playlist
 	callbacks        *gstreamer.Callbacks
 
-	segmentCount int
-	playlist     m3u8.PlaylistWriter
-	livePlaylist m3u8.PlaylistWriter
+	segmentCount       int
+	playlist           m3u8.PlaylistWriter
+	livePlaylist       m3u8.PlaylistWriter
+	lowLatencyPlaylist m3u8.LowLatencyPlaylistWriter
+	dashManifest       mpd.ManifestWriter
+	liveDashManifest   mpd.ManifestWriter
 
 	segmentLock  deadlock.Mutex
 	infoLock     deadlock.Mutex
 	playlistLock deadlock.Mutex
 
 	initialized           bool
+	initSegmentUploaded   bool
+	masterUploaded        bool
+	liveMasterUploaded    bool
 	startTime             time.Time
 	lastUpload            time.Time
 	outputType            types.OutputType
 	startRunningTime      uint64
 	openSegmentsStartTime map[string]uint64
 
+	// cuts fMP4 segments from the muxer's continuous stream
+	segmenter       *fmp4Segmenter
+	segmenterLock   deadlock.Mutex
+	segmenterClosed bool
+
+	// low latency HLS parts of the segment in progress, and of each segment in the live playlist
+	openParts   []string
+	windowParts [][]string
+
 	closedSegments  chan SegmentUpdate
 	playlistUpdates chan SegmentUpdate
 	done            core.Fuse
@@ -71,6 +99,11 @@ type SegmentUpdate struct {
 	endTime        uint64
 	filename       string
 	uploadComplete chan struct{}
+
+	// low latency HLS parts
+	isPart      bool
+	independent bool
+	nextPart    string
 }
 
 func newSegmentSink(
@@ -85,55 +118,115 @@ func newSegmentSink(
 		return nil, err
 	}
 
+	return buildSegmentSink(p, conf, o, u, callbacks, monitor)
+}
+
+func buildSegmentSink(
+	p *gstreamer.Pipeline,
+	conf *config.PipelineConfig,
+	o *config.SegmentConfig,
+	u segmentUploader,
+	callbacks *gstreamer.Callbacks,
+	monitor *stats.HandlerMonitor,
+) (*SegmentSink, error) {
+	var playlist m3u8.PlaylistWriter
+	var err error
 	playlistName := path.Join(o.LocalDir, o.PlaylistFilename)
-	playlist, err := m3u8.NewEventPlaylistWriter(playlistName, o.SegmentDuration)
+	if o.Push != nil {
+		// origins serve the pushed playlist as is, which needs to be a sliding window
+		playlist, err = m3u8.NewLivePlaylistWriter(playlistName, o.SegmentDuration, defaultLivePlaylistWindow, o.InitSegmentFilename())
+	} else {
+		playlist, err = m3u8.NewEventPlaylistWriter(playlistName, o.SegmentDuration, o.InitSegmentFilename())
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	var livePlaylist m3u8.PlaylistWriter
+	var lowLatencyPlaylist m3u8.LowLatencyPlaylistWriter
 	if o.LivePlaylistFilename != "" {
 		playlistName = path.Join(o.LocalDir, o.LivePlaylistFilename)
-		livePlaylist, err = m3u8.NewLivePlaylistWriter(playlistName, o.SegmentDuration, defaultLivePlaylistWindow)
+		if o.LowLatency() {
+			lowLatencyPlaylist, err = m3u8.NewLowLatencyPlaylistWriter(playlistName, o.SegmentDuration, defaultLivePlaylistWindow, o.InitSegmentFilename(), o.PartDuration.Seconds(), o.PartHoldBack.Seconds())
+			livePlaylist = lowLatencyPlaylist
+		} else {
+			livePlaylist, err = m3u8.NewLivePlaylistWriter(playlistName, o.SegmentDuration, defaultLivePlaylistWindow, o.InitSegmentFilename())
+		}
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	outputType := o.OutputType
-	if outputType == types.OutputTypeHLS {
-		outputType = types.OutputTypeTS
+	var manifest, liveManifest mpd.ManifestWriter
+	if o.MPDFilename() != "" {
+		representation := getMPDRepresentation(conf)
+		manifest, err = mpd.NewEventManifestWriter(path.Join(o.LocalDir, o.MPDFilename()), o.SegmentDuration, o.InitSegmentFilename(), representation)
+		if err != nil {
+			return nil, err
+		}
+		if o.LiveMPDFilename() != "" {
+			liveManifest, err = mpd.NewLiveManifestWriter(path.Join(o.LocalDir, o.LiveMPDFilename()), o.SegmentDuration, defaultLivePlaylistWindow, o.InitSegmentFilename(), representation)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	segmentBin, err := builder.BuildSegmentBin(p, conf)
-	if err != nil {
-		return nil, err
+	outputType := types.OutputTypeTS
+	if o.SegmentContainer == types.OutputTypeFMP4 {
+		outputType = types.OutputTypeFMP4
 	}
-	if err = p.AddSinkBin(segmentBin); err != nil {
-		return nil, err
+
+	if o.MasterPlaylistFilename() != "" {
+		if err = writeMasterPlaylist(conf, o, false); err != nil {
+			return nil, err
+		}
+		if o.LiveMasterPlaylistFilename() != "" {
+			if err = writeMasterPlaylist(conf, o, true); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	maxPendingUploads := (conf.MaxUploadQueue * 60) / o.SegmentDuration
 	segmentSink := &SegmentSink{
-		base: &base{
-			bin: segmentBin,
-		},
-		Uploader:              u,
+		base:                  &base{},
+		segmentUploader:       u,
 		SegmentConfig:         o,
 		conf:                  conf,
 		callbacks:             callbacks,
 		playlist:              playlist,
 		livePlaylist:          livePlaylist,
+		lowLatencyPlaylist:    lowLatencyPlaylist,
+		dashManifest:          manifest,
+		liveDashManifest:      liveManifest,
 		outputType:            outputType,
 		openSegmentsStartTime: make(map[string]uint64),
 		closedSegments:        make(chan SegmentUpdate, maxPendingUploads),
 		playlistUpdates:       make(chan SegmentUpdate, maxPendingUploads),
 	}
 
-	if conf.Manifest != nil {
+	if conf.Manifest != nil && o.Push == nil {
 		segmentSink.manifestPlaylist = conf.Manifest.AddPlaylist()
 	}
 
+	var sinkCallbacks *app.SinkCallbacks
+	if o.SegmentContainer == types.OutputTypeFMP4 {
+		sinkCallbacks = segmentSink.newFMP4SinkCallbacks()
+	}
+	segmentSink.bin, err = builder.BuildSegmentBin(p, conf, o, sinkCallbacks)
+	if err != nil {
+		return nil, err
+	}
+	if err = p.AddSinkBin(segmentSink.bin); err != nil {
+		return nil, err
+	}
+
+	if o.Rendition != nil || o.Push != nil {
+		// gauges are registered once per egress, by the main output
+		return segmentSink, nil
+	}
+
 	// Register gauges that track the number of segments and playlist updates pending upload
 	monitor.RegisterPlaylistChannelSizeGauge(segmentSink.conf.NodeID, segmentSink.conf.ClusterID, segmentSink.conf.Info.EgressId,
 		func() float64 {
@@ -169,6 +262,21 @@ func (s *SegmentSink) Start() error {
 }
 
 func (s *SegmentSink) handleClosedSegment(update SegmentUpdate) {
+	if s.SegmentContainer == types.OutputTypeFMP4 {
+		if err := s.handleInitSegment(); err != nil {
+			s.callbacks.OnError(err)
+			return
+		}
+	}
+
+	if update.isPart {
+		s.uploadPart(update)
+	} else {
+		s.uploadSegment(update)
+	}
+}
+
+func (s *SegmentSink) uploadSegment(update SegmentUpdate) {
 	// keep playlist updates in order
 	s.playlistUpdates <- update
 
@@ -196,7 +304,38 @@ func (s *SegmentSink) handleClosedSegment(update SegmentUpdate) {
 	}()
 }
 
+// handleInitSegment uploads the init segment before the first segment or part, so that playlists never
+// reference a missing EXT-X-MAP
+func (s *SegmentSink) handleInitSegment() error {
+	if s.initSegmentUploaded {
+		return nil
+	}
+
+	initFilename := s.InitSegmentFilename()
+	initLocalPath := path.Join(s.LocalDir, initFilename)
+	initStoragePath := path.Join(s.StorageDir, initFilename)
+
+	location, size, err := s.Upload(initLocalPath, initStoragePath, types.OutputTypeMP4, true)
+	if err != nil {
+		return err
+	}
+	s.initSegmentUploaded = true
+
+	s.infoLock.Lock()
+	s.SegmentsInfo.Size += size
+	if s.manifestPlaylist != nil {
+		s.manifestPlaylist.AddSegment(initStoragePath, location)
+	}
+	s.infoLock.Unlock()
+
+	return nil
+}
+
 func (s *SegmentSink) handlePlaylistUpdates(update SegmentUpdate) error {
+	if update.isPart {
+		return s.handlePartUpdate(update)
+	}
+
 	s.segmentLock.Lock()
 	t, ok := s.openSegmentsStartTime[update.filename]
 	if !ok {
@@ -219,10 +358,19 @@ func (s *SegmentSink) handlePlaylistUpdates(update SegmentUpdate) error {
 		return err
 	}
 
+	if s.dashManifest != nil {
+		if err := s.dashManifest.Append(segmentStartTime, time.Duration(t), duration, update.filename); err != nil {
+			return err
+		}
+	}
+
 	s.segmentCount++
 	if s.shouldUploadPlaylist() {
 		// ignore playlist upload failures until close
 		_ = s.uploadPlaylist()
+		if s.dashManifest != nil {
+			_ = s.uploadMPD()
+		}
 	}
 
 	if s.livePlaylist != nil {
@@ -231,15 +379,29 @@ func (s *SegmentSink) handlePlaylistUpdates(update SegmentUpdate) error {
 		}
 		// ignore playlist upload failures until close
 		_ = s.uploadLivePlaylist()
+
+		if s.lowLatencyPlaylist != nil {
+			s.deleteExpiredParts()
+		}
+	}
+
+	if s.liveDashManifest != nil {
+		if err := s.liveDashManifest.Append(segmentStartTime, time.Duration(t), duration, update.filename); err != nil {
+			return err
+		}
+		// ignore manifest upload failures until close
+		_ = s.uploadLiveMPD()
 	}
 
 	return nil
 }
 
 // Each segment adds about 100 bytes in the playlist, and long playlists can get very large.
-// Uploads every N segments, where N is the number of hours, with a minimum frequency of once per minute
+// Uploads every N segments, where N is the number of hours, with a minimum frequency of once per minute.
+// Pushed playlists are a sliding window, and are uploaded with every segment.
 func (s *SegmentSink) shouldUploadPlaylist() bool {
-	return s.lastUpload.IsZero() ||
+	return s.Push != nil ||
+		s.lastUpload.IsZero() ||
 		s.segmentCount%(int(time.Since(s.startTime)/time.Hour)+1) == 0 ||
 		time.Since(s.lastUpload) > time.Minute
 }
@@ -257,6 +419,15 @@ func (s *SegmentSink) uploadPlaylist() error {
 	if s.manifestPlaylist != nil {
 		s.manifestPlaylist.Location = playlistLocation
 	}
+
+	if !s.masterUploaded && s.MasterPlaylistFilename() != "" {
+		masterLocalPath := path.Join(s.LocalDir, s.MasterPlaylistFilename())
+		masterStoragePath := path.Join(s.StorageDir, s.MasterPlaylistFilename())
+		if _, _, err = s.Upload(masterLocalPath, masterStoragePath, types.OutputTypeHLS, false); err != nil {
+			return err
+		}
+		s.masterUploaded = true
+	}
 	return nil
 }
 
@@ -264,12 +435,119 @@ func (s *SegmentSink) uploadLivePlaylist() error {
 	liveLocalPath := path.Join(s.LocalDir, s.LivePlaylistFilename)
 	liveStoragePath := path.Join(s.StorageDir, s.LivePlaylistFilename)
 	livePlaylistLocation, _, err := s.Upload(liveLocalPath, liveStoragePath, s.OutputType, false)
-	if err == nil {
-		s.SegmentsInfo.LivePlaylistLocation = livePlaylistLocation
+	if err != nil {
+		return err
+	}
+	s.SegmentsInfo.LivePlaylistLocation = livePlaylistLocation
+
+	if !s.liveMasterUploaded && s.LiveMasterPlaylistFilename() != "" {
+		masterLocalPath := path.Join(s.LocalDir, s.LiveMasterPlaylistFilename())
+		masterStoragePath := path.Join(s.StorageDir, s.LiveMasterPlaylistFilename())
+		if _, _, err = s.Upload(masterLocalPath, masterStoragePath, types.OutputTypeHLS, false); err != nil {
+			return err
+		}
+		s.liveMasterUploaded = true
 	}
+	return nil
+}
+
+func (s *SegmentSink) uploadMPD() error {
+	localPath := path.Join(s.LocalDir, s.MPDFilename())
+	storagePath := path.Join(s.StorageDir, s.MPDFilename())
+	_, _, err := s.Upload(localPath, storagePath, types.OutputTypeDASH, false)
 	return err
 }
 
+func (s *SegmentSink) uploadLiveMPD() error {
+	localPath := path.Join(s.LocalDir, s.LiveMPDFilename())
+	storagePath := path.Join(s.StorageDir, s.LiveMPDFilename())
+	_, _, err := s.Upload(localPath, storagePath, types.OutputTypeDASH, false)
+	return err
+}
+
+func getMPDRepresentation(conf *config.PipelineConfig) mpd.Representation {
+	representation := mpd.Representation{
+		MimeType: "audio/mp4",
+	}
+
+	if conf.VideoEnabled {
+		representation.MimeType = "video/mp4"
+		representation.Width = conf.Width
+		representation.Height = conf.Height
+		representation.Bandwidth += int(conf.VideoBitrate) * 1000
+	}
+	if conf.AudioEnabled {
+		representation.Bandwidth += int(conf.AudioBitrate) * 1000
+	}
+	representation.Codecs = getCodecs(conf, types.VideoFormat{
+		Width:     conf.Width,
+		Height:    conf.Height,
+		Framerate: conf.Framerate,
+		Bitrate:   conf.VideoBitrate,
+	})
+
+	return representation
+}
+
+// getCodecs returns the codecs of the muxed segments, with the video level of the given format
+func getCodecs(conf *config.PipelineConfig, format types.VideoFormat) string {
+	var codecs []string
+	if conf.VideoEnabled {
+		codecs = append(codecs, types.CodecString(conf.VideoOutCodec, conf.VideoProfile, format))
+	}
+	if conf.AudioEnabled {
+		codecs = append(codecs, types.CodecString(conf.AudioOutCodec, "", types.VideoFormat{}))
+	}
+	return strings.Join(codecs, ",")
+}
+
+// writeMasterPlaylist lists the main output and each of its adaptive bitrate renditions
+func writeMasterPlaylist(conf *config.PipelineConfig, o *config.SegmentConfig, live bool) error {
+	representation := getMPDRepresentation(conf)
+	audioBandwidth := 0
+	if conf.AudioEnabled {
+		audioBandwidth = int(conf.AudioBitrate) * 1000
+	}
+
+	filename := o.MasterPlaylistFilename()
+	uri := o.PlaylistFilename
+	if live {
+		filename = o.LiveMasterPlaylistFilename()
+		uri = o.LivePlaylistFilename
+	}
+
+	variants := []*m3u8.Variant{{
+		URI:       uri,
+		Bandwidth: representation.Bandwidth,
+		Width:     representation.Width,
+		Height:    representation.Height,
+		Codecs:    representation.Codecs,
+		FrameRate: conf.Framerate,
+	}}
+	for _, v := range o.Variants {
+		videoBitrate := v.Rendition.VideoBitrate
+		uri = v.PlaylistFilename
+		if live {
+			uri = v.LivePlaylistFilename
+		}
+		variants = append(variants, &m3u8.Variant{
+			URI:       uri,
+			Bandwidth: int(videoBitrate)*1000 + audioBandwidth,
+			Width:     v.Rendition.Width,
+			Height:    v.Rendition.Height,
+			Codecs: getCodecs(conf, types.VideoFormat{
+				Width:     v.Rendition.Width,
+				Height:    v.Rendition.Height,
+				Framerate: conf.Framerate,
+				Bitrate:   videoBitrate,
+			}),
+			FrameRate: conf.Framerate,
+		})
+	}
+
+	return m3u8.WriteMasterPlaylist(path.Join(o.LocalDir, filename), variants)
+}
+
 func (s *SegmentSink) UpdateStartDate(t time.Time) {
 	s.segmentLock.Lock()
 	defer s.segmentLock.Unlock()
@@ -301,18 +579,23 @@ func (s *SegmentSink) FragmentOpened(filepath string, startTime uint64) error {
 }
 
 func (s *SegmentSink) FragmentClosed(filepath string, endTime uint64) error {
+	return s.queueClosedSegment(filepath, SegmentUpdate{
+		endTime: endTime,
+	})
+}
+
+// queueClosedSegment queues a closed segment or part for upload
+func (s *SegmentSink) queueClosedSegment(filepath string, update SegmentUpdate) error {
 	if !strings.HasPrefix(filepath, s.LocalDir) {
 		return fmt.Errorf("invalid filepath")
 	}
 
 	filename := filepath[len(s.LocalDir)+1:]
+	update.filename = filename
+	update.uploadComplete = make(chan struct{})
 
 	select {
-	case s.closedSegments <- SegmentUpdate{
-		filename:       filename,
-		endTime:        endTime,
-		uploadComplete: make(chan struct{}),
-	}:
+	case s.closedSegments <- update:
 		return nil
 
 	default:
@@ -337,6 +620,10 @@ func (s *SegmentSink) UploadManifest(filepath string) (string, bool, error) {
 }
 
 func (s *SegmentSink) Close() error {
+	if s.segmenter != nil {
+		s.closeSegmenter()
+	}
+
 	// wait for pending jobs to finish
 	close(s.closedSegments)
 	<-s.done.Watch()
@@ -360,5 +647,23 @@ func (s *SegmentSink) Close() error {
 		}
 	}
 
+	if s.dashManifest != nil {
+		if err := s.dashManifest.Close(); err != nil {
+			return err
+		}
+		if err := s.uploadMPD(); err != nil {
+			return err
+		}
+	}
+
+	if s.liveDashManifest != nil {
+		if err := s.liveDashManifest.Close(); err != nil {
+			return err
+		}
+		if err := s.uploadLiveMPD(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
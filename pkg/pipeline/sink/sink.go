@@ -59,11 +59,20 @@ func NewSink(
 		return newStreamSink(p, conf, o.(*config.StreamConfig))
 
 	case types.EgressTypeWebsocket:
-		return newWebsocketSink(p, o.(*config.StreamConfig), types.MimeTypeRawAudio, callbacks)
+		return newWebsocketSink(p, conf, o.(*config.StreamConfig), callbacks)
+
+	case types.EgressTypeWHIP:
+		return newWHIPSink(p, conf, o.(*config.StreamConfig), callbacks)
+
+	case types.EgressTypeHLSPush:
+		return newHLSPushSink(p, conf, o.(*config.StreamConfig), callbacks, monitor)
 
 	case types.EgressTypeImages:
 		return newImageSink(p, conf, o.(*config.ImageConfig), callbacks, monitor)
 
+	case types.EgressTypePreview:
+		return newPreviewSink(p, conf, o.(*config.PreviewConfig), monitor)
+
 	default:
 		return nil, errors.ErrInvalidInput("output type")
 	}
@@ -0,0 +1,164 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/egress/pkg/types"
+)
+
+const spriteJPEGQuality = 85
+
+// spriteSheetWriter tiles captured frames into sprite sheets, and maps each frame's time range
+// to its position within a sheet in a WebVTT thumbnail track
+type spriteSheetWriter struct {
+	localDir   string
+	prefix     string
+	outputType types.OutputType
+	columns    int
+	rows       int
+	interval   time.Duration
+	quality    int
+
+	sheet      *image.RGBA
+	tileWidth  int
+	tileHeight int
+	tiles      int
+	sheetIndex int
+	cues       strings.Builder
+}
+
+func newSpriteSheetWriter(localDir, prefix string, outputType types.OutputType, columns, rows int, interval time.Duration, quality int) *spriteSheetWriter {
+	if quality <= 0 {
+		quality = spriteJPEGQuality
+	}
+	return &spriteSheetWriter{
+		localDir:   localDir,
+		prefix:     prefix,
+		outputType: outputType,
+		columns:    columns,
+		rows:       rows,
+		interval:   interval,
+		quality:    quality,
+	}
+}
+
+// AddFrame adds the frame captured at offset to the current sheet.
+// It returns the filename of the sheet once it is full and has been written.
+func (w *spriteSheetWriter) AddFrame(framePath string, offset time.Duration) (string, error) {
+	// frames are written by the pipeline as uncompressed png
+	frame, err := decodeImage(framePath)
+	if err != nil {
+		return "", err
+	}
+
+	if w.sheet == nil {
+		if w.tileWidth == 0 {
+			// every tile has the size of the first frame
+			w.tileWidth = frame.Bounds().Dx()
+			w.tileHeight = frame.Bounds().Dy()
+		}
+		w.sheet = image.NewRGBA(image.Rect(0, 0, w.tileWidth*w.columns, w.tileHeight*w.rows))
+	}
+
+	x := (w.tiles % w.columns) * w.tileWidth
+	y := (w.tiles / w.columns) * w.tileHeight
+	draw.Draw(w.sheet, image.Rect(x, y, x+w.tileWidth, y+w.tileHeight), frame, frame.Bounds().Min, draw.Src)
+	w.tiles++
+
+	fmt.Fprintf(&w.cues, "%s --> %s\n%s#xywh=%d,%d,%d,%d\n\n",
+		formatVTTTimestamp(offset), formatVTTTimestamp(offset+w.interval),
+		w.sheetFilename(), x, y, w.tileWidth, w.tileHeight,
+	)
+
+	if w.tiles < w.columns*w.rows {
+		return "", nil
+	}
+	return w.Flush()
+}
+
+// Flush writes the current sheet, if it has any tiles
+func (w *spriteSheetWriter) Flush() (string, error) {
+	if w.tiles == 0 {
+		return "", nil
+	}
+
+	filename := w.sheetFilename()
+	if err := encodeImage(path.Join(w.localDir, filename), w.sheet, w.outputType, w.quality); err != nil {
+		return "", err
+	}
+
+	w.sheet = nil
+	w.tiles = 0
+	w.sheetIndex++
+	return filename, nil
+}
+
+// WriteVTT writes the thumbnail track, and returns its filename
+func (w *spriteSheetWriter) WriteVTT() (string, error) {
+	filename := w.prefix + types.FileExtensionVTT
+	if err := os.WriteFile(path.Join(w.localDir, filename), []byte("WEBVTT\n\n"+w.cues.String()), 0644); err != nil {
+		return "", err
+	}
+	return filename, nil
+}
+
+func (w *spriteSheetWriter) sheetFilename() string {
+	return fmt.Sprintf("%s_sprite_%05d%s", w.prefix, w.sheetIndex, types.FileExtensionForOutputType[w.outputType])
+}
+
+func decodeImage(filepath string) (image.Image, error) {
+	f, err := os.Open(filepath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+func encodeImage(filepath string, img image.Image, outputType types.OutputType, quality int) error {
+	f, err := os.Create(filepath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	switch outputType {
+	case types.OutputTypeJPEG:
+		return jpeg.Encode(f, img, &jpeg.Options{Quality: quality})
+	case types.OutputTypePNG:
+		return png.Encode(f, img)
+	default:
+		return errors.ErrNotSupported(fmt.Sprintf("%s sprite sheets", outputType))
+	}
+}
+
+// formatVTTTimestamp formats d as hh:mm:ss.ttt
+func formatVTTTimestamp(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/egress/pkg/types"
+)
+
+func writeTestFrame(t *testing.T, dir string, i int) string {
+	filepath := path.Join(dir, fmt.Sprintf("frame_%d.png", i))
+	f, err := os.Create(filepath)
+	require.NoError(t, err)
+	defer f.Close()
+
+	require.NoError(t, png.Encode(f, image.NewRGBA(image.Rect(0, 0, 16, 10))))
+	return filepath
+}
+
+func TestSpriteSheetWriter(t *testing.T) {
+	dir := t.TempDir()
+	w := newSpriteSheetWriter(dir, "thumbs", types.OutputTypeJPEG, 2, 1, 5*time.Second, 0)
+
+	filename, err := w.AddFrame(writeTestFrame(t, dir, 0), 0)
+	require.NoError(t, err)
+	require.Empty(t, filename)
+
+	filename, err = w.AddFrame(writeTestFrame(t, dir, 1), 5*time.Second)
+	require.NoError(t, err)
+	require.Equal(t, "thumbs_sprite_00000.jpeg", filename)
+
+	sheet, err := decodeImage(path.Join(dir, filename))
+	require.NoError(t, err)
+	require.Equal(t, 32, sheet.Bounds().Dx())
+	require.Equal(t, 10, sheet.Bounds().Dy())
+
+	// partial sheets are written on flush
+	_, err = w.AddFrame(writeTestFrame(t, dir, 2), 10*time.Second)
+	require.NoError(t, err)
+	filename, err = w.Flush()
+	require.NoError(t, err)
+	require.Equal(t, "thumbs_sprite_00001.jpeg", filename)
+
+	filename, err = w.Flush()
+	require.NoError(t, err)
+	require.Empty(t, filename)
+
+	filename, err = w.WriteVTT()
+	require.NoError(t, err)
+	require.Equal(t, "thumbs.vtt", filename)
+
+	b, err := os.ReadFile(path.Join(dir, filename))
+	require.NoError(t, err)
+	require.Equal(t, "WEBVTT\n\n"+
+		"00:00:00.000 --> 00:00:05.000\nthumbs_sprite_00000.jpeg#xywh=0,0,16,10\n\n"+
+		"00:00:05.000 --> 00:00:10.000\nthumbs_sprite_00000.jpeg#xywh=16,0,16,10\n\n"+
+		"00:00:10.000 --> 00:00:15.000\nthumbs_sprite_00001.jpeg#xywh=0,0,16,10\n\n",
+		string(b),
+	)
+}
+
+func TestFormatVTTTimestamp(t *testing.T) {
+	require.Equal(t, "00:00:00.000", formatVTTTimestamp(0))
+	require.Equal(t, "01:02:03.450", formatVTTTimestamp(time.Hour+2*time.Minute+3450*time.Millisecond))
+}
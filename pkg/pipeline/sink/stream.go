@@ -121,11 +121,11 @@ func (s *StreamSink) AddStream(stream *config.Stream) error {
 
 	s.mu.Unlock()
 
-	if s.bin.OutputType == types.OutputTypeRTMP {
+	if s.bin.OutputType == types.OutputTypeRTMP || s.bin.OutputType == types.OutputTypeRTSP {
 		ss.StartMonitor()
 	}
 
-	return s.bin.Bin.AddSinkBin(ss.Bin)
+	return s.bin.AddStream(ss)
 }
 
 func (s *StreamSink) GetStream(name string) (*config.Stream, error) {
@@ -162,7 +162,7 @@ func (s *StreamSink) RemoveStream(stream *config.Stream) error {
 
 	ss.StopMonitor()
 
-	return s.bin.Bin.RemoveSinkBin(stream.Name)
+	return s.bin.RemoveStream(ss)
 }
 
 func (s *StreamSink) UploadManifest(_ string) (string, bool, error) {
@@ -183,6 +183,28 @@ func (u *Uploader) Upload(
 	return "", 0, primaryErr
 }
 
+// Delete removes uploaded objects, from the backup storage as well once it is in use
+func (u *Uploader) Delete(storageFilepaths []string) error {
+	if u.disabled.Load() || len(storageFilepaths) == 0 {
+		return nil
+	}
+
+	stores := []*store{u.primary}
+	if u.primaryFailed {
+		stores = append(stores, u.backup)
+	}
+	for _, s := range stores {
+		paths := make([]string, 0, len(storageFilepaths))
+		for _, storageFilepath := range storageFilepaths {
+			paths = append(paths, path.Join(s.conf.Prefix, storageFilepath))
+		}
+		if err := s.DeleteObjects(paths); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func uploadErrorStatus(err error) string {
 	var statusErr *storage.ErrorWithStatusCode
 	if errors.As(err, &statusErr) {
@@ -18,13 +18,9 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
-	"strings"
-	"time"
 
 	"github.com/go-gst/go-gst/gst"
 	"github.com/go-gst/go-gst/gst/app"
-	"github.com/gorilla/websocket"
-	"github.com/linkdata/deadlock"
 	"go.uber.org/atomic"
 
 	"github.com/livekit/egress/pkg/config"
@@ -36,43 +32,92 @@ import (
 	"github.com/livekit/psrpc"
 )
 
-const pingPeriod = time.Second * 30
+// control actions sent by the receiving server as text messages, e.g. {"action":"pause"}
+const (
+	websocketActionPause     = "pause"      // drop audio until resumed
+	websocketActionResume    = "resume"     // send audio again
+	websocketActionEOS       = "eos"        // end the websocket output, and the egress if no other outputs remain
+	websocketActionMuteState = "mute_state" // reply with the current mute message
+)
+
+type controlMessagePayload struct {
+	Action string `json:"action"`
+}
 
 type WebsocketSink struct {
 	*base
 
-	mu            deadlock.Mutex
-	conn          *websocket.Conn
+	client        *websocketClient
+	stream        *config.Stream
 	sinkCallbacks *app.SinkCallbacks
-	closed        atomic.Bool
+	callbacks     *gstreamer.Callbacks
+
+	paused atomic.Bool
+	muted  atomic.Bool
 }
 
 func newWebsocketSink(
 	p *gstreamer.Pipeline,
+	conf *config.PipelineConfig,
 	o *config.StreamConfig,
-	mimeType types.MimeType,
 	callbacks *gstreamer.Callbacks,
 ) (*WebsocketSink, error) {
 
-	// set Content-Type header
-	header := http.Header{}
-	header.Set("Content-Type", string(mimeType))
-
-	var wsUrl string
-	o.Streams.Range(func(url, _ any) bool {
-		wsUrl = url.(string)
+	var stream *config.Stream
+	o.Streams.Range(func(_, s any) bool {
+		stream = s.(*config.Stream)
 		return false
 	})
-
-	conn, _, err := websocket.DefaultDialer.Dial(wsUrl, header)
+	if stream == nil {
+		return nil, errors.ErrInvalidInput("websocket url")
+	}
+	opts, err := config.ParseWebsocketUrl(stream.ParsedUrl)
 	if err != nil {
 		return nil, psrpc.NewError(psrpc.InvalidArgument, err)
 	}
 
+	// set Content-Type header
+	header := http.Header{}
+	header.Set("Content-Type", string(conf.AudioOutCodec))
+
+	// the first text message describes the audio format
+	sampleRate, channels := builder.WebsocketAudioFormat(conf)
+	format := &formatMessagePayload{
+		ContentType: string(conf.AudioOutCodec),
+		SampleRate:  sampleRate,
+		Channels:    channels,
+	}
+
 	websocketSink := &WebsocketSink{
-		base: &base{},
-		conn: conn,
+		base:      &base{},
+		stream:    stream,
+		callbacks: callbacks,
 	}
+
+	// the track may already be muted when the egress starts
+	muted := len(conf.AudioTracks) > 0
+	for _, ts := range conf.AudioTracks {
+		muted = muted && ts.Muted
+	}
+	websocketSink.muted.Store(muted)
+
+	websocketSink.client, err = newWebsocketClient(opts.Url, header, format, o.Reconnect,
+		websocketSink.handleControlMessage,
+		func() {
+			callbacks.OnStreamRetry(stream)
+		},
+		func() {
+			callbacks.OnStreamFinished(types.EgressTypeWebsocket, stream)
+		},
+		func(err error) {
+			logger.Warnw("websocket failed", err, "url", stream.RedactedUrl)
+			callbacks.OnStreamFailed(types.EgressTypeWebsocket, stream, err)
+		},
+	)
+	if err != nil {
+		return nil, psrpc.NewError(psrpc.InvalidArgument, err)
+	}
+
 	websocketSink.sinkCallbacks = &app.SinkCallbacks{
 		EOSFunc: func(_ *app.Sink) {
 			_ = websocketSink.Close()
@@ -93,13 +138,9 @@ func newWebsocketSink(
 			// map the buffer to READ operation
 			samples := buffer.Map(gst.MapRead).Bytes()
 
-			// send to writer
-			_, err = websocketSink.Write(samples)
-			if err != nil {
-				if err == io.EOF {
-					return gst.FlowEOS
-				}
-				callbacks.OnError(psrpc.NewError(psrpc.Unavailable, errors.MarkDestinationError(err)))
+			// send to writer, which buffers or drops audio while reconnecting
+			if _, err := websocketSink.Write(samples); err == io.EOF {
+				return gst.FlowEOS
 			}
 
 			return gst.FlowOK
@@ -110,9 +151,11 @@ func newWebsocketSink(
 
 	websocketSink.bin, err = builder.BuildWebsocketBin(p, websocketSink.sinkCallbacks)
 	if err != nil {
+		websocketSink.client.Close()
 		return nil, err
 	}
 	if err = p.AddSinkBin(websocketSink.bin); err != nil {
+		websocketSink.client.Close()
 		return nil, err
 	}
 
@@ -120,101 +163,68 @@ func newWebsocketSink(
 }
 
 func (s *WebsocketSink) Start() error {
-	// override default ping handler to include locking
-	s.conn.SetPingHandler(func(_ string) error {
-		s.mu.Lock()
-		defer s.mu.Unlock()
-
-		_ = s.conn.WriteMessage(websocket.PongMessage, []byte("pong"))
-		return nil
-	})
-
-	// read loop is required for the ping handler to receive pings
-	go func() {
-		errCount := 0
-		for {
-			_, _, err := s.conn.ReadMessage()
-			if s.closed.Load() {
-				return
-			}
-			if err != nil {
-				var closeError *websocket.CloseError
-				if errors.As(err, &closeError) ||
-					errors.Is(err, io.EOF) ||
-					strings.HasSuffix(err.Error(), "use of closed network connection") {
-					return
-				}
-				errCount++
-			}
-			// reads will panic after 1000 errors, break loop before that happens
-			if errCount > 100 {
-				logger.Errorw("closing websocket reader", err)
-				return
-			}
-		}
-	}()
-
 	// write loop for sending pings
-	go func() {
-		ticker := time.NewTicker(pingPeriod)
-		defer ticker.Stop()
-
-		for {
-			<-ticker.C
-			s.mu.Lock()
-			if s.closed.Load() {
-				s.mu.Unlock()
-				return
-			}
-			_ = s.conn.WriteMessage(websocket.PingMessage, []byte("ping"))
-			s.mu.Unlock()
-		}
-	}()
-
+	go s.client.pingLoop()
 	return nil
 }
 
 func (s *WebsocketSink) Write(p []byte) (int, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	if s.closed.Load() {
-		return 0, io.EOF
+	if s.paused.Load() {
+		return len(p), nil
 	}
-
-	return len(p), s.conn.WriteMessage(websocket.BinaryMessage, p)
+	return s.client.Write(p)
 }
 
 func (s *WebsocketSink) OnTrackMuted(_ string) {
+	s.muted.Store(true)
 	if err := s.writeMutedMessage(true); err != nil {
 		logger.Errorw("failed to write mute message", err)
 	}
 }
 
 func (s *WebsocketSink) OnTrackUnmuted(_ string) {
+	s.muted.Store(false)
 	if err := s.writeMutedMessage(false); err != nil {
 		logger.Errorw("failed to write unmute message", err)
 	}
 }
 
-type textMessagePayload struct {
-	Muted bool `json:"muted"`
-}
-
 func (s *WebsocketSink) writeMutedMessage(muted bool) error {
-	data, err := json.Marshal(&textMessagePayload{
+	return s.client.writeText(&textMessagePayload{
 		Muted: muted,
 	})
-	if err != nil {
-		return err
-	}
+}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	if s.closed.Load() {
-		return nil
+// handleControlMessage acts on requests from the receiving server. Unknown messages are ignored.
+func (s *WebsocketSink) handleControlMessage(data []byte) {
+	var msg controlMessagePayload
+	if err := json.Unmarshal(data, &msg); err != nil {
+		logger.Debugw("ignoring websocket message", "error", err)
+		return
 	}
 
-	return s.conn.WriteMessage(websocket.TextMessage, data)
+	switch msg.Action {
+	case websocketActionPause:
+		logger.Infow("websocket receiver paused audio")
+		s.paused.Store(true)
+
+	case websocketActionResume:
+		logger.Infow("websocket receiver resumed audio")
+		s.paused.Store(false)
+
+	case websocketActionEOS:
+		logger.Infow("websocket receiver requested end of stream")
+		s.client.Close()
+		s.callbacks.OnStreamFinished(types.EgressTypeWebsocket, s.stream)
+
+	case websocketActionMuteState:
+		if err := s.writeMutedMessage(s.muted.Load()); err != nil {
+			logger.Errorw("failed to write mute message", err)
+		}
+
+	default:
+		logger.Debugw("ignoring websocket message", "action", msg.Action)
+	}
 }
 
 func (s *WebsocketSink) UploadManifest(_ string) (string, bool, error) {
@@ -224,17 +234,6 @@ func (s *WebsocketSink) UploadManifest(_ string) (string, bool, error) {
 func (s *WebsocketSink) DisableUploads() {}
 
 func (s *WebsocketSink) Close() error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	if !s.closed.Swap(true) {
-		logger.Debugw("closing websocket connection")
-
-		// write close message for graceful disconnection
-		_ = s.conn.WriteMessage(websocket.CloseMessage, nil)
-
-		// terminate connection and close the `closed` channel
-		_ = s.conn.Close()
-	}
-
+	s.client.Close()
 	return nil
 }
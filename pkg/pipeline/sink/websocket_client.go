@@ -0,0 +1,379 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/frostbyte73/core"
+	"github.com/gorilla/websocket"
+	"github.com/linkdata/deadlock"
+
+	"github.com/livekit/egress/pkg/config"
+	"github.com/livekit/protocol/logger"
+)
+
+const (
+	pingPeriod = time.Second * 30
+
+	websocketMinBackoff = 500 * time.Millisecond
+)
+
+type textMessagePayload struct {
+	Muted bool `json:"muted"`
+}
+
+type formatMessagePayload struct {
+	ContentType string `json:"contentType"`
+	SampleRate  int32  `json:"sampleRate"`
+	Channels    int    `json:"channels"`
+}
+
+type resumeMessagePayload struct {
+	Resumed    bool  `json:"resumed"`
+	GapMs      int64 `json:"gapMs"`      // audio missing from the stream
+	BufferedMs int64 `json:"bufferedMs"` // audio captured while disconnected, sent after this message
+}
+
+type bufferedMessage struct {
+	data       []byte
+	receivedAt time.Time
+}
+
+// websocketClient sends audio to a websocket server. When the connection drops, audio is buffered
+// or dropped while it reconnects with backoff, and the server is told how much audio is missing.
+// Connections closed by the server with a normal or going away close code are not reconnected.
+type websocketClient struct {
+	url       string
+	header    http.Header
+	format    *formatMessagePayload
+	reconnect config.WebsocketReconnectConfig
+	onText    func([]byte)
+	onRetry   func()
+	onClose   func()
+	onFailure func(error)
+
+	mu             deadlock.Mutex
+	conn           *websocket.Conn
+	reconnecting   bool
+	disconnectedAt time.Time
+	buffered       []bufferedMessage
+
+	ended  core.Fuse // the server closed the connection, or reconnecting failed
+	closed core.Fuse
+}
+
+func newWebsocketClient(
+	url string,
+	header http.Header,
+	format *formatMessagePayload,
+	reconnect config.WebsocketReconnectConfig,
+	onText func([]byte),
+	onRetry func(),
+	onClose func(),
+	onFailure func(error),
+) (*websocketClient, error) {
+	c := &websocketClient{
+		url:       url,
+		header:    header,
+		format:    format,
+		reconnect: reconnect,
+		onText:    onText,
+		onRetry:   onRetry,
+		onClose:   onClose,
+		onFailure: onFailure,
+	}
+
+	conn, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+	c.conn = conn
+	go c.readLoop(conn)
+
+	return c, nil
+}
+
+// dial opens a connection, and describes the audio format in the first text message
+func (c *websocketClient) dial() (*websocket.Conn, error) {
+	conn, _, err := websocket.DefaultDialer.Dial(c.url, c.header)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(c.format)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	if err = conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	// override default ping handler to include locking
+	conn.SetPingHandler(func(_ string) error {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		_ = conn.WriteMessage(websocket.PongMessage, []byte("pong"))
+		return nil
+	})
+
+	return conn, nil
+}
+
+// readLoop passes text messages from the server to the handler, and is required for the ping handler
+// to receive pings. Read errors are permanent, so any error means the connection has been closed or dropped.
+func (c *websocketClient) readLoop(conn *websocket.Conn) {
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			c.mu.Lock()
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				c.endLocked(conn)
+			} else {
+				c.disconnectLocked(conn, err)
+			}
+			c.mu.Unlock()
+			return
+		}
+		if messageType == websocket.TextMessage && c.onText != nil {
+			c.onText(data)
+		}
+	}
+}
+
+// pingLoop sends pings while the client is open
+func (c *websocketClient) pingLoop() {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.closed.Watch():
+			return
+		case <-ticker.C:
+			c.mu.Lock()
+			if c.conn != nil {
+				_ = c.conn.WriteMessage(websocket.PingMessage, []byte("ping"))
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+func (c *websocketClient) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed.IsBroken() {
+		return 0, io.EOF
+	}
+
+	if c.conn != nil {
+		err := c.conn.WriteMessage(websocket.BinaryMessage, p)
+		if err == nil {
+			return len(p), nil
+		}
+		// the server has sent a close message, which is handled by the read loop
+		if err != websocket.ErrCloseSent {
+			c.disconnectLocked(c.conn, err)
+		}
+	}
+
+	// once ended, audio is dropped until the output has been removed
+	if !c.ended.IsBroken() {
+		c.bufferLocked(p)
+	}
+	return len(p), nil
+}
+
+func (c *websocketClient) writeText(payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed.IsBroken() || c.conn == nil {
+		return nil
+	}
+
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// bufferLocked keeps audio received during the buffer window
+func (c *websocketClient) bufferLocked(p []byte) {
+	if c.reconnect.BufferWindow <= 0 {
+		return
+	}
+
+	now := time.Now()
+	dropped := 0
+	for dropped < len(c.buffered) && now.Sub(c.buffered[dropped].receivedAt) > c.reconnect.BufferWindow {
+		dropped++
+	}
+	c.buffered = append(c.buffered[dropped:], bufferedMessage{
+		data:       append([]byte(nil), p...),
+		receivedAt: now,
+	})
+}
+
+// endLocked closes a connection which the server has closed on purpose, without reconnecting
+func (c *websocketClient) endLocked(conn *websocket.Conn) {
+	if c.conn != conn || c.closed.IsBroken() {
+		return
+	}
+	_ = conn.Close()
+	c.conn = nil
+
+	logger.Infow("websocket closed by server")
+	c.ended.Once(func() {
+		c.buffered = nil
+		if c.onClose != nil {
+			go c.onClose()
+		}
+	})
+}
+
+// disconnectLocked closes a dropped connection, and starts reconnecting
+func (c *websocketClient) disconnectLocked(conn *websocket.Conn, err error) {
+	if c.conn != conn || c.closed.IsBroken() {
+		return
+	}
+	_ = conn.Close()
+	c.conn = nil
+	c.disconnectedAt = time.Now()
+
+	if c.reconnect.MaxAttempts < 0 {
+		c.fail(err)
+		return
+	}
+	if !c.reconnecting {
+		c.reconnecting = true
+		logger.Warnw("websocket disconnected", err)
+		go c.reconnectLoop(err)
+	}
+}
+
+// reconnectLoop dials with backoff until a connection succeeds, or the attempts run out
+func (c *websocketClient) reconnectLoop(streamErr error) {
+	backoff := websocketMinBackoff
+	for attempt := 1; attempt <= c.reconnect.MaxAttempts; attempt++ {
+		select {
+		case <-c.closed.Watch():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, c.reconnect.MaxBackoff)
+
+		if c.onRetry != nil {
+			c.onRetry()
+		}
+		conn, err := c.dial()
+		if err != nil {
+			logger.Debugw("failed to reconnect websocket", "error", err, "attempt", attempt)
+			streamErr = err
+			continue
+		}
+
+		c.mu.Lock()
+		err = c.resumeLocked(conn)
+		c.mu.Unlock()
+		if err != nil {
+			streamErr = err
+			continue
+		}
+		go c.readLoop(conn)
+		return
+	}
+
+	c.mu.Lock()
+	c.reconnecting = false
+	c.fail(streamErr)
+	c.mu.Unlock()
+}
+
+// resumeLocked tells the server how much audio is missing, and sends the buffered audio
+func (c *websocketClient) resumeLocked(conn *websocket.Conn) error {
+	if c.closed.IsBroken() {
+		_ = conn.Close()
+		return nil
+	}
+
+	now := time.Now()
+	gap := now.Sub(c.disconnectedAt)
+	var buffered time.Duration
+	if len(c.buffered) > 0 {
+		buffered = min(now.Sub(c.buffered[0].receivedAt), gap)
+	}
+
+	data, err := json.Marshal(&resumeMessagePayload{
+		Resumed:    true,
+		GapMs:      (gap - buffered).Milliseconds(),
+		BufferedMs: buffered.Milliseconds(),
+	})
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	if err = conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		_ = conn.Close()
+		return err
+	}
+	for _, msg := range c.buffered {
+		if err = conn.WriteMessage(websocket.BinaryMessage, msg.data); err != nil {
+			_ = conn.Close()
+			return err
+		}
+	}
+
+	logger.Infow("websocket reconnected", "gap", gap, "buffered", buffered)
+	c.conn = conn
+	c.reconnecting = false
+	c.buffered = nil
+	return nil
+}
+
+// fail is called with the lock held, and the failure callback is called without it
+func (c *websocketClient) fail(err error) {
+	c.ended.Once(func() {
+		c.buffered = nil
+		go c.onFailure(err)
+	})
+}
+
+func (c *websocketClient) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed.Once(func() {
+		c.buffered = nil
+		if c.conn == nil {
+			return
+		}
+		logger.Debugw("closing websocket connection")
+
+		// write close message for graceful disconnection
+		_ = c.conn.WriteMessage(websocket.CloseMessage, nil)
+
+		// terminate connection
+		_ = c.conn.Close()
+	})
+}
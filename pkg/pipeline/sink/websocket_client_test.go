@@ -0,0 +1,300 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/egress/pkg/config"
+	"github.com/livekit/egress/pkg/gstreamer"
+	"github.com/livekit/egress/pkg/types"
+)
+
+// testWebsocketServer records the messages received on each connection
+type testWebsocketServer struct {
+	reject atomic.Bool
+
+	mu       sync.Mutex
+	conns    []*websocket.Conn
+	messages [][]string
+}
+
+func (s *testWebsocketServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.reject.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	idx := len(s.conns)
+	s.conns = append(s.conns, conn)
+	s.messages = append(s.messages, nil)
+	s.mu.Unlock()
+
+	go func() {
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.messages[idx] = append(s.messages[idx], string(msg))
+			s.mu.Unlock()
+		}
+	}()
+}
+
+// drop closes the latest connection without a close message, as a restarting load balancer would
+func (s *testWebsocketServer) drop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = s.conns[len(s.conns)-1].Close()
+}
+
+// close closes the latest connection with a close message
+func (s *testWebsocketServer) close(code int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conns[len(s.conns)-1].WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(code, ""), time.Now().Add(time.Second))
+}
+
+// send writes a text message on the latest connection
+func (s *testWebsocketServer) send(msg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conns[len(s.conns)-1].WriteMessage(websocket.TextMessage, []byte(msg))
+}
+
+func (s *testWebsocketServer) received(conn int) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if conn >= len(s.messages) {
+		return nil
+	}
+	return append([]string(nil), s.messages[conn]...)
+}
+
+func newTestWebsocketClient(
+	t *testing.T,
+	reconnect config.WebsocketReconnectConfig,
+	onText func([]byte),
+	onClose func(),
+) (*testWebsocketServer, *websocketClient, *atomic.Int32) {
+	server := &testWebsocketServer{}
+	s := httptest.NewServer(server)
+	t.Cleanup(s.Close)
+
+	var failures atomic.Int32
+	client, err := newWebsocketClient(
+		"ws"+strings.TrimPrefix(s.URL, "http"),
+		http.Header{"Content-Type": []string{"audio/pcmu"}},
+		&formatMessagePayload{ContentType: "audio/pcmu", SampleRate: 8000, Channels: 1},
+		reconnect,
+		onText,
+		nil,
+		onClose,
+		func(error) { failures.Inc() },
+	)
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+
+	return server, client, &failures
+}
+
+func waitForDisconnect(t *testing.T, client *websocketClient) {
+	require.Eventually(t, func() bool {
+		client.mu.Lock()
+		defer client.mu.Unlock()
+		return client.conn == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestWebsocketClientReconnect(t *testing.T) {
+	server, client, failures := newTestWebsocketClient(t, config.WebsocketReconnectConfig{
+		MaxAttempts:  3,
+		MaxBackoff:   time.Second,
+		BufferWindow: 10 * time.Second,
+	}, nil, nil)
+
+	_, err := client.Write([]byte("a"))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return len(server.received(0)) == 2 }, time.Second, 10*time.Millisecond)
+	require.Equal(t, `{"contentType":"audio/pcmu","sampleRate":8000,"channels":1}`, server.received(0)[0])
+	require.Equal(t, "a", server.received(0)[1])
+
+	// audio is buffered while reconnecting
+	server.drop()
+	waitForDisconnect(t, client)
+	_, err = client.Write([]byte("b"))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return len(server.received(1)) == 3 }, 2*time.Second, 10*time.Millisecond)
+	messages := server.received(1)
+	require.Equal(t, server.received(0)[0], messages[0])
+
+	var resume resumeMessagePayload
+	require.NoError(t, json.Unmarshal([]byte(messages[1]), &resume))
+	require.True(t, resume.Resumed)
+	require.GreaterOrEqual(t, resume.GapMs+resume.BufferedMs, int64(500))
+	require.Equal(t, "b", messages[2])
+
+	_, err = client.Write([]byte("c"))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return len(server.received(1)) == 4 }, time.Second, 10*time.Millisecond)
+	require.Zero(t, failures.Load())
+}
+
+func TestWebsocketClientDropsAudio(t *testing.T) {
+	server, client, _ := newTestWebsocketClient(t, config.WebsocketReconnectConfig{
+		MaxAttempts: 3,
+		MaxBackoff:  time.Second,
+	}, nil, nil)
+
+	server.drop()
+	waitForDisconnect(t, client)
+	_, err := client.Write([]byte("a"))
+	require.NoError(t, err)
+
+	// without a buffer window, the whole outage is reported as missing audio
+	require.Eventually(t, func() bool { return len(server.received(1)) == 2 }, 2*time.Second, 10*time.Millisecond)
+	var resume resumeMessagePayload
+	require.NoError(t, json.Unmarshal([]byte(server.received(1)[1]), &resume))
+	require.True(t, resume.Resumed)
+	require.GreaterOrEqual(t, resume.GapMs, int64(500))
+	require.Zero(t, resume.BufferedMs)
+
+	time.Sleep(100 * time.Millisecond)
+	require.Len(t, server.received(1), 2)
+}
+
+func TestWebsocketClientFailure(t *testing.T) {
+	server, client, failures := newTestWebsocketClient(t, config.WebsocketReconnectConfig{
+		MaxAttempts: 2,
+		MaxBackoff:  time.Second,
+	}, nil, nil)
+
+	server.reject.Store(true)
+	server.drop()
+	require.Eventually(t, func() bool { return failures.Load() == 1 }, 3*time.Second, 10*time.Millisecond)
+
+	_, err := client.Write([]byte("a"))
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failures.Load())
+}
+
+func TestWebsocketClientReconnectDisabled(t *testing.T) {
+	server, client, failures := newTestWebsocketClient(t, config.WebsocketReconnectConfig{
+		MaxAttempts: -1,
+	}, nil, nil)
+
+	server.drop()
+	require.Eventually(t, func() bool { return failures.Load() == 1 }, time.Second, 10*time.Millisecond)
+
+	client.mu.Lock()
+	require.False(t, client.reconnecting)
+	client.mu.Unlock()
+}
+
+func TestWebsocketClientServerClose(t *testing.T) {
+	for _, code := range []int{websocket.CloseNormalClosure, websocket.CloseGoingAway} {
+		var closes atomic.Int32
+		server, client, failures := newTestWebsocketClient(t, config.WebsocketReconnectConfig{
+			MaxAttempts:  3,
+			MaxBackoff:   time.Second,
+			BufferWindow: 10 * time.Second,
+		}, nil, func() { closes.Inc() })
+
+		// the stream ends without reconnecting
+		require.NoError(t, server.close(code))
+		require.Eventually(t, func() bool { return closes.Load() == 1 }, time.Second, 10*time.Millisecond)
+		_, err := client.Write([]byte("a"))
+		require.NoError(t, err)
+
+		time.Sleep(time.Second)
+		require.Nil(t, server.received(1))
+		require.Zero(t, failures.Load())
+		client.mu.Lock()
+		require.False(t, client.reconnecting)
+		require.Empty(t, client.buffered)
+		client.mu.Unlock()
+	}
+}
+
+func TestWebsocketSinkControlMessages(t *testing.T) {
+	var sink *WebsocketSink
+	server, client, _ := newTestWebsocketClient(t, config.WebsocketReconnectConfig{
+		MaxAttempts: -1,
+	}, func(data []byte) { sink.handleControlMessage(data) }, nil)
+
+	stream := &config.Stream{ParsedUrl: "wss://agent.example.com/audio"}
+	var finished atomic.Pointer[config.Stream]
+	callbacks := &gstreamer.Callbacks{}
+	callbacks.SetOnStreamFinished(func(egressType types.EgressType, s *config.Stream) {
+		if egressType == types.EgressTypeWebsocket {
+			finished.Store(s)
+		}
+	})
+	sink = &WebsocketSink{
+		base:      &base{},
+		client:    client,
+		stream:    stream,
+		callbacks: callbacks,
+	}
+	sink.muted.Store(true)
+
+	// audio is dropped while paused
+	require.NoError(t, server.send(`{"action":"pause"}`))
+	require.Eventually(t, sink.paused.Load, time.Second, 10*time.Millisecond)
+	_, err := sink.Write([]byte("a"))
+	require.NoError(t, err)
+
+	require.NoError(t, server.send(`{"action":"resume"}`))
+	require.Eventually(t, func() bool { return !sink.paused.Load() }, time.Second, 10*time.Millisecond)
+	_, err = sink.Write([]byte("b"))
+	require.NoError(t, err)
+
+	// unknown and malformed messages are ignored
+	require.NoError(t, server.send(`{"action":"rewind"}`))
+	require.NoError(t, server.send(`not json`))
+
+	require.NoError(t, server.send(`{"action":"mute_state"}`))
+	require.Eventually(t, func() bool { return len(server.received(0)) == 3 }, time.Second, 10*time.Millisecond)
+	require.Equal(t, "b", server.received(0)[1])
+	require.Equal(t, `{"muted":true}`, server.received(0)[2])
+
+	// eos only ends the websocket output
+	require.NoError(t, server.send(`{"action":"eos"}`))
+	require.Eventually(t, func() bool { return finished.Load() == stream }, time.Second, 10*time.Millisecond)
+	_, err = sink.Write([]byte("c"))
+	require.ErrorIs(t, err, io.EOF)
+}
@@ -0,0 +1,204 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-gst/go-gst/gst"
+	"github.com/go-gst/go-gst/gst/app"
+	"github.com/pion/webrtc/v4"
+	"github.com/pion/webrtc/v4/pkg/media"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/egress/pkg/config"
+	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/egress/pkg/gstreamer"
+	"github.com/livekit/egress/pkg/pipeline/builder"
+	"github.com/livekit/egress/pkg/types"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/psrpc"
+)
+
+// keyframes requested by the endpoint are forced at most this often, since every receiver may ask for one
+const whipKeyFrameRequestInterval = 500 * time.Millisecond
+
+type WHIPSink struct {
+	*base
+
+	client    *whipClient
+	videoSink *gst.Element
+
+	lastKeyFrameRequest atomic.Time
+}
+
+func newWHIPSink(
+	p *gstreamer.Pipeline,
+	conf *config.PipelineConfig,
+	o *config.StreamConfig,
+	callbacks *gstreamer.Callbacks,
+) (*WHIPSink, error) {
+	var stream *config.Stream
+	o.Streams.Range(func(_, s any) bool {
+		stream = s.(*config.Stream)
+		return false
+	})
+	if stream == nil {
+		return nil, errors.ErrInvalidInput("whip url")
+	}
+
+	endpoint, token, err := config.WHIPEndpoint(stream.ParsedUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	var tracks []webrtc.TrackLocal
+	var audioCallbacks, videoCallbacks *app.SinkCallbacks
+	if conf.AudioEnabled {
+		track, err := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{
+			MimeType:  webrtc.MimeTypeOpus,
+			ClockRate: 48000,
+			Channels:  2,
+		}, "audio", conf.Info.EgressId)
+		if err != nil {
+			return nil, err
+		}
+		tracks = append(tracks, track)
+		audioCallbacks = newWHIPSinkCallbacks(track)
+	}
+	if conf.VideoEnabled {
+		capability := webrtc.RTPCodecCapability{
+			MimeType:    webrtc.MimeTypeH264,
+			ClockRate:   90000,
+			SDPFmtpLine: whipH264Fmtp(conf),
+		}
+		if conf.VideoOutCodec == types.MimeTypeVP8 {
+			capability = webrtc.RTPCodecCapability{
+				MimeType:  webrtc.MimeTypeVP8,
+				ClockRate: 90000,
+			}
+		}
+		track, err := webrtc.NewTrackLocalStaticSample(capability, "video", conf.Info.EgressId)
+		if err != nil {
+			return nil, err
+		}
+		tracks = append(tracks, track)
+		videoCallbacks = newWHIPSinkCallbacks(track)
+	}
+
+	whipSink := &WHIPSink{
+		base: &base{},
+	}
+	whipSink.bin, whipSink.videoSink, err = builder.BuildWHIPBin(p, conf, audioCallbacks, videoCallbacks)
+	if err != nil {
+		return nil, err
+	}
+
+	// a failed session only removes this output, the egress fails once no outputs remain
+	whipSink.client = newWHIPClient(endpoint, token, stream.RedactedUrl, tracks, func(err error) {
+		logger.Warnw("whip stream failed", err, "url", stream.RedactedUrl)
+		callbacks.OnStreamFailed(types.EgressTypeWHIP, stream, err)
+	}, whipSink.requestKeyFrame)
+
+	// a bad url or token fails the request
+	if err = whipSink.client.connect(); err != nil {
+		return nil, psrpc.NewError(psrpc.InvalidArgument, err)
+	}
+
+	if err = p.AddSinkBin(whipSink.bin); err != nil {
+		whipSink.client.close()
+		return nil, err
+	}
+
+	return whipSink, nil
+}
+
+// whipH264Fmtp signals constrained baseline, the h264 profile supported by every webrtc endpoint,
+// at the lowest level supporting the encoded format
+func whipH264Fmtp(conf *config.PipelineConfig) string {
+	level := types.H264Level(types.ProfileBaseline, types.VideoFormat{
+		Width:     conf.Width,
+		Height:    conf.Height,
+		Framerate: conf.Framerate,
+		Bitrate:   conf.VideoBitrate,
+	})
+	return fmt.Sprintf("level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e0%02x", level)
+}
+
+// requestKeyFrame forwards picture loss and full intra requests to the encoder
+func (s *WHIPSink) requestKeyFrame() {
+	if s.videoSink == nil {
+		return
+	}
+
+	now := time.Now()
+	if now.Sub(s.lastKeyFrameRequest.Load()) < whipKeyFrameRequestInterval {
+		return
+	}
+	s.lastKeyFrameRequest.Store(now)
+	builder.RequestKeyFrame(s.videoSink)
+}
+
+func newWHIPSinkCallbacks(track *webrtc.TrackLocalStaticSample) *app.SinkCallbacks {
+	lastPTS := gst.ClockTimeNone
+	return &app.SinkCallbacks{
+		NewSampleFunc: func(appSink *app.Sink) gst.FlowReturn {
+			sample := appSink.PullSample()
+			if sample == nil {
+				return gst.FlowOK
+			}
+			buffer := sample.GetBuffer()
+			if buffer == nil {
+				return gst.FlowOK
+			}
+
+			// the duration advances the rtp timestamp, fall back to the time since the previous buffer
+			pts, duration := buffer.PresentationTimestamp(), buffer.Duration()
+			if duration == gst.ClockTimeNone {
+				duration = 0
+				if pts != gst.ClockTimeNone && lastPTS != gst.ClockTimeNone && pts > lastPTS {
+					duration = pts - lastPTS
+				}
+			}
+			lastPTS = pts
+
+			// samples are dropped while there is no session, e.g. while reconnecting
+			if err := track.WriteSample(media.Sample{
+				Data:     buffer.Bytes(),
+				Duration: time.Duration(duration),
+			}); err != nil {
+				logger.Debugw("failed to write whip sample", "error", err)
+			}
+
+			return gst.FlowOK
+		},
+	}
+}
+
+func (s *WHIPSink) Start() error {
+	return nil
+}
+
+func (s *WHIPSink) UploadManifest(_ string) (string, bool, error) {
+	return "", false, nil
+}
+
+func (s *WHIPSink) DisableUploads() {}
+
+func (s *WHIPSink) Close() error {
+	s.client.close()
+	return nil
+}
@@ -0,0 +1,328 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/frostbyte73/core"
+	"github.com/linkdata/deadlock"
+	"github.com/pion/rtcp"
+	"github.com/pion/webrtc/v4"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/protocol/logger"
+)
+
+const (
+	whipRequestTimeout = 10 * time.Second
+
+	// a dropped session is renegotiated for as long as a dropped stream is reset
+	whipReconnectTimeout = 30 * time.Second
+	whipMinBackoff       = time.Second
+	whipMaxBackoff       = 8 * time.Second
+
+	whipICEDisconnectedTimeout = 5 * time.Second
+	whipICEFailedTimeout       = 10 * time.Second
+	whipICEKeepAliveInterval   = 2 * time.Second
+)
+
+// whipClient publishes tracks to a whip endpoint, and negotiates a new session whenever the connection fails
+type whipClient struct {
+	endpoint    string
+	token       string
+	redactedUrl string
+	tracks      []webrtc.TrackLocal
+	onFailure   func(error)
+	// called for picture loss and full intra requests on the video track
+	onKeyFrameRequest func()
+
+	api        *webrtc.API
+	httpClient *http.Client
+
+	mu       deadlock.Mutex
+	pc       *webrtc.PeerConnection
+	resource string
+
+	connected      atomic.Bool
+	reconnecting   atomic.Bool
+	reconnections  atomic.Int32
+	disconnectedAt atomic.Time
+	failed         core.Fuse
+	closed         core.Fuse
+}
+
+func newWHIPClient(
+	endpoint, token, redactedUrl string,
+	tracks []webrtc.TrackLocal,
+	onFailure func(error),
+	onKeyFrameRequest func(),
+) *whipClient {
+	var se webrtc.SettingEngine
+	se.SetICETimeouts(whipICEDisconnectedTimeout, whipICEFailedTimeout, whipICEKeepAliveInterval)
+
+	return &whipClient{
+		endpoint:          endpoint,
+		token:             token,
+		redactedUrl:       redactedUrl,
+		tracks:            tracks,
+		onFailure:         onFailure,
+		onKeyFrameRequest: onKeyFrameRequest,
+		api:               webrtc.NewAPI(webrtc.WithSettingEngine(se)),
+		httpClient:        &http.Client{Timeout: whipRequestTimeout},
+	}
+}
+
+// connect replaces the current session with a new one
+func (c *whipClient) connect() error {
+	c.teardown()
+
+	pc, err := c.api.NewPeerConnection(webrtc.Configuration{})
+	if err != nil {
+		return err
+	}
+	for _, track := range c.tracks {
+		transceiver, err := pc.AddTransceiverFromTrack(track, webrtc.RTPTransceiverInit{
+			Direction: webrtc.RTPTransceiverDirectionSendonly,
+		})
+		if err != nil {
+			_ = pc.Close()
+			return err
+		}
+		go c.readRTCP(transceiver.Sender(), track.Kind() == webrtc.RTPCodecTypeVideo)
+	}
+	pc.OnConnectionStateChange(func(state webrtc.PeerConnectionState) {
+		c.onConnectionStateChange(pc, state)
+	})
+
+	offer, err := pc.CreateOffer(nil)
+	if err != nil {
+		_ = pc.Close()
+		return err
+	}
+
+	// whip endpoints are not required to support trickle ice, so the offer includes every candidate
+	gathered := webrtc.GatheringCompletePromise(pc)
+	if err = pc.SetLocalDescription(offer); err != nil {
+		_ = pc.Close()
+		return err
+	}
+	<-gathered
+
+	answer, resource, err := c.postOffer(pc.LocalDescription().SDP)
+	if err != nil {
+		_ = pc.Close()
+		return err
+	}
+
+	c.mu.Lock()
+	if c.closed.IsBroken() {
+		c.mu.Unlock()
+		_ = pc.Close()
+		c.deleteResource(resource)
+		return nil
+	}
+	c.pc = pc
+	c.resource = resource
+	c.mu.Unlock()
+
+	return pc.SetRemoteDescription(webrtc.SessionDescription{
+		Type: webrtc.SDPTypeAnswer,
+		SDP:  answer,
+	})
+}
+
+// postOffer creates a session, returning the answer and the url of the session resource
+func (c *whipClient) postOffer(offer string) (string, string, error) {
+	req, err := http.NewRequest(http.MethodPost, c.endpoint, strings.NewReader(offer))
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Content-Type", "application/sdp")
+	c.setAuthorization(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", "", err
+	}
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("whip endpoint returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	// the location may be relative to the endpoint
+	var resource string
+	if location := resp.Header.Get("Location"); location != "" {
+		base, err := url.Parse(c.endpoint)
+		if err != nil {
+			return "", "", err
+		}
+		ref, err := base.Parse(location)
+		if err != nil {
+			return "", "", err
+		}
+		resource = ref.String()
+	}
+
+	return string(body), resource, nil
+}
+
+func (c *whipClient) deleteResource(resource string) {
+	if resource == "" {
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, resource, nil)
+	if err != nil {
+		logger.Warnw("failed to delete whip session", err, "url", c.redactedUrl)
+		return
+	}
+	c.setAuthorization(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		logger.Warnw("failed to delete whip session", err, "url", c.redactedUrl)
+		return
+	}
+	_ = resp.Body.Close()
+}
+
+func (c *whipClient) setAuthorization(req *http.Request) {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+}
+
+func (c *whipClient) onConnectionStateChange(pc *webrtc.PeerConnection, state webrtc.PeerConnectionState) {
+	c.mu.Lock()
+	current := c.pc == pc
+	c.mu.Unlock()
+	if !current || c.closed.IsBroken() {
+		return
+	}
+
+	switch state {
+	case webrtc.PeerConnectionStateConnected:
+		logger.Infow("whip session connected", "url", c.redactedUrl)
+		c.connected.Store(true)
+		c.reconnections.Store(0)
+		c.disconnectedAt.Store(time.Time{})
+
+	case webrtc.PeerConnectionStateFailed:
+		go c.reconnect(errors.New("whip connection failed"))
+
+	case webrtc.PeerConnectionStateClosed:
+		// sessions closed by the client are no longer current, so this was closed by the endpoint
+		go c.reconnect(errors.New("whip session closed by endpoint"))
+	}
+}
+
+// reconnect negotiates new sessions with backoff until one succeeds, or the session has been down for too long
+func (c *whipClient) reconnect(streamErr error) {
+	if !c.reconnecting.CompareAndSwap(false, true) {
+		return
+	}
+	defer c.reconnecting.Store(false)
+
+	// a session which never connected is unlikely to connect on retry
+	if !c.connected.Load() {
+		c.fail(streamErr)
+		return
+	}
+
+	if c.disconnectedAt.Load().IsZero() {
+		c.disconnectedAt.Store(time.Now())
+	}
+
+	backoff := whipMinBackoff
+	for {
+		if c.closed.IsBroken() {
+			return
+		}
+		if time.Since(c.disconnectedAt.Load()) > whipReconnectTimeout {
+			c.fail(streamErr)
+			return
+		}
+
+		c.reconnections.Inc()
+		logger.Warnw("reconnecting whip session", streamErr, "url", c.redactedUrl, "attempt", c.reconnections.Load())
+		err := c.connect()
+		if err == nil {
+			return
+		}
+		streamErr = err
+
+		select {
+		case <-c.closed.Watch():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, whipMaxBackoff)
+	}
+}
+
+func (c *whipClient) fail(err error) {
+	c.failed.Once(func() {
+		c.teardown()
+		c.onFailure(err)
+	})
+}
+
+// teardown closes the current session and deletes it from the endpoint
+func (c *whipClient) teardown() {
+	c.mu.Lock()
+	pc, resource := c.pc, c.resource
+	c.pc, c.resource = nil, ""
+	c.mu.Unlock()
+
+	if pc != nil {
+		_ = pc.Close()
+	}
+	c.deleteResource(resource)
+}
+
+func (c *whipClient) close() {
+	c.closed.Once(c.teardown)
+}
+
+// readRTCP forwards keyframe requests for the video track. Rtcp also has to be read for interceptors such as nack to work.
+func (c *whipClient) readRTCP(sender *webrtc.RTPSender, video bool) {
+	for {
+		packets, _, err := sender.ReadRTCP()
+		if err != nil {
+			return
+		}
+		if !video || c.onKeyFrameRequest == nil {
+			continue
+		}
+		for _, packet := range packets {
+			switch packet.(type) {
+			case *rtcp.PictureLossIndication, *rtcp.FullIntraRequest:
+				c.onKeyFrameRequest()
+			}
+		}
+	}
+}
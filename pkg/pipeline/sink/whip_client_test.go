@@ -0,0 +1,241 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/webrtc/v4"
+	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+// testWHIPEndpoint answers whip offers with pion, and counts the packets received for each codec
+type testWHIPEndpoint struct {
+	token string
+
+	mu       sync.Mutex
+	sessions map[string]*webrtc.PeerConnection
+	packets  map[string]int
+
+	posts   atomic.Int32
+	deletes atomic.Int32
+}
+
+func newTestWHIPEndpoint(token string) *testWHIPEndpoint {
+	return &testWHIPEndpoint{
+		token:    token,
+		sessions: make(map[string]*webrtc.PeerConnection),
+		packets:  make(map[string]int),
+	}
+}
+
+func (e *testWHIPEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+e.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		e.posts.Inc()
+		offer, _ := io.ReadAll(r.Body)
+		if r.Header.Get("Content-Type") != "application/sdp" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+
+		answer, err := e.answer(string(offer))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/sdp")
+		w.Header().Set("Location", fmt.Sprintf("session/%d", e.posts.Load()))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(answer))
+
+	case http.MethodDelete:
+		e.deletes.Inc()
+		e.mu.Lock()
+		pc, ok := e.sessions[r.URL.Path]
+		delete(e.sessions, r.URL.Path)
+		e.mu.Unlock()
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = pc.Close()
+	}
+}
+
+func (e *testWHIPEndpoint) answer(offer string) (string, error) {
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	if err != nil {
+		return "", err
+	}
+	pc.OnTrack(func(track *webrtc.TrackRemote, _ *webrtc.RTPReceiver) {
+		requestKeyFrame := track.Kind() == webrtc.RTPCodecTypeVideo
+		for {
+			if _, _, err := track.ReadRTP(); err != nil {
+				return
+			}
+			e.mu.Lock()
+			e.packets[track.Codec().MimeType]++
+			e.mu.Unlock()
+
+			if requestKeyFrame {
+				requestKeyFrame = false
+				_ = pc.WriteRTCP([]rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: uint32(track.SSRC())}})
+			}
+		}
+	})
+
+	if err = pc.SetRemoteDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: offer}); err != nil {
+		return "", err
+	}
+	answer, err := pc.CreateAnswer(nil)
+	if err != nil {
+		return "", err
+	}
+	gathered := webrtc.GatheringCompletePromise(pc)
+	if err = pc.SetLocalDescription(answer); err != nil {
+		return "", err
+	}
+	<-gathered
+
+	e.mu.Lock()
+	e.sessions[fmt.Sprintf("/session/%d", e.posts.Load())] = pc
+	e.mu.Unlock()
+
+	return pc.LocalDescription().SDP, nil
+}
+
+func (e *testWHIPEndpoint) received(mimeType string) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.packets[mimeType]
+}
+
+func (e *testWHIPEndpoint) sessionCount() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.sessions)
+}
+
+func newTestWHIPTracks(t *testing.T) (*webrtc.TrackLocalStaticSample, *webrtc.TrackLocalStaticSample) {
+	audio, err := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{
+		MimeType:  webrtc.MimeTypeOpus,
+		ClockRate: 48000,
+		Channels:  2,
+	}, "audio", "egress")
+	require.NoError(t, err)
+	video, err := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{
+		MimeType:  webrtc.MimeTypeVP8,
+		ClockRate: 90000,
+	}, "video", "egress")
+	require.NoError(t, err)
+	return audio, video
+}
+
+func TestWHIPClient(t *testing.T) {
+	endpoint := newTestWHIPEndpoint("token")
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	audio, video := newTestWHIPTracks(t)
+	var failure atomic.Error
+	var keyFrameRequests atomic.Int32
+	c := newWHIPClient(server.URL+"/whip", "token", "whip://{to...en}@localhost/whip",
+		[]webrtc.TrackLocal{audio, video},
+		func(err error) { failure.Store(err) },
+		func() { keyFrameRequests.Inc() },
+	)
+	require.NoError(t, c.connect())
+
+	// send media until both tracks are received
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(20 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				_ = audio.WriteSample(media.Sample{Data: []byte{0xfc, 0xff, 0xfe}, Duration: 20 * time.Millisecond})
+				_ = video.WriteSample(media.Sample{Data: []byte{0x10, 0x02, 0x00, 0x9d, 0x01, 0x2a}, Duration: 20 * time.Millisecond})
+			}
+		}
+	}()
+	defer close(done)
+
+	require.Eventually(t, func() bool {
+		return endpoint.received(webrtc.MimeTypeOpus) > 0 && endpoint.received(webrtc.MimeTypeVP8) > 0
+	}, 10*time.Second, 50*time.Millisecond)
+	require.True(t, c.connected.Load())
+
+	// picture loss indications are forwarded as keyframe requests
+	require.Eventually(t, func() bool {
+		return keyFrameRequests.Load() > 0
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// a reconnect replaces the session
+	c.reconnect(fmt.Errorf("connection failed"))
+	require.Equal(t, int32(2), endpoint.posts.Load())
+	require.Equal(t, int32(1), endpoint.deletes.Load())
+	require.Equal(t, 1, endpoint.sessionCount())
+	require.Nil(t, failure.Load())
+
+	// closing deletes the session
+	c.close()
+	require.Equal(t, int32(2), endpoint.deletes.Load())
+	require.Equal(t, 0, endpoint.sessionCount())
+}
+
+func TestWHIPClientUnauthorized(t *testing.T) {
+	endpoint := newTestWHIPEndpoint("token")
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	audio, video := newTestWHIPTracks(t)
+	c := newWHIPClient(server.URL+"/whip", "wrong", "", []webrtc.TrackLocal{audio, video}, func(error) {}, nil)
+	err := c.connect()
+	require.Error(t, err)
+	require.True(t, strings.Contains(err.Error(), "401"))
+}
+
+func TestWHIPClientNeverConnected(t *testing.T) {
+	audio, video := newTestWHIPTracks(t)
+	var failure atomic.Error
+	c := newWHIPClient("http://localhost:1/whip", "", "", []webrtc.TrackLocal{audio, video},
+		func(err error) { failure.Store(err) }, nil,
+	)
+
+	// sessions which never connected fail without retrying
+	c.reconnect(fmt.Errorf("connection failed"))
+	require.EqualError(t, failure.Load(), "connection failed")
+	require.Equal(t, int32(0), c.reconnections.Load())
+}
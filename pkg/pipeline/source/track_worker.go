@@ -468,6 +468,7 @@ func (s *SDKSource) createWriterForOp(op Operation) (*sdk.AppWriter, *config.Tra
 		MimeType:        types.MimeType(strings.ToLower(track.Codec().MimeType)),
 		PayloadType:     track.Codec().PayloadType,
 		ClockRate:       track.Codec().ClockRate,
+		Muted:           pub.IsMuted(),
 	}
 
 	// Set audio channel from route match (RequestTypeMedia)
@@ -29,6 +29,7 @@ import (
 	"github.com/livekit/egress/pkg/gstreamer"
 	"github.com/livekit/egress/pkg/pipeline/builder"
 	"github.com/livekit/egress/pkg/pipeline/source"
+	"github.com/livekit/egress/pkg/types"
 )
 
 const (
@@ -174,7 +175,7 @@ func (c *Controller) handleMessageWarning(gErr *gst.GError) error {
 			return err
 		}
 
-		return c.streamFailed(context.Background(), stream, gErr)
+		return c.streamFailed(context.Background(), types.EgressTypeStream, stream, gErr)
 	}
 
 	logger.Warnw(gErr.Message(), errors.New(message), "element", element)
@@ -182,10 +183,11 @@ func (c *Controller) handleMessageWarning(gErr *gst.GError) error {
 }
 
 const (
-	elementGstAppSrc       = "GstAppSrc"
-	elementGstRtmp2Sink    = "GstRtmp2Sink"
-	elementGstSplitMuxSink = "GstSplitMuxSink"
-	elementGstSrtSink      = "GstSRTSink"
+	elementGstAppSrc         = "GstAppSrc"
+	elementGstRtmp2Sink      = "GstRtmp2Sink"
+	elementGstRTSPClientSink = "GstRTSPClientSink"
+	elementGstSplitMuxSink   = "GstSplitMuxSink"
+	elementGstSrtSink        = "GstSRTSink"
 
 	msgStreamingNotNegotiated = "streaming stopped, reason not-negotiated (-4)"
 	msgMuxer                  = ":muxer"
@@ -196,7 +198,7 @@ func (c *Controller) handleMessageError(gErr *gst.GError) error {
 	element, name, message := parseDebugInfo(gErr)
 
 	switch element {
-	case elementGstRtmp2Sink:
+	case elementGstRtmp2Sink, elementGstRTSPClientSink:
 		streamSink := c.getStreamSink()
 
 		streamName := strings.Split(name, "_")[1]
@@ -217,7 +219,7 @@ func (c *Controller) handleMessageError(gErr *gst.GError) error {
 		}
 
 		// remove sink
-		return c.streamFailed(context.Background(), stream, gErr)
+		return c.streamFailed(context.Background(), types.EgressTypeStream, stream, gErr)
 
 	case elementGstSrtSink:
 		streamName := strings.Split(name, "_")[1]
@@ -226,7 +228,7 @@ func (c *Controller) handleMessageError(gErr *gst.GError) error {
 			return err
 		}
 
-		return c.streamFailed(context.Background(), stream, gErr)
+		return c.streamFailed(context.Background(), types.EgressTypeStream, stream, gErr)
 
 	case elementGstAppSrc:
 		if message == msgStreamingNotNegotiated {
@@ -301,6 +303,7 @@ const (
 	msgFragmentOpened      = "splitmuxsink-fragment-opened"
 	msgFragmentClosed      = "splitmuxsink-fragment-closed"
 	msgGstMultiFileSink    = "GstMultiFileSink"
+	msgSceneChange         = "SceneChange"
 )
 
 func (c *Controller) handleMessageElement(msg *gst.Message) error {
@@ -329,7 +332,11 @@ func (c *Controller) handleMessageElement(msg *gst.Message) error {
 			}
 			logger.Debugw("received FirstSampleMetadata message", "startDate", startDate)
 
-			c.getSegmentSink().UpdateStartDate(startDate)
+			segmentSink := c.getSegmentSink(msg.Source())
+			if segmentSink == nil {
+				return errors.ErrSinkNotFound
+			}
+			segmentSink.UpdateStartDate(startDate)
 
 		case msgFragmentOpened:
 			filepath, t, err := getSegmentParamsFromGstStructure(s)
@@ -338,7 +345,11 @@ func (c *Controller) handleMessageElement(msg *gst.Message) error {
 				return err
 			}
 
-			if err = c.getSegmentSink().FragmentOpened(filepath, t); err != nil {
+			segmentSink := c.getSegmentSink(msg.Source())
+			if segmentSink == nil {
+				return errors.ErrSinkNotFound
+			}
+			if err = segmentSink.FragmentOpened(filepath, t); err != nil {
 				logger.Errorw("failed to register new segment with playlist writer", err, "location", filepath, "runningTime", t)
 				return err
 			}
@@ -353,7 +364,11 @@ func (c *Controller) handleMessageElement(msg *gst.Message) error {
 			// We need to dispatch to a queue to:
 			// 1. Avoid concurrent access to the SegmentsInfo structure
 			// 2. Ensure that playlists are uploaded in the same order they are enqueued to avoid an older playlist overwriting a newer one
-			if err = c.getSegmentSink().FragmentClosed(filepath, t); err != nil {
+			segmentSink := c.getSegmentSink(msg.Source())
+			if segmentSink == nil {
+				return errors.ErrSinkNotFound
+			}
+			if err = segmentSink.FragmentClosed(filepath, t); err != nil {
 				logger.Errorw("failed to end segment with playlist writer", err, "runningTime", t)
 				return err
 			}
@@ -373,6 +388,18 @@ func (c *Controller) handleMessageElement(msg *gst.Message) error {
 			if err != nil {
 				return err
 			}
+
+		case msgSceneChange:
+			sceneChange, err := getSceneChangeFromGstStructure(s)
+			if err != nil {
+				return err
+			}
+
+			imageSink := c.getImageSink(msg.Source())
+			if imageSink == nil {
+				return errors.ErrSinkNotFound
+			}
+			imageSink.SceneChanged(sceneChange.Timestamp, sceneChange.Score)
 		}
 	}
 
@@ -517,6 +544,16 @@ func getImageInformationFromGstStructure(s *gst.Structure) (string, uint64, erro
 
 }
 
+// getSceneChangeFromGstStructure parses the message posted by the image sink before an image triggered
+// by a scene change is written. Its timestamp matches the timestamp of the multifilesink message.
+func getSceneChangeFromGstStructure(s *gst.Structure) (*builder.SceneChange, error) {
+	sceneChange := &builder.SceneChange{}
+	if err := s.UnmarshalInto(sceneChange); err != nil {
+		return nil, err
+	}
+	return sceneChange, nil
+}
+
 func isQosForAudioMixer(msg *gst.Message) bool {
 	src := msg.SourceObject()
 	if src == nil {
@@ -323,9 +323,70 @@ func (m *Monitor) costsForRequest(req *rpc.StartEgressRequest) requestCosts {
 		setV2Costs(r.Egress)
 	}
 
+	// adaptive bitrate renditions and previews run their own encoders
+	filepaths, segments := encodedOutputs(req)
+	for _, filepath := range filepaths {
+		if config.PreviewRequested(filepath) {
+			costs.cpu += m.cpuCostConfig.PreviewCpuCost
+		}
+	}
+	for _, s := range segments {
+		costs.cpu += float64(config.RenditionCount(s)) * m.cpuCostConfig.RenditionCpuCost
+	}
+
 	return costs
 }
 
+// encodedOutputs returns the file output paths and segment outputs of a request
+func encodedOutputs(req *rpc.StartEgressRequest) ([]string, []*livekit.SegmentedFileOutput) {
+	var encoded egress.EncodedOutput
+	switch r := req.Request.(type) {
+	case *rpc.StartEgressRequest_RoomComposite:
+		encoded = r.RoomComposite
+	case *rpc.StartEgressRequest_Web:
+		encoded = r.Web
+	case *rpc.StartEgressRequest_Participant:
+		encoded = r.Participant
+	case *rpc.StartEgressRequest_TrackComposite:
+		encoded = r.TrackComposite
+	case *rpc.StartEgressRequest_Replay:
+		return v2EncodedOutputs(r.Replay)
+	case *rpc.StartEgressRequest_Egress:
+		return v2EncodedOutputs(r.Egress)
+	default:
+		return nil, nil
+	}
+
+	var filepaths []string
+	for _, file := range encoded.GetFileOutputs() {
+		filepaths = append(filepaths, file.GetFilepath())
+	}
+	segments := encoded.GetSegmentOutputs()
+	if deprecated, ok := encoded.(egress.EncodedOutputDeprecated); ok {
+		if deprecated.GetFile() != nil {
+			filepaths = append(filepaths, deprecated.GetFile().GetFilepath())
+		}
+		if deprecated.GetSegments() != nil {
+			segments = append(segments, deprecated.GetSegments())
+		}
+	}
+	return filepaths, segments
+}
+
+func v2EncodedOutputs(request v2Request) ([]string, []*livekit.SegmentedFileOutput) {
+	var filepaths []string
+	var segments []*livekit.SegmentedFileOutput
+	for _, output := range request.GetOutputs() {
+		if f := output.GetFile(); f != nil {
+			filepaths = append(filepaths, f.GetFilepath())
+		}
+		if s := output.GetSegments(); s != nil {
+			segments = append(segments, s)
+		}
+	}
+	return filepaths, segments
+}
+
 func (m *Monitor) canAcceptWebLocked() bool {
 	clients, err := pulse.Clients()
 	if err != nil {
@@ -37,6 +37,8 @@ func TestCostsForRequest(t *testing.T) {
 			ParticipantCpuCost:              2,
 			TrackCompositeCpuCost:           2.5,
 			TrackCpuCost:                    0.2,
+			RenditionCpuCost:                0.75,
+			PreviewCpuCost:                  0.25,
 			MemoryCost:                      3,
 		},
 	}
@@ -77,6 +79,24 @@ func TestCostsForRequest(t *testing.T) {
 			req:  roomComposite(&livekit.RoomCompositeEgressRequest{AudioOnly: true, CustomBaseUrl: "https://example.com"}),
 			cpu:  1, memory: 3, isWeb: true,
 		},
+		{
+			name: "room composite with renditions",
+			req: roomComposite(&livekit.RoomCompositeEgressRequest{
+				SegmentOutputs: []*livekit.SegmentedFileOutput{{
+					PlaylistName: "playlist.m3u8?renditions=1280x720,640x360@800",
+				}},
+			}),
+			cpu: 5.5, memory: 3, isWeb: true,
+		},
+		{
+			name: "room composite with preview",
+			req: roomComposite(&livekit.RoomCompositeEgressRequest{
+				FileOutputs: []*livekit.EncodedFileOutput{{
+					Filepath: "recordings/room.mp4?preview=gif",
+				}},
+			}),
+			cpu: 4.25, memory: 3, isWeb: true,
+		},
 		{
 			name: "web",
 			req: &rpc.StartEgressRequest{Request: &rpc.StartEgressRequest_Web{
@@ -146,6 +166,34 @@ func TestCostsForRequest(t *testing.T) {
 			}},
 			cpu: 2, memory: 3, isWeb: false,
 		},
+		{
+			name: "v2 media with renditions",
+			req: &rpc.StartEgressRequest{Request: &rpc.StartEgressRequest_Egress{
+				Egress: &livekit.StartEgressRequest{
+					Source: &livekit.StartEgressRequest_Media{Media: &livekit.MediaSource{}},
+					Outputs: []*livekit.Output{{
+						Config: &livekit.Output_Segments{Segments: &livekit.SegmentedFileOutput{
+							FilenamePrefix: "segment?renditions=640x360",
+						}},
+					}},
+				},
+			}},
+			cpu: 2.75, memory: 3, isWeb: false,
+		},
+		{
+			name: "v2 media with preview",
+			req: &rpc.StartEgressRequest{Request: &rpc.StartEgressRequest_Egress{
+				Egress: &livekit.StartEgressRequest{
+					Source: &livekit.StartEgressRequest_Media{Media: &livekit.MediaSource{}},
+					Outputs: []*livekit.Output{{
+						Config: &livekit.Output_File{File: &livekit.FileOutput{
+							Filepath: "recordings/room.mp4?preview",
+						}},
+					}},
+				},
+			}},
+			cpu: 2.25, memory: 3, isWeb: false,
+		},
 		{
 			name: "v2 replay template audio sdk",
 			req: &rpc.StartEgressRequest{Request: &rpc.StartEgressRequest_Replay{
@@ -0,0 +1,125 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+// VideoFormat describes the encoded video, which determines the codec level
+type VideoFormat struct {
+	Width     int32
+	Height    int32
+	Framerate int32
+	Bitrate   int32 // kbps
+}
+
+type codecLevel struct {
+	id         int
+	frameSize  int64 // luma samples, or macroblocks for h264
+	sampleRate int64 // frame size units per second
+	bitrate    int64 // kbps
+}
+
+var (
+	// ITU-T H.264 Table A-1
+	h264Levels = []codecLevel{
+		{10, 99, 1485, 64}, {11, 396, 3000, 192}, {12, 396, 6000, 384}, {13, 396, 11880, 768},
+		{20, 396, 11880, 2000}, {21, 792, 19800, 4000}, {22, 1620, 20250, 4000},
+		{30, 1620, 40500, 10000}, {31, 3600, 108000, 14000}, {32, 5120, 216000, 20000},
+		{40, 8192, 245760, 20000}, {41, 8192, 245760, 50000}, {42, 8704, 522240, 50000},
+		{50, 22080, 589824, 135000}, {51, 36864, 983040, 240000}, {52, 36864, 2073600, 240000},
+		{60, 139264, 4177920, 240000}, {61, 139264, 8355840, 480000}, {62, 139264, 16711680, 800000},
+	}
+
+	// ITU-T H.265 Table A.8, main tier. Level ids are 30 times the level number.
+	h265Levels = []codecLevel{
+		{30, 36864, 552960, 128}, {60, 122880, 3686400, 1500}, {63, 245760, 7372800, 3000},
+		{90, 552960, 16588800, 6000}, {93, 983040, 33177600, 10000},
+		{120, 2228224, 66846720, 12000}, {123, 2228224, 133693440, 20000},
+		{150, 8912896, 267386880, 25000}, {153, 8912896, 534773760, 40000}, {156, 8912896, 1069547520, 60000},
+		{180, 35651584, 1069547520, 60000}, {183, 35651584, 2139095040, 120000}, {186, 35651584, 4278190080, 240000},
+	}
+
+	// VP9 bitstream specification, Annex A
+	vp9Levels = []codecLevel{
+		{10, 36864, 829440, 200}, {11, 73728, 2764800, 800}, {20, 122880, 4608000, 1800}, {21, 245760, 9216000, 3600},
+		{30, 552960, 20736000, 7200}, {31, 983040, 36864000, 12000},
+		{40, 2228224, 83558400, 18000}, {41, 2228224, 160432128, 30000},
+		{50, 8912896, 311951360, 60000}, {51, 8912896, 588251136, 120000}, {52, 8912896, 1176502272, 180000},
+		{60, 35651584, 1176502272, 180000}, {61, 35651584, 2353004544, 240000}, {62, 35651584, 4706009088, 480000},
+	}
+
+	// AV1 specification, Annex A, main tier. Level ids are seq_level_idx values.
+	av1Levels = []codecLevel{
+		{0, 147456, 4423680, 1500}, {1, 278784, 8363520, 3000},
+		{4, 665856, 19975680, 6000}, {5, 1065024, 31950720, 10000},
+		{8, 2359296, 70778880, 12000}, {9, 2359296, 141557760, 20000},
+		{12, 8912896, 267386880, 30000}, {13, 8912896, 534773760, 40000}, {14, 8912896, 1069547520, 60000},
+		{16, 35651584, 1069547520, 60000}, {17, 35651584, 2139095040, 100000}, {18, 35651584, 4278190080, 160000},
+	}
+)
+
+// H264Level returns the lowest level_idc supporting the format
+func H264Level(profile Profile, format VideoFormat) int {
+	if format.Width == 0 || format.Height == 0 {
+		return 41
+	}
+	frameSize := int64((format.Width+15)/16) * int64((format.Height+15)/16)
+	bitrate := int64(format.Bitrate)
+	if profile == ProfileHigh {
+		// high profile allows 1.25 times the bitrate
+		bitrate = bitrate * 4 / 5
+	}
+	return selectLevel(h264Levels, frameSize, format.framerate(), bitrate)
+}
+
+func h265Level(format VideoFormat) int {
+	if format.Width == 0 || format.Height == 0 {
+		return 123
+	}
+	return selectLevel(h265Levels, format.lumaSamples(), format.framerate(), int64(format.Bitrate))
+}
+
+func vp9Level(format VideoFormat) int {
+	if format.Width == 0 || format.Height == 0 {
+		return 41
+	}
+	return selectLevel(vp9Levels, format.lumaSamples(), format.framerate(), int64(format.Bitrate))
+}
+
+func av1Level(format VideoFormat) int {
+	if format.Width == 0 || format.Height == 0 {
+		return 9
+	}
+	return selectLevel(av1Levels, format.lumaSamples(), format.framerate(), int64(format.Bitrate))
+}
+
+func (f VideoFormat) lumaSamples() int64 {
+	return int64(f.Width) * int64(f.Height)
+}
+
+func (f VideoFormat) framerate() int64 {
+	if f.Framerate <= 0 {
+		return 30
+	}
+	return int64(f.Framerate)
+}
+
+// selectLevel returns the first level supporting the frame size, sample rate and bitrate, or the highest level
+func selectLevel(levels []codecLevel, frameSize, framerate, bitrate int64) int {
+	for _, l := range levels {
+		if frameSize <= l.frameSize && frameSize*framerate <= l.sampleRate && bitrate <= l.bitrate {
+			return l.id
+		}
+	}
+	return levels[len(levels)-1].id
+}
@@ -14,6 +14,8 @@
 
 package types
 
+import "fmt"
+
 type RequestType string
 type SourceType string
 type EgressType string
@@ -40,20 +42,28 @@ const (
 	// egress types
 	EgressTypeStream    EgressType = "stream"
 	EgressTypeWebsocket EgressType = "websocket"
+	EgressTypeWHIP      EgressType = "whip"
+	EgressTypeHLSPush   EgressType = "hls_push"
 	EgressTypeFile      EgressType = "file"
 	EgressTypeSegments  EgressType = "segments"
 	EgressTypeImages    EgressType = "images"
+	EgressTypePreview   EgressType = "preview"
 
 	// input types
 	MimeTypeAAC      MimeType = "audio/aac"
 	MimeTypeOpus     MimeType = "audio/opus"
 	MimeTypeRawAudio MimeType = "audio/x-raw"
 	MimeTypeH264     MimeType = "video/h264"
+	MimeTypeH265     MimeType = "video/h265"
 	MimeTypeVP8      MimeType = "video/vp8"
 	MimeTypeVP9      MimeType = "video/vp9"
+	MimeTypeAV1      MimeType = "video/av1"
 	MimeTypeJPEG     MimeType = "image/jpeg"
+	MimeTypePNG      MimeType = "image/png"
+	MimeTypeWebP     MimeType = "image/webp"
 	MimeTypeRawVideo MimeType = "video/x-raw"
 	MimeTypeMP3      MimeType = "audio/mpeg"
+	MimeTypeFLAC     MimeType = "audio/flac"
 	MimeTypePCMU     MimeType = "audio/pcmu"
 	MimeTypePCMA     MimeType = "audio/pcma"
 
@@ -67,14 +77,25 @@ const (
 	OutputTypeRaw         OutputType = "audio/x-raw"
 	OutputTypeOGG         OutputType = "audio/ogg"
 	OutputTypeMP3         OutputType = "audio/mpeg"
+	OutputTypeWAV         OutputType = "audio/wav"
+	OutputTypeFLAC        OutputType = "audio/flac"
 	OutputTypeIVF         OutputType = "video/x-ivf"
 	OutputTypeMP4         OutputType = "video/mp4"
 	OutputTypeTS          OutputType = "video/mp2t"
+	OutputTypeFMP4        OutputType = "video/iso.segment"
 	OutputTypeWebM        OutputType = "video/webm"
+	OutputTypeMKV         OutputType = "video/x-matroska"
 	OutputTypeJPEG        OutputType = "image/jpeg"
+	OutputTypePNG         OutputType = "image/png"
+	OutputTypeWebP        OutputType = "image/webp"
+	OutputTypeGIF         OutputType = "image/gif"
+	OutputTypeVTT         OutputType = "text/vtt"
 	OutputTypeRTMP        OutputType = "rtmp"
 	OutputTypeSRT         OutputType = "srt"
+	OutputTypeRTSP        OutputType = "rtsp"
+	OutputTypeWHIP        OutputType = "whip"
 	OutputTypeHLS         OutputType = "application/x-mpegurl"
+	OutputTypeDASH        OutputType = "application/dash+xml"
 	OutputTypeJSON        OutputType = "application/json"
 	OutputTypeBlob        OutputType = "application/octet-stream"
 
@@ -82,12 +103,21 @@ const (
 	FileExtensionRaw  = ".raw"
 	FileExtensionOGG  = ".ogg"
 	FileExtensionMP3  = ".mp3"
+	FileExtensionWAV  = ".wav"
+	FileExtensionFLAC = ".flac"
 	FileExtensionIVF  = ".ivf"
 	FileExtensionMP4  = ".mp4"
 	FileExtensionTS   = ".ts"
 	FileExtensionWebM = ".webm"
+	FileExtensionMKV  = ".mkv"
+	FileExtensionM4S  = ".m4s"
 	FileExtensionM3U8 = ".m3u8"
+	FileExtensionMPD  = ".mpd"
 	FileExtensionJPEG = ".jpeg"
+	FileExtensionPNG  = ".png"
+	FileExtensionWebP = ".webp"
+	FileExtensionGIF  = ".gif"
+	FileExtensionVTT  = ".vtt"
 
 	Unknown = "unknown"
 )
@@ -97,11 +127,17 @@ var (
 		OutputTypeRaw:  MimeTypeRawAudio,
 		OutputTypeOGG:  MimeTypeOpus,
 		OutputTypeMP3:  MimeTypeMP3,
+		OutputTypeWAV:  MimeTypeRawAudio,
+		OutputTypeFLAC: MimeTypeFLAC,
 		OutputTypeMP4:  MimeTypeAAC,
 		OutputTypeTS:   MimeTypeAAC,
+		OutputTypeFMP4: MimeTypeAAC,
 		OutputTypeWebM: MimeTypeOpus,
+		OutputTypeMKV:  MimeTypeOpus,
 		OutputTypeRTMP: MimeTypeAAC,
 		OutputTypeSRT:  MimeTypeAAC,
+		OutputTypeRTSP: MimeTypeAAC,
+		OutputTypeWHIP: MimeTypeOpus,
 		OutputTypeHLS:  MimeTypeAAC,
 	}
 
@@ -109,9 +145,13 @@ var (
 		OutputTypeIVF:  MimeTypeVP8,
 		OutputTypeMP4:  MimeTypeH264,
 		OutputTypeTS:   MimeTypeH264,
+		OutputTypeFMP4: MimeTypeH264,
 		OutputTypeWebM: MimeTypeVP8,
+		OutputTypeMKV:  MimeTypeH264,
 		OutputTypeRTMP: MimeTypeH264,
 		OutputTypeSRT:  MimeTypeH264,
+		OutputTypeRTSP: MimeTypeH264,
+		OutputTypeWHIP: MimeTypeH264,
 		OutputTypeHLS:  MimeTypeH264,
 	}
 
@@ -119,29 +159,51 @@ var (
 		FileExtensionRaw:  {},
 		FileExtensionOGG:  {},
 		FileExtensionMP3:  {},
+		FileExtensionWAV:  {},
+		FileExtensionFLAC: {},
 		FileExtensionIVF:  {},
 		FileExtensionMP4:  {},
 		FileExtensionTS:   {},
 		FileExtensionWebM: {},
+		FileExtensionMKV:  {},
+		FileExtensionM4S:  {},
 		FileExtensionM3U8: {},
+		FileExtensionMPD:  {},
 		FileExtensionJPEG: {},
+		FileExtensionPNG:  {},
+		FileExtensionWebP: {},
+		FileExtensionGIF:  {},
+		FileExtensionVTT:  {},
 	}
 
 	FileExtensionForOutputType = map[OutputType]FileExtension{
 		OutputTypeRaw:  FileExtensionRaw,
 		OutputTypeOGG:  FileExtensionOGG,
 		OutputTypeMP3:  FileExtensionMP3,
+		OutputTypeWAV:  FileExtensionWAV,
+		OutputTypeFLAC: FileExtensionFLAC,
 		OutputTypeIVF:  FileExtensionIVF,
 		OutputTypeMP4:  FileExtensionMP4,
 		OutputTypeTS:   FileExtensionTS,
+		OutputTypeFMP4: FileExtensionM4S,
 		OutputTypeWebM: FileExtensionWebM,
+		OutputTypeMKV:  FileExtensionMKV,
 		OutputTypeHLS:  FileExtensionM3U8,
+		OutputTypeDASH: FileExtensionMPD,
 		OutputTypeJPEG: FileExtensionJPEG,
+		OutputTypePNG:  FileExtensionPNG,
+		OutputTypeWebP: FileExtensionWebP,
+		OutputTypeGIF:  FileExtensionGIF,
+		OutputTypeVTT:  FileExtensionVTT,
 	}
 
 	CodecCompatibility = map[OutputType]map[MimeType]bool{
+		// raw outputs are websocket streams, which can also send encoded audio
 		OutputTypeRaw: {
 			MimeTypeRawAudio: true,
+			MimeTypeOpus:     true,
+			MimeTypePCMU:     true,
+			MimeTypePCMA:     true,
 		},
 		OutputTypeOGG: {
 			MimeTypeOpus: true,
@@ -154,16 +216,35 @@ var (
 			MimeTypeAAC:  true,
 			MimeTypeOpus: true,
 			MimeTypeH264: true,
+			MimeTypeH265: true,
+			MimeTypeVP9:  true,
+			MimeTypeAV1:  true,
 		},
 		OutputTypeTS: {
 			MimeTypeAAC:  true,
 			MimeTypeOpus: true,
 			MimeTypeH264: true,
+			MimeTypeH265: true,
+		},
+		OutputTypeFMP4: {
+			MimeTypeAAC:  true,
+			MimeTypeOpus: true,
+			MimeTypeH264: true,
+			MimeTypeH265: true,
+			MimeTypeAV1:  true,
 		},
 		OutputTypeWebM: {
 			MimeTypeOpus: true,
 			MimeTypeVP8:  true,
 			MimeTypeVP9:  true,
+			MimeTypeAV1:  true,
+		},
+		OutputTypeMKV: {
+			MimeTypeAAC:  true,
+			MimeTypeOpus: true,
+			MimeTypeH264: true,
+			MimeTypeVP8:  true,
+			MimeTypeVP9:  true,
 		},
 		OutputTypeRTMP: {
 			MimeTypeAAC:  true,
@@ -173,9 +254,19 @@ var (
 			MimeTypeAAC:  true,
 			MimeTypeH264: true,
 		},
+		OutputTypeRTSP: {
+			MimeTypeAAC:  true,
+			MimeTypeH264: true,
+		},
+		OutputTypeWHIP: {
+			MimeTypeOpus: true,
+			MimeTypeH264: true,
+			MimeTypeVP8:  true,
+		},
 		OutputTypeHLS: {
 			MimeTypeAAC:  true,
 			MimeTypeH264: true,
+			MimeTypeH265: true,
 		},
 		OutputTypeMP3: {
 			MimeTypeMP3:      true,
@@ -183,13 +274,23 @@ var (
 			MimeTypeAAC:      true,
 			MimeTypeRawAudio: true,
 		},
+		OutputTypeWAV: {
+			MimeTypeRawAudio: true,
+		},
+		OutputTypeFLAC: {
+			MimeTypeFLAC: true,
+		},
 		OutputTypeUnknownFile: {
-			MimeTypeAAC:  true,
-			MimeTypeOpus: true,
-			MimeTypeMP3:  true,
-			MimeTypeH264: true,
-			MimeTypeVP8:  true,
-			MimeTypeVP9:  true,
+			MimeTypeAAC:      true,
+			MimeTypeOpus:     true,
+			MimeTypeMP3:      true,
+			MimeTypeFLAC:     true,
+			MimeTypeRawAudio: true,
+			MimeTypeH264:     true,
+			MimeTypeVP8:      true,
+			MimeTypeVP9:      true,
+			MimeTypeAV1:      true,
+			MimeTypeH265:     true,
 		},
 	}
 
@@ -198,22 +299,35 @@ var (
 		MimeTypeOpus:     true,
 		MimeTypeRawAudio: true,
 		MimeTypeMP3:      true,
+		MimeTypeFLAC:     true,
 	}
 
 	AllOutputVideoCodecs = map[MimeType]bool{
 		MimeTypeH264: true,
+		MimeTypeH265: true,
+		MimeTypeVP8:  true,
+		MimeTypeVP9:  true,
+		MimeTypeAV1:  true,
 	}
 
 	AudioOnlyFileOutputTypes = []OutputType{
 		OutputTypeOGG,
 		OutputTypeMP4,
 		OutputTypeMP3,
+		OutputTypeMKV,
+		OutputTypeWAV,
+		OutputTypeFLAC,
 	}
 	VideoOnlyFileOutputTypes = []OutputType{
 		OutputTypeMP4,
+		OutputTypeWebM,
+		OutputTypeIVF,
+		OutputTypeMKV,
 	}
 	AudioVideoFileOutputTypes = []OutputType{
 		OutputTypeMP4,
+		OutputTypeWebM,
+		OutputTypeMKV,
 	}
 
 	TrackOutputTypes = map[MimeType]OutputType{
@@ -226,13 +340,19 @@ var (
 	}
 
 	StreamOutputTypes = map[string]OutputType{
-		"rtmp":   OutputTypeRTMP,
-		"rtmps":  OutputTypeRTMP,
-		"mux":    OutputTypeRTMP,
-		"twitch": OutputTypeRTMP,
-		"srt":    OutputTypeSRT,
-		"ws":     OutputTypeRaw,
-		"wss":    OutputTypeRaw,
+		"rtmp":      OutputTypeRTMP,
+		"rtmps":     OutputTypeRTMP,
+		"mux":       OutputTypeRTMP,
+		"twitch":    OutputTypeRTMP,
+		"srt":       OutputTypeSRT,
+		"rtsp":      OutputTypeRTSP,
+		"rtsps":     OutputTypeRTSP,
+		"whip":      OutputTypeWHIP,
+		"whips":     OutputTypeWHIP,
+		"hls+http":  OutputTypeHLS,
+		"hls+https": OutputTypeHLS,
+		"ws":        OutputTypeRaw,
+		"wss":       OutputTypeRaw,
 	}
 )
 
@@ -261,6 +381,35 @@ func IsOutputTypeCompatibleWithCodecs(ot OutputType, codecs map[MimeType]bool) b
 	return false
 }
 
+// CodecString returns the RFC 6381 codecs parameter used in HLS and DASH manifests.
+// Video levels are the lowest supporting the encoded format.
+func CodecString(codec MimeType, profile Profile, format VideoFormat) string {
+	switch codec {
+	case MimeTypeH264:
+		level := H264Level(profile, format)
+		switch profile {
+		case ProfileBaseline:
+			return fmt.Sprintf("avc1.42e0%02x", level)
+		case ProfileHigh:
+			return fmt.Sprintf("avc1.6400%02x", level)
+		default:
+			return fmt.Sprintf("avc1.4d40%02x", level)
+		}
+	case MimeTypeH265:
+		return fmt.Sprintf("hvc1.1.6.L%d.B0", h265Level(format))
+	case MimeTypeVP9:
+		return fmt.Sprintf("vp09.00.%d.08", vp9Level(format))
+	case MimeTypeAV1:
+		return fmt.Sprintf("av01.0.%02dM.08", av1Level(format))
+	case MimeTypeAAC:
+		return "mp4a.40.2"
+	case MimeTypeOpus:
+		return "opus"
+	default:
+		return ""
+	}
+}
+
 func GetMapIntersection[K comparable](mapA map[K]bool, mapB map[K]bool) map[K]bool {
 	res := make(map[K]bool)
 
@@ -62,3 +62,23 @@ func TestGetOutputTypesCompatibleWithCodecs(t *testing.T) {
 	res = GetOutputTypeCompatibleWithCodecs(outputTypes, audioCodecs, videoCodecs)
 	require.Equal(t, OutputTypeMP4, res)
 }
+
+func TestCodecString(t *testing.T) {
+	hd := VideoFormat{Width: 1280, Height: 720, Framerate: 30, Bitrate: 3000}
+	fhd := VideoFormat{Width: 1920, Height: 1080, Framerate: 30, Bitrate: 4500}
+	fhd60 := VideoFormat{Width: 1920, Height: 1080, Framerate: 60, Bitrate: 6000}
+	uhd := VideoFormat{Width: 3840, Height: 2160, Framerate: 30, Bitrate: 20000}
+
+	require.Equal(t, "avc1.42e01f", CodecString(MimeTypeH264, ProfileBaseline, hd))
+	require.Equal(t, "avc1.4d4028", CodecString(MimeTypeH264, ProfileMain, fhd))
+	require.Equal(t, "avc1.64002a", CodecString(MimeTypeH264, ProfileHigh, fhd60))
+	require.Equal(t, "avc1.4d4029", CodecString(MimeTypeH264, ProfileMain, VideoFormat{}))
+	require.Equal(t, "hvc1.1.6.L93.B0", CodecString(MimeTypeH265, "", hd))
+	require.Equal(t, "hvc1.1.6.L120.B0", CodecString(MimeTypeH265, "", fhd))
+	require.Equal(t, "hvc1.1.6.L150.B0", CodecString(MimeTypeH265, "", uhd))
+	require.Equal(t, "vp09.00.31.08", CodecString(MimeTypeVP9, "", hd))
+	require.Equal(t, "av01.0.08M.08", CodecString(MimeTypeAV1, "", fhd))
+	require.Equal(t, "mp4a.40.2", CodecString(MimeTypeAAC, "", VideoFormat{}))
+	require.Equal(t, "opus", CodecString(MimeTypeOpus, "", VideoFormat{}))
+	require.Equal(t, "", CodecString(MimeTypeMP3, "", VideoFormat{}))
+}
@@ -213,7 +213,16 @@ func verify(t *testing.T, in string, p *config.PipelineConfig, res *livekit.Egre
 
 			case types.MimeTypeRawAudio:
 				require.Equal(t, "pcm_s16le", stream.CodecName)
-				require.Equal(t, "48000", stream.SampleRate)
+				if p.Outputs[egressType][0].GetOutputType() == types.OutputTypeWAV {
+					require.Equal(t, fmt.Sprint(p.AudioFrequency), stream.SampleRate)
+				} else {
+					require.Equal(t, "48000", stream.SampleRate)
+				}
+
+			case types.MimeTypeFLAC:
+				require.Equal(t, "flac", stream.CodecName)
+				require.Equal(t, fmt.Sprint(p.AudioFrequency), stream.SampleRate)
+				require.Equal(t, "stereo", stream.ChannelLayout)
 			}
 
 			// channels
@@ -246,8 +255,12 @@ func verify(t *testing.T, in string, p *config.PipelineConfig, res *livekit.Egre
 				}
 			case types.MimeTypeVP8:
 				require.Equal(t, "vp8", stream.CodecName)
+			case types.MimeTypeH265:
+				require.Equal(t, "hevc", stream.CodecName)
 			case types.MimeTypeVP9:
 				require.Equal(t, "vp9", stream.CodecName)
+			case types.MimeTypeAV1:
+				require.Equal(t, "av1", stream.CodecName)
 			}
 
 			if p.VideoEncoding {
@@ -261,8 +274,7 @@ func verify(t *testing.T, in string, p *config.PipelineConfig, res *livekit.Egre
 				require.Equal(t, "vp8", stream.CodecName)
 
 			case types.OutputTypeMP4:
-				require.Equal(t, "h264", stream.CodecName)
-
+				// codec already verified above, mp4 also carries h265, vp9 and av1
 				if p.VideoEncoding {
 					// bitrate, not available for HLS or WebM
 					bitrate, err := strconv.Atoi(stream.BitRate)
@@ -92,6 +92,69 @@ func (r *Runner) testFile(t *testing.T) {
 				},
 			},
 
+			{
+				name:        "RoomComposite/AudioOnlyWAV",
+				requestType: types.RequestTypeRoomComposite,
+				publishOptions: publishOptions{
+					audioCodec: types.MimeTypeOpus,
+					audioOnly:  true,
+				},
+				fileOptions: &fileOptions{
+					filename: "r_{room_name}_audio_{time}.wav",
+				},
+			},
+			{
+				name:        "RoomComposite/AudioOnlyFLAC",
+				requestType: types.RequestTypeRoomComposite,
+				publishOptions: publishOptions{
+					audioCodec: types.MimeTypeOpus,
+					audioOnly:  true,
+				},
+				fileOptions: &fileOptions{
+					filename: "r_{room_name}_audio_{time}.flac",
+				},
+			},
+
+			{
+				name:        "RoomComposite/VP9",
+				requestType: types.RequestTypeRoomComposite, publishOptions: publishOptions{
+					audioCodec: types.MimeTypeOpus,
+					videoCodec: types.MimeTypeVP8,
+					layout:     layoutSpeaker,
+				},
+				encodingOptions: &livekit.EncodingOptions{
+					VideoCodec: livekit.VideoCodec_VP9,
+				},
+				fileOptions: &fileOptions{
+					filename: "r_{room_name}_vp9_{time}.webm",
+				},
+			},
+			{
+				name:        "RoomComposite/AV1",
+				requestType: types.RequestTypeRoomComposite, publishOptions: publishOptions{
+					audioCodec: types.MimeTypeOpus,
+					videoCodec: types.MimeTypeH264,
+					layout:     layoutSpeaker,
+				},
+				encodingOptions: &livekit.EncodingOptions{
+					VideoCodec: livekit.VideoCodec_AV1,
+				},
+				fileOptions: &fileOptions{
+					filename: "r_{room_name}_av1_{time}.mp4",
+				},
+			},
+			{
+				name:        "RoomComposite/MKV",
+				requestType: types.RequestTypeRoomComposite, publishOptions: publishOptions{
+					audioCodec: types.MimeTypeOpus,
+					videoCodec: types.MimeTypeH264,
+					layout:     layoutSpeaker,
+				},
+				fileOptions: &fileOptions{
+					filename: "r_{room_name}_mkv_{time}.mkv",
+				},
+			},
+
 			// ---------- Web ----------
 
 			{
@@ -56,6 +56,31 @@ func (r *Runner) testImages(t *testing.T) {
 				},
 			},
 
+			{
+				name:        "RoomComposite/PNG",
+				requestType: types.RequestTypeRoomComposite,
+				publishOptions: publishOptions{
+					audioCodec: types.MimeTypeOpus,
+					videoCodec: types.MimeTypeH264,
+					layout:     "speaker",
+				},
+				imageOptions: &imageOptions{
+					prefix: "r_{room_name}_{time}.png",
+				},
+			},
+			{
+				name:        "RoomComposite/WebP",
+				requestType: types.RequestTypeRoomComposite,
+				publishOptions: publishOptions{
+					audioCodec: types.MimeTypeOpus,
+					videoCodec: types.MimeTypeH264,
+					layout:     "speaker",
+				},
+				imageOptions: &imageOptions{
+					prefix: "r_{room_name}_{time}.webp",
+				},
+			},
+
 			// ---- Track Composite ----
 
 			{
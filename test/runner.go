@@ -172,6 +172,8 @@ func NewRunner(t *testing.T) *Runner {
 	conf.AudioTempoController.AdjustmentRate = 0.05
 	// short grace so the pulse sink reaper edge case completes quickly
 	conf.PulseSinkReapGraceSec = 3
+	// the srt listener test pulls from this node
+	conf.SRTListener = config.SRTListenerConfig{Address: "localhost", PortMin: 9010, PortMax: 9019}
 
 	r.ServiceConfig = conf
 
@@ -65,6 +65,41 @@ func (r *Runner) testSegments(t *testing.T) {
 					suffix:       livekit.SegmentedFileSuffix_INDEX,
 				},
 			},
+			{
+				name:        "RoomComposite/H265",
+				requestType: types.RequestTypeRoomComposite,
+				publishOptions: publishOptions{
+					audioCodec: types.MimeTypeOpus,
+					videoCodec: types.MimeTypeVP8,
+					layout:     layoutSpeaker,
+				},
+				encodingOptions: &livekit.EncodingOptions{
+					AudioCodec: livekit.AudioCodec_AAC,
+					VideoCodec: livekit.VideoCodec_H265_MAIN,
+				},
+				segmentOptions: &segmentOptions{
+					prefix:   "r_{room_name}_h265_{time}",
+					playlist: "r_{room_name}_h265_{time}.m3u8",
+					suffix:   livekit.SegmentedFileSuffix_INDEX,
+				},
+			},
+			{
+				name:        "RoomComposite/CMAF",
+				requestType: types.RequestTypeRoomComposite,
+				publishOptions: publishOptions{
+					audioCodec: types.MimeTypeOpus,
+					videoCodec: types.MimeTypeVP8,
+					layout:     layoutSpeaker,
+				},
+				encodingOptions: &livekit.EncodingOptions{
+					AudioCodec: livekit.AudioCodec_OPUS,
+				},
+				segmentOptions: &segmentOptions{
+					prefix:   "r_{room_name}_cmaf_{time}",
+					playlist: "r_{room_name}_cmaf_{time}.m3u8?container=fmp4",
+					suffix:   livekit.SegmentedFileSuffix_INDEX,
+				},
+			},
 			{
 				name:        "RoomComposite/AudioOnly",
 				requestType: types.RequestTypeRoomComposite,
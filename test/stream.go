@@ -43,6 +43,8 @@ const (
 	badRtmpUrl2Redacted = "rtmp://localhost:1936/live/{st...am}"
 	badSrtUrl1          = "srt://localhost:8891?streamid=publish:wrongport&pkt_size=1316"
 	badSrtUrl2          = "srt://localhost:8891?streamid=publish:badstream&pkt_size=1316"
+	badRtspUrl1         = "rtsp://localhost:8555/wrongport"
+	badRtspUrl2         = "rtsp://localhost:8555/badstream"
 )
 
 var (
@@ -56,6 +58,11 @@ var (
 	srtReadUrl1         = fmt.Sprintf("srt://localhost:8890?streamid=read:%s", streamKey1)
 	srtPublishUrl2      = fmt.Sprintf("srt://localhost:8890?streamid=publish:%s&pkt_size=1316", streamKey2)
 	srtReadUrl2         = fmt.Sprintf("srt://localhost:8890?streamid=read:%s", streamKey2)
+	srtListenerUrl      = "srt://:9010?mode=listener&passphrase=egresspassphrase&pbkeylen=16"
+	srtListenerRedacted = "srt://localhost:9010?mode=listener&passphrase={egr...ase}&pbkeylen=16"
+	srtListenerReadUrl  = "srt://localhost:9010?mode=caller&passphrase=egresspassphrase&pbkeylen=16"
+	rtspUrl1            = fmt.Sprintf("rtsp://localhost:8554/%s", streamKey1)
+	rtspUrl2            = fmt.Sprintf("rtsp://localhost:8554/%s", streamKey2)
 )
 
 // [[publish, redacted, verification]]
@@ -72,6 +79,12 @@ var streamUrls = map[types.OutputType][][]string{
 		{srtPublishUrl2, srtPublishUrl2, srtReadUrl2},
 		{badSrtUrl2, badSrtUrl2, ""},
 	},
+	types.OutputTypeRTSP: {
+		{rtspUrl1, rtspUrl1, rtspUrl1},
+		{badRtspUrl1, badRtspUrl1, ""},
+		{rtspUrl2, rtspUrl2, rtspUrl2},
+		{badRtspUrl2, badRtspUrl2, ""},
+	},
 }
 
 func (r *Runner) testStream(t *testing.T) {
@@ -161,6 +174,44 @@ func (r *Runner) testStream(t *testing.T) {
 					outputType: types.OutputTypeRTMP,
 				},
 			},
+			{
+				name:        "TrackComposite/RTSP",
+				requestType: types.RequestTypeTrackComposite,
+				publishOptions: publishOptions{
+					audioCodec: types.MimeTypeOpus,
+					videoCodec: types.MimeTypeVP8,
+				},
+				streamOptions: &streamOptions{
+					streamUrls: []string{rtspUrl1, badRtspUrl1},
+					outputType: types.OutputTypeRTSP,
+				},
+			},
+			{
+				name:        "TrackComposite/WHIP",
+				requestType: types.RequestTypeTrackComposite,
+				publishOptions: publishOptions{
+					audioCodec: types.MimeTypeOpus,
+					videoCodec: types.MimeTypeVP8,
+				},
+				streamOptions: &streamOptions{
+					outputType: types.OutputTypeWHIP,
+				},
+				custom: r.runWHIPTest,
+			},
+
+			{
+				name:        "TrackComposite/SRTListener",
+				requestType: types.RequestTypeTrackComposite,
+				publishOptions: publishOptions{
+					audioCodec: types.MimeTypeOpus,
+					videoCodec: types.MimeTypeVP8,
+				},
+				streamOptions: &streamOptions{
+					streamUrls: []string{srtListenerUrl},
+					outputType: types.OutputTypeSRT,
+				},
+				custom: r.runSRTListenerTest,
+			},
 
 			// --------- Track ---------
 
@@ -232,6 +283,24 @@ func (r *Runner) verifyStreams(t *testing.T, tc *testCase, p *config.PipelineCon
 	}
 }
 
+// runSRTListenerTest waits for egress to listen, then pulls the encrypted stream as a caller
+func (r *Runner) runSRTListenerTest(t *testing.T, test *testCase) {
+	req := r.build(test)
+
+	egressID := r.startEgress(t, req)
+
+	p, err := config.GetValidatedPipelineConfig(r.ServiceConfig, req)
+	require.NoError(t, err)
+
+	time.Sleep(time.Second * 5)
+	r.verifyStreams(t, test, p, srtListenerReadUrl)
+
+	res := r.stopEgress(t, egressID)
+	require.Len(t, res.StreamResults, 1)
+	require.Equal(t, srtListenerRedacted, res.StreamResults[0].Url)
+	require.Equal(t, livekit.StreamInfo_FINISHED, res.StreamResults[0].Status)
+}
+
 func (r *Runner) runWebsocketTest(t *testing.T, test *testCase) {
 	filepath := path.Join(r.FilePrefix, test.rawFileName)
 	wss := newTestWebsocketServer(filepath)
@@ -0,0 +1,204 @@
+// Copyright 2026 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build integration
+
+package test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+)
+
+const whipTestToken = "whiptoken"
+
+// runWHIPTest publishes to a local pion whip endpoint, drops the session once media is flowing,
+// and checks that the egress negotiates a new one
+func (r *Runner) runWHIPTest(t *testing.T, test *testCase) {
+	server := newWHIPTestServer(whipTestToken)
+	s := httptest.NewServer(server)
+	defer s.Close()
+
+	whipUrl := fmt.Sprintf("whip://%s@%s/whip", whipTestToken, strings.TrimPrefix(s.URL, "http://"))
+	test.streamUrls = []string{whipUrl}
+
+	req := r.build(test)
+	egressID := r.startEgress(t, req)
+
+	// audio and video are received
+	require.Eventually(t, func() bool {
+		return server.received(webrtc.MimeTypeOpus) > 0 && server.received(webrtc.MimeTypeH264) > 0
+	}, 30*time.Second, 100*time.Millisecond)
+
+	// the egress renegotiates when the session is dropped
+	server.dropSessions()
+	require.Eventually(t, func() bool {
+		return server.postCount() > 1 && server.sessionCount() == 1
+	}, 45*time.Second, 100*time.Millisecond)
+	received := server.received(webrtc.MimeTypeH264)
+	require.Eventually(t, func() bool {
+		return server.received(webrtc.MimeTypeH264) > received
+	}, 10*time.Second, 100*time.Millisecond)
+
+	res := r.stopEgress(t, egressID)
+	require.Len(t, res.StreamResults, 1)
+	require.Equal(t, livekit.StreamInfo_FINISHED, res.StreamResults[0].Status)
+	require.NotContains(t, res.StreamResults[0].Url, whipTestToken)
+
+	// the session is deleted when the egress ends
+	require.Equal(t, 0, server.sessionCount())
+}
+
+// whipTestServer is a minimal whip endpoint, which answers offers with pion and counts the packets it receives
+type whipTestServer struct {
+	token string
+
+	mu       sync.Mutex
+	posts    int
+	sessions map[string]*webrtc.PeerConnection
+	packets  map[string]int
+}
+
+func newWHIPTestServer(token string) *whipTestServer {
+	return &whipTestServer{
+		token:    token,
+		sessions: make(map[string]*webrtc.PeerConnection),
+		packets:  make(map[string]int),
+	}
+}
+
+func (s *whipTestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+s.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		offer, err := io.ReadAll(r.Body)
+		if err != nil || r.Header.Get("Content-Type") != "application/sdp" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		resource, answer, err := s.createSession(string(offer))
+		if err != nil {
+			logger.Errorw("could not create whip session", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/sdp")
+		w.Header().Set("Location", resource)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(answer))
+
+	case http.MethodDelete:
+		s.mu.Lock()
+		pc, ok := s.sessions[r.URL.Path]
+		delete(s.sessions, r.URL.Path)
+		s.mu.Unlock()
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = pc.Close()
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *whipTestServer) createSession(offer string) (string, string, error) {
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	if err != nil {
+		return "", "", err
+	}
+	pc.OnTrack(func(track *webrtc.TrackRemote, _ *webrtc.RTPReceiver) {
+		for {
+			if _, _, err := track.ReadRTP(); err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.packets[track.Codec().MimeType]++
+			s.mu.Unlock()
+		}
+	})
+
+	if err = pc.SetRemoteDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: offer}); err != nil {
+		_ = pc.Close()
+		return "", "", err
+	}
+	answer, err := pc.CreateAnswer(nil)
+	if err != nil {
+		_ = pc.Close()
+		return "", "", err
+	}
+	gathered := webrtc.GatheringCompletePromise(pc)
+	if err = pc.SetLocalDescription(answer); err != nil {
+		_ = pc.Close()
+		return "", "", err
+	}
+	<-gathered
+
+	s.mu.Lock()
+	s.posts++
+	resource := fmt.Sprintf("/whip/session/%d", s.posts)
+	s.sessions[resource] = pc
+	s.mu.Unlock()
+
+	return resource, pc.LocalDescription().SDP, nil
+}
+
+// dropSessions closes every session without the client deleting it, as if the endpoint restarted
+func (s *whipTestServer) dropSessions() {
+	s.mu.Lock()
+	sessions := s.sessions
+	s.sessions = make(map[string]*webrtc.PeerConnection)
+	s.mu.Unlock()
+
+	for _, pc := range sessions {
+		_ = pc.Close()
+	}
+}
+
+func (s *whipTestServer) received(mimeType string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.packets[mimeType]
+}
+
+func (s *whipTestServer) postCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.posts
+}
+
+func (s *whipTestServer) sessionCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sessions)
+}